@@ -0,0 +1,87 @@
+// Package assistant produces conversational replies for SMS messages that
+// match no command. Implementations receive the sender's recent history and
+// must keep replies short enough for SMS.
+package assistant
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Roles of a Turn.
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Turn is one message in a conversation.
+type Turn struct {
+	Role    string
+	Content string
+}
+
+// Request is the input to an Assistant.
+type Request struct {
+	// Name is the sender's first name, if known.
+	Name string
+	// History is the conversation so far, oldest first. The last turn is
+	// the message being answered.
+	History []Turn
+	// MaxChars is the longest reply that fits the SMS budget.
+	MaxChars int
+}
+
+// Assistant answers a conversation.
+type Assistant interface {
+	Reply(ctx context.Context, req *Request) (string, error)
+}
+
+// Defaults for budgeting a conversation.
+const (
+	DefaultMaxChars      = 320  // two GSM-7 segments
+	DefaultHistoryTokens = 1000 // prompt budget for history
+)
+
+// EstimateTokens approximates a text's token count (about four characters
+// per token for English).
+func EstimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
+
+// TrimHistory drops the oldest turns until the rest fit within maxTokens.
+// The latest turn is always kept.
+func TrimHistory(history []Turn, maxTokens int) []Turn {
+	total := 0
+	for i := len(history) - 1; i >= 0; i-- {
+		total += EstimateTokens(history[i].Content)
+		if total > maxTokens && i < len(history)-1 {
+			return history[i+1:]
+		}
+	}
+	return history
+}
+
+// ellipsis marks truncated replies. "…" is avoided because it is not in the
+// GSM-7 alphabet and would force the whole SMS into UCS-2.
+const ellipsis = "..."
+
+// Truncate shortens s to at most max characters, cutting at a word boundary
+// and appending "..." when anything was removed.
+func Truncate(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if max <= len(ellipsis) || utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	keep := max - len(ellipsis)
+	cut := string(runes[:keep])
+	// Back up to the last word boundary unless the cut already falls on one.
+	if !unicode.IsSpace(runes[keep]) {
+		if i := strings.LastIndexAny(cut, " \n"); i > len(cut)/2 {
+			cut = cut[:i]
+		}
+	}
+	return strings.TrimRight(cut, " \n.,;:") + ellipsis
+}
@@ -0,0 +1,120 @@
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"short reply", 20, "short reply"},
+		{"  padded  ", 20, "padded"},
+		{"the quick brown fox jumps over the lazy dog", 20, "the quick brown..."},
+		{"the quick brown fox jumps over the lazy dog", 22, "the quick brown fox..."},
+		{"abcdefghijklmnopqrstuvwxyz", 10, "abcdefg..."},
+		{"anything", 0, "anything"},
+	}
+	for _, tt := range tests {
+		got := Truncate(tt.in, tt.max)
+		if got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+		if tt.max > 0 && utf8.RuneCountInString(got) > tt.max {
+			t.Errorf("Truncate(%q, %d) is %d chars", tt.in, tt.max, utf8.RuneCountInString(got))
+		}
+	}
+}
+
+func TestTrimHistory(t *testing.T) {
+	history := []Turn{
+		{RoleUser, strings.Repeat("a", 40)},      // 10 tokens
+		{RoleAssistant, strings.Repeat("b", 40)}, // 10 tokens
+		{RoleUser, strings.Repeat("c", 40)},      // 10 tokens
+	}
+	if got := TrimHistory(history, 25); len(got) != 2 || got[0].Content[0] != 'b' {
+		t.Errorf("TrimHistory(25) kept %d turns starting %q", len(got), got[0].Content[:1])
+	}
+	if got := TrimHistory(history, 100); len(got) != 3 {
+		t.Errorf("TrimHistory(100) kept %d turns, want 3", len(got))
+	}
+	// The latest turn survives even when it alone is over budget.
+	if got := TrimHistory(history, 1); len(got) != 1 || got[0].Content[0] != 'c' {
+		t.Errorf("TrimHistory(1) = %v, want only the latest turn", got)
+	}
+}
+
+func TestStub(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{"Tex", "hey there", "Hi Tex! Text HELP to see what I can do."},
+		{"", "Hello!", "Hi there! Text HELP to see what I can do."},
+		{"Tex", "thanks so much", "You're welcome, Tex!"},
+		{"Tex", "what is the capital of peru", "I'm nexus, a personal assistant you text. I can save notes, find them again and set reminders. Text HELP for commands."},
+		{"Tex", "purple monkey dishwasher", stubDefault},
+		{"Tex", "think", stubDefault}, // no partial-word matches
+	}
+	for _, tt := range tests {
+		got, err := Stub{}.Reply(context.Background(), &Request{
+			Name:     tt.name,
+			History:  []Turn{{RoleUser, "earlier"}, {RoleUser, tt.text}},
+			MaxChars: DefaultMaxChars,
+		})
+		if err != nil || got != tt.want {
+			t.Errorf("Stub(%q) = %q, %v; want %q", tt.text, got, err, tt.want)
+		}
+	}
+}
+
+func TestOpenAI(t *testing.T) {
+	var got chatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]string{"message": "bad key"}})
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []interface{}{map[string]interface{}{
+				"message": map[string]string{"role": "assistant", "content": strings.Repeat("long answer ", 10)},
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	req := &Request{
+		Name:     "Tex",
+		History:  []Turn{{RoleUser, "hi"}, {RoleAssistant, "hello!"}, {RoleUser, "tell me a story"}},
+		MaxChars: 40,
+	}
+	reply, err := NewOpenAI(srv.URL+"/v1", "sk-test", "test-model").Reply(context.Background(), req)
+	if err != nil {
+		t.Fatalf("reply: %v", err)
+	}
+	if utf8.RuneCountInString(reply) > 40 || !strings.HasSuffix(reply, "...") {
+		t.Errorf("reply not truncated to budget: %q", reply)
+	}
+	if got.Model != "test-model" || len(got.Messages) != 4 || got.Messages[0].Role != "system" {
+		t.Fatalf("unexpected request: %+v", got)
+	}
+	if !strings.Contains(got.Messages[0].Content, "at most 40 characters") || !strings.Contains(got.Messages[0].Content, "Tex") {
+		t.Errorf("system prompt = %q", got.Messages[0].Content)
+	}
+	if got.Messages[3].Content != "tell me a story" {
+		t.Errorf("last message = %+v", got.Messages[3])
+	}
+
+	if _, err := NewOpenAI(srv.URL+"/v1", "wrong", "m").Reply(context.Background(), req); err == nil || !strings.Contains(err.Error(), "bad key") {
+		t.Errorf("expected API error, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package assistant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultSystemPrompt instructs the model to answer like an SMS assistant.
+const DefaultSystemPrompt = "You are nexus, a personal assistant people talk to by text message. " +
+	"Answer briefly in plain text (no markdown), in at most %d characters."
+
+// OpenAI is an Assistant backed by an OpenAI-compatible chat completions
+// API (OpenAI, or a local server such as Ollama or llama.cpp).
+type OpenAI struct {
+	baseURL, apiKey, model string
+
+	// SystemPrompt is formatted with the reply budget in characters.
+	SystemPrompt string
+
+	http *http.Client
+}
+
+// NewOpenAI creates an OpenAI-compatible client. baseURL includes the API
+// version prefix, e.g. https://api.openai.com/v1.
+func NewOpenAI(baseURL, apiKey, model string) *OpenAI {
+	return &OpenAI{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		apiKey:       apiKey,
+		model:        model,
+		SystemPrompt: DefaultSystemPrompt,
+		http:         &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model     string        `json:"model"`
+	Messages  []chatMessage `json:"messages"`
+	MaxTokens int           `json:"max_tokens,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Reply implements Assistant. The reply is truncated to req.MaxChars in
+// case the model overshoots.
+func (o *OpenAI) Reply(ctx context.Context, req *Request) (string, error) {
+	maxChars := req.MaxChars
+	if maxChars <= 0 {
+		maxChars = DefaultMaxChars
+	}
+
+	system := fmt.Sprintf(o.SystemPrompt, maxChars)
+	if req.Name != "" {
+		system += " The user's name is " + req.Name + "."
+	}
+	body := chatRequest{
+		Model:     o.model,
+		Messages:  []chatMessage{{Role: "system", Content: system}},
+		MaxTokens: maxChars/4 + 16, // ~4 chars per token, plus slack
+	}
+	for _, t := range req.History {
+		body.Messages = append(body.Messages, chatMessage{Role: t.Role, Content: t.Content})
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return "", err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", &buf)
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.http.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("chat completion: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("chat completion: status %d: decode: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg := ""
+		if out.Error != nil {
+			msg = out.Error.Message
+		}
+		return "", fmt.Errorf("chat completion: status %d: %s", resp.StatusCode, msg)
+	}
+	if len(out.Choices) == 0 {
+		return "", fmt.Errorf("chat completion: no choices")
+	}
+	return Truncate(out.Choices[0].Message.Content, maxChars), nil
+}
@@ -0,0 +1,51 @@
+package assistant
+
+import (
+	"context"
+	"strings"
+)
+
+// Stub is a deterministic, rule-based Assistant. It needs no network access
+// and is the default when no model is configured.
+type Stub struct{}
+
+// stubRules are checked in order; the first whose keyword appears as a word
+// in the message wins. "%s" in a reply is replaced with the sender's name
+// ("there" if unknown).
+var stubRules = []struct {
+	keywords []string
+	reply    string
+}{
+	{[]string{"hi", "hello", "hey", "yo"}, "Hi %s! Text HELP to see what I can do."},
+	{[]string{"thanks", "thank", "thx", "ty"}, "You're welcome, %s!"},
+	{[]string{"who", "what"}, "I'm nexus, a personal assistant you text. I can save notes, find them again and set reminders. Text HELP for commands."},
+	{[]string{"bye", "goodnight", "later"}, "Talk soon, %s."},
+}
+
+// stubDefault answers anything no rule matches.
+const stubDefault = "I'm not sure how to help with that yet. Text HELP for a list of commands."
+
+// Reply implements Assistant.
+func (Stub) Reply(ctx context.Context, req *Request) (string, error) {
+	if len(req.History) == 0 {
+		return stubDefault, nil
+	}
+	name := req.Name
+	if name == "" {
+		name = "there"
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(req.History[len(req.History)-1].Content), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || r == '\'')
+	})
+	for _, rule := range stubRules {
+		for _, kw := range rule.keywords {
+			for _, w := range words {
+				if w == kw {
+					return Truncate(strings.ReplaceAll(rule.reply, "%s", name), req.MaxChars), nil
+				}
+			}
+		}
+	}
+	return stubDefault, nil
+}
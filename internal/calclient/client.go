@@ -0,0 +1,125 @@
+// Package calclient is a small HTTP client for the nexus-cal management API
+// (services/cal).
+package calclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client talks to a nexus-cal server.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// New creates a Client for the nexus-cal server at baseURL
+// (e.g. https://nexus-cal-dev.example.com).
+func New(baseURL string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Feed is a calendar feed as returned by POST /api/feeds.
+type Feed struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Token string `json:"token"`
+	URL   string `json:"url"` // path of the .ics feed, relative to the cal server
+}
+
+// Event mirrors the nexus-cal event JSON.
+type Event struct {
+	ID          string     `json:"id,omitempty"`
+	FeedID      string     `json:"feed_id"`
+	Summary     string     `json:"summary"`
+	Description string     `json:"description,omitempty"`
+	Location    string     `json:"location,omitempty"`
+	URL         string     `json:"url,omitempty"`
+	Start       time.Time  `json:"start"`
+	End         *time.Time `json:"end,omitempty"`
+	AllDay      bool       `json:"all_day"`
+	Deadline    *time.Time `json:"deadline,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	Categories  string     `json:"categories,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"` // set by the server
+}
+
+// CreateFeed creates a new calendar feed with an unguessable token.
+func (c *Client) CreateFeed(ctx context.Context, name string) (*Feed, error) {
+	var feed Feed
+	if err := c.do(ctx, http.MethodPost, "/api/feeds", map[string]string{"name": name}, &feed); err != nil {
+		return nil, fmt.Errorf("create feed: %w", err)
+	}
+	return &feed, nil
+}
+
+// CreateEvent adds an event to a feed and returns the stored event.
+func (c *Client) CreateEvent(ctx context.Context, e *Event) (*Event, error) {
+	var created Event
+	if err := c.do(ctx, http.MethodPost, "/api/events", e, &created); err != nil {
+		return nil, fmt.Errorf("create event: %w", err)
+	}
+	return &created, nil
+}
+
+// ListEvents returns all events in a feed, ordered by start time.
+func (c *Client) ListEvents(ctx context.Context, feedID string) ([]Event, error) {
+	var events []Event
+	if err := c.do(ctx, http.MethodGet, "/api/feeds/"+feedID+"/events", nil, &events); err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+	return events, nil
+}
+
+// SubscribeURL returns the webcal:// URL calendar apps use to subscribe to
+// the feed with the given token.
+func (c *Client) SubscribeURL(token string) string {
+	host := c.baseURL
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	return "webcal://" + host + "/" + token + ".ics"
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, &buf)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("%s %s: status %d: %s", method, path, resp.StatusCode, apiErr.Error)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
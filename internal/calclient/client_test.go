@@ -0,0 +1,75 @@
+package calclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateFeedAndEvent(t *testing.T) {
+	var gotEvent map[string]interface{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/feeds", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Feed{ID: "feed-1", Name: "nexus reminders", Token: "tok-123", URL: "/tok-123.ics"})
+	})
+	mux.HandleFunc("POST /api/events", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&gotEvent); err != nil {
+			t.Errorf("decode event: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"id": "event-1", "feed_id": "feed-1", "summary": "dentist"})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New(srv.URL)
+	ctx := context.Background()
+
+	feed, err := c.CreateFeed(ctx, "nexus reminders")
+	if err != nil {
+		t.Fatalf("create feed: %v", err)
+	}
+	if feed.ID != "feed-1" || feed.Token != "tok-123" {
+		t.Errorf("unexpected feed: %+v", feed)
+	}
+
+	start := time.Date(2026, 3, 6, 15, 0, 0, 0, time.UTC)
+	created, err := c.CreateEvent(ctx, &Event{FeedID: feed.ID, Summary: "dentist", Start: start, Deadline: &start})
+	if err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	if created.ID != "event-1" {
+		t.Errorf("unexpected event ID %q", created.ID)
+	}
+	if gotEvent["start"] != "2026-03-06T15:00:00Z" || gotEvent["deadline"] != "2026-03-06T15:00:00Z" {
+		t.Errorf("start/deadline not sent as RFC 3339: %v", gotEvent)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "name is required"})
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL).CreateFeed(context.Background(), "")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got := err.Error(); got != "create feed: POST /api/feeds: status 400: name is required" {
+		t.Errorf("unexpected error: %s", got)
+	}
+}
+
+func TestSubscribeURL(t *testing.T) {
+	c := New("https://cal.example.com/")
+	if got, want := c.SubscribeURL("tok-123"), "webcal://cal.example.com/tok-123.ics"; got != want {
+		t.Errorf("SubscribeURL = %q, want %q", got, want)
+	}
+}
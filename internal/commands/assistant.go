@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jredh-dev/nexus/internal/assistant"
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+// ask answers a free-form message with the assistant, giving it the recent
+// turns of the sender's conversation thread.
+func (c *Commands) ask(ctx context.Context, msg *sms.Message) (string, error) {
+	history := make([]assistant.Turn, 0, len(msg.History)+1)
+	for _, t := range msg.History {
+		role := assistant.RoleAssistant
+		if t.Inbound {
+			role = assistant.RoleUser
+		}
+		history = append(history, assistant.Turn{Role: role, Content: t.Body})
+	}
+	if len(history) == 0 {
+		history = append(history, assistant.Turn{Role: assistant.RoleUser, Content: msg.Body})
+	}
+
+	req := &assistant.Request{
+		History:  assistant.TrimHistory(history, assistant.DefaultHistoryTokens),
+		MaxChars: c.maxReply,
+	}
+	if msg.Account != nil {
+		req.Name = msg.Account.FirstName()
+	}
+	reply, err := c.assistant.Reply(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("assistant: %w", err)
+	}
+	return assistant.Truncate(reply, c.maxReply), nil
+}
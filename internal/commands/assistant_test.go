@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jredh-dev/nexus/internal/assistant"
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+// echoAssistant records requests and replies with a long echo.
+type echoAssistant struct {
+	requests []*assistant.Request
+}
+
+func (e *echoAssistant) Reply(ctx context.Context, req *assistant.Request) (string, error) {
+	e.requests = append(e.requests, req)
+	return "you said " + strings.Repeat(req.History[len(req.History)-1].Content+" ", 20), nil
+}
+
+func TestFallbackAsksAssistant(t *testing.T) {
+	db, err := database.Open(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	defer db.Close()
+
+	bot := &echoAssistant{}
+	r := sms.NewRouter()
+	New(db, Options{Assistant: bot, MaxReplyChars: 60}).Register(r)
+
+	// The SMS handler attaches the thread's recent turns.
+	msg := sms.ParseMessage(url.Values{"From": {"+15555555555"}, "Body": {"how are you"}})
+	msg.Account = &sms.Account{ID: "user-1", Name: "Tex Ter"}
+	msg.History = []sms.Turn{
+		{Inbound: true, Body: "hello"},
+		{Inbound: false, Body: "Hi Tex!"},
+		{Inbound: true, Body: "how are you"},
+	}
+	reply, err := r.Dispatch(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if len([]rune(reply)) > 60 || !strings.HasPrefix(reply, "you said how are you") {
+		t.Errorf("reply not budgeted: %q", reply)
+	}
+
+	if len(bot.requests) != 1 {
+		t.Fatalf("assistant called %d times, want 1", len(bot.requests))
+	}
+	req := bot.requests[0]
+	if req.Name != "Tex" || req.MaxChars != 60 {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	want := []assistant.Turn{
+		{Role: assistant.RoleUser, Content: "hello"},
+		{Role: assistant.RoleAssistant, Content: "Hi Tex!"},
+		{Role: assistant.RoleUser, Content: "how are you"},
+	}
+	if len(req.History) != len(want) {
+		t.Fatalf("history = %+v, want %+v", req.History, want)
+	}
+	for i := range want {
+		if req.History[i] != want[i] {
+			t.Errorf("history[%d] = %+v, want %+v", i, req.History[i], want[i])
+		}
+	}
+
+	// Without handler-provided history the message itself is the only turn.
+	send(t, r, "+15555555555", "anyone there")
+	if got := bot.requests[1].History; len(got) != 1 || got[0].Content != "anyone there" {
+		t.Errorf("history without thread = %+v", got)
+	}
+
+	// Commands never reach the assistant.
+	send(t, r, "+15555555555", "LIST")
+	if len(bot.requests) != 2 {
+		t.Errorf("command was sent to the assistant")
+	}
+}
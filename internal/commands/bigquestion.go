@@ -0,0 +1,244 @@
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+const (
+	// defaultBigQMinute is when the Big Question goes out if BIGQ ON is
+	// sent without a time: 7pm local.
+	defaultBigQMinute = 19 * 60
+
+	// contributionPoints are credited for every answer shared as training
+	// data.
+	contributionPoints = 10
+)
+
+const bigQUsage = "Usage: BIGQ ON [time], BIGQ OFF, BIGQ TZ <zone>, BIGQ SHARE or BIGQ PRIVATE"
+
+// bigq manages the sender's Big Question subscription: a question a day at
+// their chosen local time, and whether their answers may be shared.
+// BIGQ [ON [time] | OFF | TZ <zone> | SHARE | PRIVATE]
+func (c *Commands) bigq(ctx context.Context, msg *sms.Message) (string, error) {
+	sub, arg, _ := strings.Cut(strings.TrimSpace(msg.Args), " ")
+	arg = strings.TrimSpace(arg)
+
+	s, err := c.db.BigQSubscriber(msg.Phone)
+	if err != nil {
+		return "", fmt.Errorf("lookup big question subscriber: %w", err)
+	}
+
+	switch strings.ToUpper(sub) {
+	case "":
+		return bigQStatus(s), nil
+	case "ON":
+		minute := defaultBigQMinute
+		if arg != "" {
+			parsed, err := c.dates.Parse(arg)
+			if err != nil || !parsed.HasClock || parsed.Recurrence != nil {
+				return "Usage: BIGQ ON [time], e.g. BIGQ ON 8:30pm", nil
+			}
+			minute = parsed.Time.Hour()*60 + parsed.Time.Minute()
+		}
+		if s == nil {
+			if s, err = c.newBigQSubscriber(msg.Phone); err != nil {
+				return "", err
+			}
+		}
+		s.Active = true
+		s.SendMinute = minute
+	case "OFF":
+		if s == nil || !s.Active {
+			return "You aren't getting the Big Question. Text BIGQ ON to start.", nil
+		}
+		s.Active = false
+	case "TZ":
+		loc, err := time.LoadLocation(arg)
+		if arg == "" || err != nil {
+			return "Usage: BIGQ TZ <zone>, e.g. BIGQ TZ America/New_York", nil
+		}
+		if s == nil {
+			return "Text BIGQ ON to start getting the Big Question first.", nil
+		}
+		s.Timezone = loc.String()
+	case "SHARE":
+		if s == nil {
+			return "Text BIGQ ON to start getting the Big Question first.", nil
+		}
+		if !s.Consent {
+			now := c.now().UTC()
+			s.Consent, s.ConsentAt = true, &now
+		}
+	case "PRIVATE":
+		if s == nil {
+			return "Text BIGQ ON to start getting the Big Question first.", nil
+		}
+		s.Consent, s.ConsentAt = false, nil
+	default:
+		return bigQUsage, nil
+	}
+
+	if err := c.db.SaveBigQSubscriber(s); err != nil {
+		return "", fmt.Errorf("save big question subscriber: %w", err)
+	}
+	return bigQStatus(s), nil
+}
+
+// newBigQSubscriber returns an inactive, non-sharing subscription for phone
+// in the default time zone, with a fresh contributor pseudonym.
+func (c *Commands) newBigQSubscriber(phone string) (*database.BigQSubscriber, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return &database.BigQSubscriber{
+		Phone:         phone,
+		Timezone:      c.dates.Location.String(),
+		SendMinute:    defaultBigQMinute,
+		ContributorID: "c_" + hex.EncodeToString(b),
+		CreatedAt:     c.now().UTC(),
+	}, nil
+}
+
+// bigQStatus describes a subscription.
+func bigQStatus(s *database.BigQSubscriber) string {
+	if s == nil {
+		return "The Big Question is one question a day by text. Text BIGQ ON [time] to start, e.g. BIGQ ON 8pm."
+	}
+	sharing := "Your answers are private. Text BIGQ SHARE to share them as training data and earn points."
+	if s.Consent {
+		sharing = fmt.Sprintf("You're sharing your answers as training data (+%d points each). Text BIGQ PRIVATE to stop.", contributionPoints)
+	}
+	if !s.Active {
+		return "The Big Question is off. Text BIGQ ON to restart. " + sharing
+	}
+	at := time.Date(2000, 1, 1, s.SendMinute/60, s.SendMinute%60, 0, 0, time.UTC)
+	return fmt.Sprintf("Big Question is on: daily at %s (%s). %s", at.Format("3:04 PM"), s.Timezone, sharing)
+}
+
+// answer records the sender's answer to the last Big Question they were
+// sent, crediting points if they share their answers.
+// ANSWER <text>
+func (c *Commands) answer(ctx context.Context, msg *sms.Message) (string, error) {
+	if msg.Args == "" {
+		return "Usage: ANSWER <your answer>", nil
+	}
+	prompt, question, err := c.db.LatestBigQPrompt(msg.Phone)
+	if err != nil {
+		return "", fmt.Errorf("lookup big question prompt: %w", err)
+	}
+	if prompt == nil {
+		return "There's no question to answer yet. Text BIGQ ON to get the Big Question every day.", nil
+	}
+	s, err := c.db.BigQSubscriber(msg.Phone)
+	if err != nil {
+		return "", fmt.Errorf("lookup big question subscriber: %w", err)
+	}
+
+	a := &database.BigQAnswer{
+		PromptID:   prompt.ID,
+		Phone:      msg.Phone,
+		QuestionID: prompt.QuestionID,
+		Body:       msg.Args,
+		Consent:    s != nil && s.Consent,
+		CreatedAt:  c.now().UTC(),
+	}
+	points := 0
+	if a.Consent {
+		points = contributionPoints
+	}
+	fresh, err := c.db.RecordBigQAnswer(a, points, "big question contribution")
+	if err != nil {
+		return "", fmt.Errorf("record big question answer: %w", err)
+	}
+	if !fresh {
+		return fmt.Sprintf("You've already answered %q. Watch for tomorrow's question!", question), nil
+	}
+	if points == 0 {
+		return "Thanks, your answer is saved privately. Text BIGQ SHARE to share answers and earn points.", nil
+	}
+	balance, err := c.db.PointsBalance(msg.Phone)
+	if err != nil {
+		return "", fmt.Errorf("points balance: %w", err)
+	}
+	return fmt.Sprintf("Thanks for contributing! +%d points (%d total).", points, balance), nil
+}
+
+// points replies with the sender's points balance.
+// POINTS
+func (c *Commands) points(ctx context.Context, msg *sms.Message) (string, error) {
+	balance, err := c.db.PointsBalance(msg.Phone)
+	if err != nil {
+		return "", fmt.Errorf("points balance: %w", err)
+	}
+	if balance == 1 {
+		return "You have 1 point.", nil
+	}
+	return fmt.Sprintf("You have %d points.", balance), nil
+}
+
+// DispatchBigQuestions texts today's Big Question to every active
+// subscriber whose local send time has passed and who hasn't had one
+// today. Each subscriber works through the active questions in order and
+// stops getting texts once they've seen them all. A database error for one
+// subscriber is logged and skipped so it can't hold up the others. It is
+// meant to run every minute from the job scheduler.
+func (c *Commands) DispatchBigQuestions(ctx context.Context, now time.Time) error {
+	if c.sender == nil {
+		return nil
+	}
+	subs, err := c.db.BigQSubscribers(true)
+	if err != nil {
+		return fmt.Errorf("list big question subscribers: %w", err)
+	}
+	for _, s := range subs {
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			log.Printf("big question subscriber %s has bad time zone %q: %v", s.Phone, s.Timezone, err)
+			continue
+		}
+		local := now.In(loc)
+		if local.Hour()*60+local.Minute() < s.SendMinute {
+			continue
+		}
+		q, err := c.db.NextBigQuestion(s.Phone)
+		if err != nil {
+			log.Printf("error picking big question for %s: %v", s.Phone, err)
+			continue
+		}
+		if q == nil {
+			continue
+		}
+		p := &database.BigQPrompt{Phone: s.Phone, QuestionID: q.ID, LocalDate: local.Format("2006-01-02"), SentAt: now.UTC()}
+		fresh, err := c.db.CreateBigQPrompt(p)
+		if err != nil {
+			log.Printf("error recording big question for %s: %v", s.Phone, err)
+			continue
+		}
+		if !fresh {
+			continue
+		}
+		body := "Today's Big Question: " + q.Text + "\nReply ANSWER <your answer>"
+		if _, err := c.sender.Send(ctx, &sms.Outbound{To: "+" + s.Phone, Body: body}); err != nil {
+			if errors.Is(err, sms.ErrOptedOut) {
+				continue
+			}
+			log.Printf("error sending big question to %s: %v", s.Phone, err)
+			// Forget the prompt so the next run tries again.
+			if err := c.db.DeleteBigQPrompt(p.ID); err != nil {
+				log.Printf("error forgetting big question for %s: %v", s.Phone, err)
+			}
+		}
+	}
+	return nil
+}
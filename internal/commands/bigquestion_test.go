@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+func TestBigQuestion(t *testing.T) {
+	db, err := database.Open(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	defer db.Close()
+	for _, text := range []string{"What made you laugh today?", "Who taught you to cook?"} {
+		if err := db.CreateBigQuestion(&database.BigQuestion{Text: text, Active: true, CreatedAt: testNow}); err != nil {
+			t.Fatalf("create question: %v", err)
+		}
+	}
+
+	sender := sms.NewFakeSender("")
+	c := New(db, Options{Sender: sender, Location: testNow.Location()})
+	c.now = func() time.Time { return testNow }
+	r := sms.NewRouter()
+	c.Register(r)
+
+	say := func(body, want string) {
+		t.Helper()
+		reply, err := r.Dispatch(context.Background(), sms.ParseMessage(url.Values{"From": {"+15555555555"}, "Body": {body}}))
+		if err != nil {
+			t.Fatalf("dispatch %q: %v", body, err)
+		}
+		if !strings.Contains(reply, want) {
+			t.Errorf("%q: reply %q does not contain %q", body, reply, want)
+		}
+	}
+	dispatch := func(now time.Time) {
+		t.Helper()
+		if err := c.DispatchBigQuestions(context.Background(), now); err != nil {
+			t.Fatalf("DispatchBigQuestions: %v", err)
+		}
+	}
+
+	say("BIGQ", "Text BIGQ ON [time] to start")
+	say("ANSWER my dog", "no question to answer yet")
+	say("BIGQ TZ Mars/Olympus", "Usage: BIGQ TZ")
+	say("BIGQ ON whenever", "Usage: BIGQ ON")
+	say("BIGQ ON 9:30am", "daily at 9:30 AM (America/Los_Angeles). Your answers are private.")
+
+	// 10:00 is past 9:30, so the first question goes out once.
+	dispatch(testNow)
+	dispatch(testNow.Add(time.Minute))
+	sent := sender.Sent()
+	if len(sent) != 1 || sent[0].To != "+15555555555" ||
+		sent[0].Body != "Today's Big Question: What made you laugh today?\nReply ANSWER <your answer>" {
+		t.Fatalf("sent = %+v, want the first question", sent)
+	}
+
+	say("ANSWER my dog", "saved privately")
+	say("ANSWER again", "already answered")
+	say("POINTS", "You have 0 points.")
+
+	say("BIGQ SHARE", "You're sharing your answers")
+	tomorrow := testNow.AddDate(0, 0, 1)
+	dispatch(tomorrow.Add(-time.Hour)) // 9:00, too early
+	if len(sender.Sent()) != 1 {
+		t.Fatalf("question sent before the subscriber's time")
+	}
+	dispatch(tomorrow)
+	if sent := sender.Sent(); len(sent) != 2 || !strings.Contains(sent[1].Body, "Who taught you to cook?") {
+		t.Fatalf("sent = %+v, want the second question", sent)
+	}
+	say("ANSWER my grandma", "+10 points (10 total)")
+	say("POINTS", "You have 10 points.")
+
+	// Out of questions: nothing more is sent.
+	dispatch(tomorrow.AddDate(0, 0, 1))
+	if len(sender.Sent()) != 2 {
+		t.Errorf("sent a question after running out")
+	}
+
+	say("BIGQ OFF", "The Big Question is off.")
+	if subs, _ := db.BigQSubscribers(true); len(subs) != 0 {
+		t.Errorf("subscriber still active after BIGQ OFF")
+	}
+	if list, _ := db.Contributions(); len(list) != 1 || list[0].Answer != "my grandma" {
+		t.Errorf("Contributions = %+v, want only the shared answer", list)
+	}
+}
+
+func TestDispatchBigQuestions_SkipsFailingSubscriber(t *testing.T) {
+	path := t.TempDir() + "/test.db"
+	db, err := database.Open(path)
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	defer db.Close()
+	if err := db.CreateBigQuestion(&database.BigQuestion{Text: "What made you laugh today?", Active: true, CreatedAt: testNow}); err != nil {
+		t.Fatalf("create question: %v", err)
+	}
+
+	sender := sms.NewFakeSender("")
+	c := New(db, Options{Sender: sender, Location: testNow.Location()})
+	c.now = func() time.Time { return testNow }
+	r := sms.NewRouter()
+	c.Register(r)
+	for _, from := range []string{"+15555550001", "+15555550002"} {
+		if _, err := r.Dispatch(context.Background(), sms.ParseMessage(url.Values{"From": {from}, "Body": {"BIGQ ON 9am"}})); err != nil {
+			t.Fatalf("subscribe %s: %v", from, err)
+		}
+	}
+
+	// Make recording the first subscriber's prompt fail.
+	raw, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open raw db: %v", err)
+	}
+	defer raw.Close()
+	if _, err := raw.Exec(`CREATE TRIGGER fail_prompt BEFORE INSERT ON bq_prompts
+		WHEN NEW.phone = '15555550001' BEGIN SELECT RAISE(FAIL, 'disk on fire'); END`); err != nil {
+		t.Fatalf("create trigger: %v", err)
+	}
+
+	if err := c.DispatchBigQuestions(context.Background(), testNow); err != nil {
+		t.Fatalf("DispatchBigQuestions: %v", err)
+	}
+	if sent := sender.Sent(); len(sent) != 1 || sent[0].To != "+15555550002" {
+		t.Errorf("sent = %+v, want only the second subscriber's question", sent)
+	}
+}
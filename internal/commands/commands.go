@@ -0,0 +1,153 @@
+// Package commands implements the keyword commands of the nexus SMS
+// assistant and registers them on an sms.Router.
+package commands
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/assistant"
+	"github.com/jredh-dev/nexus/internal/calclient"
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/media"
+	"github.com/jredh-dev/nexus/internal/portalclient"
+	"github.com/jredh-dev/nexus/internal/ratelimit"
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/pkg/when"
+)
+
+// Options configures optional integrations. Commands whose integration is
+// not configured reply that they are unavailable.
+type Options struct {
+	Cal      *calclient.Client    // nexus-cal API, used by REMIND
+	Portal   *portalclient.Client // portal internal API, used by LOGIN, ASK, PIN and escalation relays
+	Sender   sms.Sender           // outbound messages (reminders, broadcasts)
+	Location *time.Location       // time zone for parsing dates (default UTC)
+
+	// Assistant answers messages that match no command. MaxReplyChars
+	// bounds its replies (default assistant.DefaultMaxChars).
+	Assistant     assistant.Assistant
+	MaxReplyChars int
+
+	// MediaLinks makes the links to stored attachments in replies; nil
+	// omits them.
+	MediaLinks *media.Links
+
+	// HelpMessage introduces the HELP reply (program name, contact info,
+	// opt-out instructions), as carriers require.
+	HelpMessage string
+}
+
+// Commands holds dependencies shared by the SMS command handlers.
+type Commands struct {
+	db       *database.DB
+	cal      *calclient.Client
+	portal   *portalclient.Client
+	logins   *ratelimit.Window
+	sender   sms.Sender
+	dates    *when.Parser
+	help     string
+	links    *media.Links
+	maxReply int
+	now      func() time.Time
+
+	assistant assistant.Assistant
+}
+
+// New creates the command set.
+func New(db *database.DB, opts Options) *Commands {
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	c := &Commands{
+		db:       db,
+		cal:      opts.Cal,
+		portal:   opts.Portal,
+		logins:   ratelimit.NewWindow(maxLoginLinks, loginLinkPeriod),
+		sender:   opts.Sender,
+		help:     opts.HelpMessage,
+		links:    opts.MediaLinks,
+		maxReply: opts.MaxReplyChars,
+		now:      time.Now,
+
+		assistant: opts.Assistant,
+	}
+	if c.maxReply <= 0 {
+		c.maxReply = assistant.DefaultMaxChars
+	}
+	c.dates = &when.Parser{Location: loc, Now: func() time.Time { return c.now() }}
+	return c
+}
+
+// Register adds every command and the fallback handler to r, and parks
+// the commands an escalation classifier should hold for a person.
+func (c *Commands) Register(r *sms.Router) {
+	r.Handle("HELP", "HELP - list commands", c.helpFor(r))
+	r.Handle("NOTE", "NOTE <text> - save a note", c.note)
+	r.Handle("RECALL", "RECALL <words> - search your notes", c.recall)
+	r.Handle("LIST", "LIST - show recent notes", c.list)
+	if c.cal != nil {
+		r.Handle("REMIND", "REMIND <what> <when> - add a reminder to your calendar", c.remind)
+	} else {
+		r.Handle("REMIND", "REMIND <what> <when> - set a reminder", c.notAvailable("REMIND"))
+	}
+	if c.portal != nil {
+		r.Handle("LOGIN", "LOGIN - get a link to sign in to the portal", c.login)
+		r.Handle("ASK", "ASK <question> - ask your circle", c.askCircle)
+		r.Handle("PIN", "PIN <digits> - set the PIN for calling in", c.pin)
+	} else {
+		r.Handle("LOGIN", "LOGIN - get a sign-in link", c.notAvailable("LOGIN"))
+		r.Handle("ASK", "ASK <question> - ask your circle", c.notAvailable("ASK"))
+		r.Handle("PIN", "PIN <digits> - set the PIN for calling in", c.notAvailable("PIN"))
+	}
+	r.Handle("BIGQ", "BIGQ ON [time] - get the Big Question every day", c.bigq)
+	r.Handle("ANSWER", "ANSWER <text> - answer the Big Question", c.answer)
+	r.Handle("POINTS", "POINTS - show your points", c.points)
+	r.Fallback(c.fallback)
+
+	// A flagged question goes to a person before the sender's circle sees
+	// it. Other commands only touch the sender's own data and still run.
+	r.Park("ASK")
+}
+
+// helpFor answers HELP with the configured info message followed by the
+// router's command list, greeting linked senders by name.
+func (c *Commands) helpFor(r *sms.Router) sms.HandlerFunc {
+	return func(ctx context.Context, msg *sms.Message) (string, error) {
+		text := "Commands:\n" + strings.Join(r.Usage(), "\n")
+		if c.help != "" {
+			text = c.help + "\n" + text
+		}
+		if msg.Account != nil {
+			text = "Hi " + msg.Account.FirstName() + "! " + text
+		}
+		return text, nil
+	}
+}
+
+// fallback answers messages that match no command. Attachments sent
+// without a command are saved as a note captioned with the message text;
+// anything else goes to the assistant, if one is configured.
+func (c *Commands) fallback(ctx context.Context, msg *sms.Message) (string, error) {
+	if len(msg.Media) > 0 {
+		return c.saveNote(msg, msg.Body)
+	}
+	if c.assistant != nil {
+		return c.ask(ctx, msg)
+	}
+	sorry := "Sorry"
+	if msg.Account != nil {
+		sorry += " " + msg.Account.FirstName()
+	}
+	return sorry + ", I didn't understand that. Text HELP for a list of commands.", nil
+}
+
+// notAvailable is a placeholder for commands whose backing service is not
+// configured.
+func (c *Commands) notAvailable(keyword string) sms.HandlerFunc {
+	return func(ctx context.Context, msg *sms.Message) (string, error) {
+		return keyword + " isn't available right now. Text HELP for a list of commands.", nil
+	}
+}
@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/media"
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+func testRouter(t *testing.T) *sms.Router {
+	t.Helper()
+	db, err := database.Open(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	r := sms.NewRouter()
+	New(db, Options{}).Register(r)
+	return r
+}
+
+func send(t *testing.T, r *sms.Router, from, body string) string {
+	t.Helper()
+	reply, err := r.Dispatch(context.Background(), sms.ParseMessage(url.Values{"From": {from}, "Body": {body}}))
+	if err != nil {
+		t.Fatalf("dispatch %q: %v", body, err)
+	}
+	return reply
+}
+
+func TestNoteAndRecall(t *testing.T) {
+	r := testRouter(t)
+	const alice = "+15555555555"
+	const bob = "+15550000000"
+
+	if reply := send(t, r, alice, "NOTE locker code is 4812"); !strings.HasPrefix(reply, "Noted") {
+		t.Errorf("NOTE reply = %q", reply)
+	}
+	send(t, r, alice, "note parking on level 3")
+
+	if reply := send(t, r, alice, "recall locker"); !strings.Contains(reply, "locker code is 4812") {
+		t.Errorf("RECALL reply = %q", reply)
+	}
+	if reply := send(t, r, bob, "RECALL locker"); !strings.HasPrefix(reply, "No notes match") {
+		t.Errorf("notes leaked across senders: %q", reply)
+	}
+
+	reply := send(t, r, alice, "LIST")
+	if !strings.Contains(reply, "parking on level 3") || !strings.Contains(reply, "locker code") {
+		t.Errorf("LIST reply = %q", reply)
+	}
+}
+
+func TestUsageMessages(t *testing.T) {
+	r := testRouter(t)
+	const alice = "+15555555555"
+
+	if reply := send(t, r, alice, "NOTE"); reply != "Usage: NOTE <text>" {
+		t.Errorf("empty NOTE reply = %q", reply)
+	}
+	if reply := send(t, r, alice, "RECALL"); reply != "Usage: RECALL <words>" {
+		t.Errorf("empty RECALL reply = %q", reply)
+	}
+	if reply := send(t, r, alice, "LIST"); !strings.HasPrefix(reply, "You have no notes") {
+		t.Errorf("empty LIST reply = %q", reply)
+	}
+}
+
+func TestHelpIncludesInfoMessage(t *testing.T) {
+	db, err := database.Open(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	defer db.Close()
+
+	r := sms.NewRouter()
+	New(db, Options{HelpMessage: "nexus: reply STOP to unsubscribe."}).Register(r)
+
+	reply := send(t, r, "+15555555555", "help")
+	if !strings.HasPrefix(reply, "nexus: reply STOP to unsubscribe.\nCommands:") {
+		t.Errorf("unexpected HELP reply: %q", reply)
+	}
+	if !strings.Contains(reply, "NOTE <text> - save a note") {
+		t.Errorf("HELP reply missing command list: %q", reply)
+	}
+}
+
+func TestRepliesGreetLinkedAccount(t *testing.T) {
+	r := testRouter(t)
+
+	msg := sms.ParseMessage(url.Values{"From": {"+15555555555"}, "Body": {"HELP"}})
+	msg.Account = &sms.Account{ID: "user-1", Username: "tex", Name: "Tex Ter"}
+	reply, err := r.Dispatch(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if !strings.HasPrefix(reply, "Hi Tex! ") {
+		t.Errorf("HELP reply = %q, want greeting", reply)
+	}
+
+	msg = sms.ParseMessage(url.Values{"From": {"+15555555555"}, "Body": {"gibberish"}})
+	msg.Account = &sms.Account{ID: "user-1", Username: "tex"}
+	reply, err = r.Dispatch(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if !strings.HasPrefix(reply, "Sorry tex, ") {
+		t.Errorf("fallback reply = %q, want username when name is empty", reply)
+	}
+}
+
+func TestAttachmentsSavedAsNotes(t *testing.T) {
+	db, err := database.Open(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	defer db.Close()
+
+	r := sms.NewRouter()
+	New(db, Options{MediaLinks: media.NewLinks("https://nexus.example.com/media/", "secret", time.Hour)}).Register(r)
+
+	// The SMS handler records the message and its media before dispatch.
+	msg := sms.ParseMessage(url.Values{
+		"From": {"+15555555555"}, "Body": {"costco receipt"},
+		"NumMedia": {"1"}, "MediaUrl0": {"https://api.twilio.com/Media/ME1"}, "MediaContentType0": {"image/jpeg"},
+	})
+	rec := &database.Message{Phone: msg.Phone, Direction: database.DirectionInbound, Body: msg.Body, CreatedAt: time.Now().UTC()}
+	if err := db.RecordMessage(rec); err != nil {
+		t.Fatalf("record message: %v", err)
+	}
+	msg.ID = rec.ID
+	if err := db.CreateMedia(&database.Media{MessageID: rec.ID, Phone: msg.Phone, URL: msg.Media[0].URL, ContentType: "image/jpeg", SHA256: "abc123", CreatedAt: rec.CreatedAt}); err != nil {
+		t.Fatalf("create media: %v", err)
+	}
+
+	reply, err := r.Dispatch(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if !strings.HasPrefix(reply, "Saved with 1 attachment") {
+		t.Errorf("photo reply = %q", reply)
+	}
+
+	reply = send(t, r, "+15555555555", "RECALL receipt")
+	if !strings.Contains(reply, "costco receipt [photo: https://nexus.example.com/media/abc123?exp=") {
+		t.Errorf("RECALL reply = %q", reply)
+	}
+}
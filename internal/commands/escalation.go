@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+// RelayEscalations texts answers to escalated messages back to their
+// senders and acknowledges them to the portal. Failed sends are retried
+// on the next run; answers to numbers that opted out are dropped. It is
+// meant to run every minute from the job scheduler.
+func (c *Commands) RelayEscalations(ctx context.Context) error {
+	if c.portal == nil || c.sender == nil {
+		return nil
+	}
+	relays, err := c.portal.EscalationRelays(ctx)
+	if err != nil {
+		return err
+	}
+	for _, r := range relays {
+		_, err := c.sender.Send(ctx, &sms.Outbound{To: "+" + identity.NormalizePhone(r.Phone), Body: r.Answer})
+		if err != nil && !errors.Is(err, sms.ErrOptedOut) {
+			log.Printf("error relaying escalation %s: %v", r.EscalationID, err)
+			continue
+		}
+		if err := c.portal.EscalationRelayed(ctx, r.EscalationID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
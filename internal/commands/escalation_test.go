@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/portalclient"
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+// fakeEscalations is an in-memory stand-in for the portal's escalation
+// queue API.
+type fakeEscalations struct {
+	mu      sync.Mutex
+	pending []portalclient.EscalationRelay
+}
+
+func (f *fakeEscalations) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /internal/escalation-relays", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewEncoder(w).Encode(f.pending)
+	})
+	mux.HandleFunc("POST /internal/escalation-relays/{id}/done", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for i, p := range f.pending {
+			if p.EscalationID == r.PathValue("id") {
+				f.pending = append(f.pending[:i], f.pending[i+1:]...)
+				break
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+func TestRelayEscalations(t *testing.T) {
+	portal := &fakeEscalations{}
+	srv := httptest.NewServer(portal.handler())
+	defer srv.Close()
+
+	db, err := database.Open(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	defer db.Close()
+
+	sender := sms.NewFakeSender("")
+	c := New(db, Options{Portal: portalclient.New(srv.URL, "secret"), Sender: sender})
+
+	portal.pending = []portalclient.EscalationRelay{{EscalationID: "esc-1", Phone: "15555555555", Answer: "Call tenant legal aid."}}
+	for i := 0; i < 2; i++ {
+		if err := c.RelayEscalations(context.Background()); err != nil {
+			t.Fatalf("RelayEscalations: %v", err)
+		}
+	}
+	sent := sender.Sent()
+	if len(sent) != 1 || sent[0].To != "+15555555555" || sent[0].Body != "Call tenant legal aid." {
+		t.Errorf("sent = %+v, want one relayed answer", sent)
+	}
+	if len(portal.pending) != 0 {
+		t.Errorf("relay not acknowledged: %+v", portal.pending)
+	}
+}
+
+func TestRegister_Parks(t *testing.T) {
+	db, err := database.Open(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	defer db.Close()
+	r := sms.NewRouter()
+	New(db, Options{Portal: portalclient.New("http://portal.invalid", "secret")}).Register(r)
+
+	for body, want := range map[string]bool{
+		"ASK anyone know a good lawyer?": true,
+		"NOTE call the lawyer":           false,
+		"REMIND lawyer friday 3pm":       false,
+		"I need a lawyer":                true,
+	} {
+		if got := r.Parks(sms.ParseMessage(url.Values{"Body": {body}})); got != want {
+			t.Errorf("Parks(%q) = %v, want %v", body, got, want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+// LOGIN rate limit: each number may request this many links per period.
+const (
+	maxLoginLinks   = 3
+	loginLinkPeriod = time.Hour
+)
+
+// login texts back a one-time portal login link for the account registered
+// with the sender's phone number.
+// LOGIN
+func (c *Commands) login(ctx context.Context, msg *sms.Message) (string, error) {
+	if !c.logins.Allow(msg.Phone, c.now()) {
+		return "Too many login requests. Please try again in an hour.", nil
+	}
+
+	link, err := c.portal.CreateMagicLink(ctx, identity.HashIdentifier(msg.Phone))
+	if err != nil {
+		return "", fmt.Errorf("create login link: %w", err)
+	}
+	if link == "" {
+		return "No account uses this phone number. Sign up first, then text LOGIN.", nil
+	}
+	return "Your nexus login link (expires in 15 minutes, works once): " + link + "\nDon't share it with anyone.", nil
+}
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/portalclient"
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+func TestLoginSendsMagicLink(t *testing.T) {
+	known := identity.PhoneHash("+15555555555")
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["phone_hash"] != known {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"link": "https://portal.example.com/auth/magic?token=abc"})
+	}))
+	defer srv.Close()
+
+	db, err := database.Open(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	defer db.Close()
+
+	c := New(db, Options{Portal: portalclient.New(srv.URL, "secret")})
+	now := testNow
+	c.now = func() time.Time { return now }
+	r := sms.NewRouter()
+	c.Register(r)
+
+	login := func(from string) string {
+		t.Helper()
+		reply, err := r.Dispatch(context.Background(), sms.ParseMessage(url.Values{"From": {from}, "Body": {"login"}}))
+		if err != nil {
+			t.Fatalf("dispatch: %v", err)
+		}
+		return reply
+	}
+
+	for i := 0; i < maxLoginLinks; i++ {
+		if reply := login("+15555555555"); !strings.Contains(reply, "https://portal.example.com/auth/magic?token=abc") {
+			t.Fatalf("request %d: reply missing link: %q", i+1, reply)
+		}
+	}
+	if reply := login("+15555555555"); !strings.HasPrefix(reply, "Too many login requests") {
+		t.Errorf("expected rate limit, got %q", reply)
+	}
+	if requests != maxLoginLinks {
+		t.Errorf("portal called %d times, want %d", requests, maxLoginLinks)
+	}
+
+	now = now.Add(loginLinkPeriod)
+	if reply := login("+15555555555"); !strings.Contains(reply, "auth/magic") {
+		t.Errorf("expected link after the window passed, got %q", reply)
+	}
+
+	if reply := login("+15550000000"); !strings.HasPrefix(reply, "No account uses this phone number") {
+		t.Errorf("unknown number reply = %q", reply)
+	}
+}
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+// maxNotesInReply caps how many notes LIST and RECALL send back.
+const maxNotesInReply = 5
+
+// note saves the message arguments, and any attachments, as a note.
+// NOTE <text>
+func (c *Commands) note(ctx context.Context, msg *sms.Message) (string, error) {
+	if msg.Args == "" && len(msg.Media) == 0 {
+		return "Usage: NOTE <text>", nil
+	}
+	return c.saveNote(msg, msg.Args)
+}
+
+// saveNote stores body as a note linked to msg, so msg's attachments are
+// listed with it.
+func (c *Commands) saveNote(msg *sms.Message, body string) (string, error) {
+	n := &database.Note{
+		Phone:     msg.Phone,
+		Body:      body,
+		MessageID: msg.ID,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := c.db.CreateNote(n); err != nil {
+		return "", fmt.Errorf("create note: %w", err)
+	}
+	switch len(msg.Media) {
+	case 0:
+		return "Noted. Text RECALL <words> to find it later.", nil
+	case 1:
+		return "Saved with 1 attachment. Text RECALL <words> to find it later.", nil
+	default:
+		return fmt.Sprintf("Saved with %d attachments. Text RECALL <words> to find it later.", len(msg.Media)), nil
+	}
+}
+
+// recall searches the sender's notes.
+// RECALL <words>
+func (c *Commands) recall(ctx context.Context, msg *sms.Message) (string, error) {
+	if msg.Args == "" {
+		return "Usage: RECALL <words>", nil
+	}
+
+	notes, err := c.db.SearchNotes(msg.Phone, msg.Args, maxNotesInReply)
+	if err != nil {
+		return "", fmt.Errorf("search notes: %w", err)
+	}
+	if len(notes) == 0 {
+		return fmt.Sprintf("No notes match %q.", msg.Args), nil
+	}
+	return c.formatNotes(notes)
+}
+
+// list shows the sender's most recent notes.
+// LIST
+func (c *Commands) list(ctx context.Context, msg *sms.Message) (string, error) {
+	notes, err := c.db.RecentNotes(msg.Phone, maxNotesInReply)
+	if err != nil {
+		return "", fmt.Errorf("list notes: %w", err)
+	}
+	if len(notes) == 0 {
+		return "You have no notes yet. Text NOTE <text> to save one.", nil
+	}
+	return c.formatNotes(notes)
+}
+
+// formatNotes renders one line per note, followed by links to the note's
+// attachments.
+func (c *Commands) formatNotes(notes []*database.Note) (string, error) {
+	lines := make([]string, len(notes))
+	for i, n := range notes {
+		parts := []string{n.Body}
+		if n.MessageID != 0 {
+			media, err := c.db.MediaByMessage(n.MessageID)
+			if err != nil {
+				return "", fmt.Errorf("note media: %w", err)
+			}
+			for _, m := range media {
+				parts = append(parts, c.mediaLabel(m))
+			}
+		}
+		lines[i] = fmt.Sprintf("%s: %s", n.CreatedAt.Format("Jan 2"), strings.TrimSpace(strings.Join(parts, " ")))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// mediaLabel describes an attachment, with a link when it was stored and
+// media links are configured.
+func (c *Commands) mediaLabel(m *database.Media) string {
+	kind := "file"
+	switch {
+	case strings.HasPrefix(m.ContentType, "image/"):
+		kind = "photo"
+	case strings.HasPrefix(m.ContentType, "audio/"):
+		kind = "recording"
+	}
+	if c.links == nil || m.SHA256 == "" {
+		return "[" + kind + "]"
+	}
+	return "[" + kind + ": " + c.links.URL(m.SHA256) + "]"
+}
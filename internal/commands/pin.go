@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+// Voice PIN length limits.
+const (
+	minPINDigits = 4
+	maxPINDigits = 8
+)
+
+// pin sets the PIN callers enter before the voice menu reads their
+// calendar or records a memo. Only linked accounts can set one, and the
+// SMS handler stores the message with the digits masked.
+// PIN <digits>
+func (c *Commands) pin(ctx context.Context, msg *sms.Message) (string, error) {
+	if msg.Account == nil {
+		return "Link this number to a portal account before setting a phone PIN.", nil
+	}
+	if !validPIN(msg.Args) {
+		return fmt.Sprintf("Usage: PIN <%d to %d digits>", minPINDigits, maxPINDigits), nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(msg.Args), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("hash PIN: %w", err)
+	}
+	if err := c.db.SetVoicePIN(msg.Phone, string(hash), c.now().UTC()); err != nil {
+		return "", fmt.Errorf("save PIN: %w", err)
+	}
+	return "Your phone PIN is set. Enter it when you call to hear your calendar or leave a memo. You can delete this text.", nil
+}
+
+func validPIN(s string) bool {
+	if len(s) < minPINDigits || len(s) > maxPINDigits {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/portalclient"
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+func TestPIN(t *testing.T) {
+	db, err := database.Open(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	defer db.Close()
+
+	c := New(db, Options{Portal: portalclient.New("http://portal.invalid", "secret")})
+	r := sms.NewRouter()
+	c.Register(r)
+
+	pin := func(body string, linked bool) string {
+		t.Helper()
+		msg := sms.ParseMessage(url.Values{"From": {"+15555555555"}, "Body": {body}})
+		if linked {
+			msg.Account = &sms.Account{ID: "user-1", Username: "tex"}
+		}
+		reply, err := r.Dispatch(context.Background(), msg)
+		if err != nil {
+			t.Fatalf("dispatch %q: %v", body, err)
+		}
+		return reply
+	}
+
+	if reply := pin("PIN 1234", false); !strings.HasPrefix(reply, "Link this number") {
+		t.Errorf("unlinked sender: %q", reply)
+	}
+	for _, body := range []string{"PIN", "PIN 123", "PIN 123456789", "PIN 12a4"} {
+		if reply := pin(body, true); !strings.HasPrefix(reply, "Usage: PIN") {
+			t.Errorf("%q: %q", body, reply)
+		}
+	}
+	if hash, _ := db.VoicePIN("15555555555"); hash != "" {
+		t.Fatalf("invalid PINs were stored")
+	}
+
+	if reply := pin("PIN 2468", true); !strings.HasPrefix(reply, "Your phone PIN is set") {
+		t.Errorf("set reply: %q", reply)
+	}
+	hash, err := db.VoicePIN("15555555555")
+	if err != nil {
+		t.Fatalf("load PIN: %v", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte("2468")) != nil {
+		t.Errorf("stored hash does not match the PIN")
+	}
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+// askCircle posts a question to the sender's circle on the portal's
+// community Q&A board. Answers come back by text (see RelayAnswers).
+// ASK <question>
+func (c *Commands) askCircle(ctx context.Context, msg *sms.Message) (string, error) {
+	if msg.Args == "" {
+		return "Usage: ASK <question>", nil
+	}
+	size, found, err := c.portal.Ask(ctx, identity.HashIdentifier(msg.Phone), msg.Args)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case !found:
+		return "No account uses this phone number. Sign up first, then text ASK.", nil
+	case size == 0:
+		return "Posted! Your circle is empty, so add people on the portal to get answers.", nil
+	case size == 1:
+		return "Posted to your circle (1 person). Answers will be texted to you.", nil
+	default:
+		return fmt.Sprintf("Posted to your circle (%d people). Answers will be texted to you.", size), nil
+	}
+}
+
+// RelayAnswers texts new community Q&A answers to the people who asked
+// and acknowledges them to the portal. Failed sends are retried on the
+// next run; answers to numbers that opted out are dropped. It is meant to
+// run every minute from the job scheduler.
+func (c *Commands) RelayAnswers(ctx context.Context) error {
+	if c.portal == nil || c.sender == nil {
+		return nil
+	}
+	relays, err := c.portal.AnswerRelays(ctx)
+	if err != nil {
+		return err
+	}
+	for _, r := range relays {
+		body := fmt.Sprintf("@%s answered %q: %s", r.Username, r.Question, r.Answer)
+		_, err := c.sender.Send(ctx, &sms.Outbound{To: "+" + identity.NormalizePhone(r.Phone), Body: body})
+		if err != nil && !errors.Is(err, sms.ErrOptedOut) {
+			log.Printf("error relaying answer %s: %v", r.AnswerID, err)
+			continue
+		}
+		if err := c.portal.AnswerRelayed(ctx, r.AnswerID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
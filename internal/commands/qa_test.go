@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/portalclient"
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+// fakeQA is an in-memory stand-in for the portal's community Q&A API.
+type fakeQA struct {
+	mu       sync.Mutex
+	asked    []string
+	pending  []portalclient.AnswerRelay
+	circle   int
+	accounts map[string]bool // phone hashes with an account
+}
+
+func (f *fakeQA) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /internal/questions", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if !f.accounts[req["phone_hash"]] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		f.asked = append(f.asked, req["body"])
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "q-1", "circle_size": f.circle})
+	})
+	mux.HandleFunc("GET /internal/answer-relays", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewEncoder(w).Encode(f.pending)
+	})
+	mux.HandleFunc("POST /internal/answer-relays/{id}/done", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for i, p := range f.pending {
+			if p.AnswerID == r.PathValue("id") {
+				f.pending = append(f.pending[:i], f.pending[i+1:]...)
+				break
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+func TestAskAndRelayAnswers(t *testing.T) {
+	portal := &fakeQA{circle: 3, accounts: map[string]bool{identity.PhoneHash("+15555555555"): true}}
+	srv := httptest.NewServer(portal.handler())
+	defer srv.Close()
+
+	db, err := database.Open(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	defer db.Close()
+
+	sender := sms.NewFakeSender("")
+	c := New(db, Options{Portal: portalclient.New(srv.URL, "secret"), Sender: sender})
+	r := sms.NewRouter()
+	c.Register(r)
+
+	for from, want := range map[string]string{
+		"+15555555555": "Posted to your circle (3 people).",
+		"+15550000000": "No account uses this phone number.",
+	} {
+		if reply := send(t, r, from, "ASK Anyone have a ladder?"); !strings.Contains(reply, want) {
+			t.Errorf("ASK from %s = %q, want %q", from, reply, want)
+		}
+	}
+	if reply := send(t, r, "+15555555555", "ASK"); reply != "Usage: ASK <question>" {
+		t.Errorf("empty ASK = %q", reply)
+	}
+	if len(portal.asked) != 1 || portal.asked[0] != "Anyone have a ladder?" {
+		t.Errorf("asked = %q", portal.asked)
+	}
+
+	portal.pending = []portalclient.AnswerRelay{{
+		AnswerID: "a-1", Phone: "(555) 555-5555", Question: "Anyone have a ladder?", Answer: "Yes, come by", Username: "bob",
+	}}
+	for i := 0; i < 2; i++ {
+		if err := c.RelayAnswers(context.Background()); err != nil {
+			t.Fatalf("RelayAnswers: %v", err)
+		}
+	}
+	sent := sender.Sent()
+	if len(sent) != 1 || sent[0].To != "+15555555555" || sent[0].Body != `@bob answered "Anyone have a ladder?": Yes, come by` {
+		t.Errorf("sent = %+v, want one relayed answer", sent)
+	}
+	if len(portal.pending) != 0 {
+		t.Errorf("relay not acknowledged: %+v", portal.pending)
+	}
+}
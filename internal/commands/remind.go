@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/calclient"
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+// reminderFeedName is the name of the per-user nexus-cal feed.
+const reminderFeedName = "nexus reminders"
+
+// remind parses a reminder and adds it as an event with a deadline to the
+// sender's nexus-cal feed. The deadline makes the feed emit a VALARM so the
+// phone's calendar alerts before it is due.
+// REMIND <what> <when>
+func (c *Commands) remind(ctx context.Context, msg *sms.Message) (string, error) {
+	what, parsed, err := c.dates.Extract(msg.Args)
+	if err != nil || what == "" {
+		return "Usage: REMIND <what> <when>, e.g. REMIND dentist friday 3pm", nil
+	}
+	if parsed.Recurrence != nil {
+		return "Sorry, repeating reminders aren't supported yet. Try a single date, e.g. REMIND dentist friday 3pm", nil
+	}
+	when := parsed.Time
+
+	feed, err := c.reminderFeed(ctx, msg.Phone)
+	if err != nil {
+		return "", err
+	}
+
+	deadline := when
+	event := &calclient.Event{
+		FeedID:      feed.FeedID,
+		Summary:     what,
+		Description: "Reminder created by text message.",
+		Start:       when,
+		Deadline:    &deadline,
+		Categories:  "reminder",
+	}
+	if _, err := c.cal.CreateEvent(ctx, event); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Reminder set: %s, %s.\nSubscribe: %s",
+		what, when.Format("Mon Jan 2 3:04 PM"), c.cal.SubscribeURL(feed.Token)), nil
+}
+
+// reminderFeed returns the sender's reminder feed, creating it in nexus-cal
+// on first use.
+func (c *Commands) reminderFeed(ctx context.Context, phone string) (*database.CalFeed, error) {
+	feed, err := c.db.CalFeedByPhone(phone)
+	if err != nil {
+		return nil, fmt.Errorf("lookup cal feed: %w", err)
+	}
+	if feed != nil {
+		return feed, nil
+	}
+
+	created, err := c.cal.CreateFeed(ctx, reminderFeedName)
+	if err != nil {
+		return nil, err
+	}
+	feed = &database.CalFeed{
+		Phone:     phone,
+		FeedID:    created.ID,
+		Token:     created.Token,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := c.db.SaveCalFeed(feed); err != nil {
+		return nil, fmt.Errorf("save cal feed: %w", err)
+	}
+	return feed, nil
+}
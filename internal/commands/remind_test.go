@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/calclient"
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+// Wednesday, March 4 2026, 10:00 in Seattle.
+var testNow = time.Date(2026, 3, 4, 10, 0, 0, 0, mustLoad("America/Los_Angeles"))
+
+func mustLoad(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// fakeCal is an in-memory stand-in for the nexus-cal management API.
+type fakeCal struct {
+	mu     sync.Mutex
+	feeds  int
+	events []calclient.Event
+}
+
+func (f *fakeCal) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/feeds", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.feeds++
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(calclient.Feed{ID: "feed-1", Token: "tok-123"})
+	})
+	mux.HandleFunc("POST /api/events", func(w http.ResponseWriter, r *http.Request) {
+		var e calclient.Event
+		json.NewDecoder(r.Body).Decode(&e)
+		f.mu.Lock()
+		f.events = append(f.events, e)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(e)
+	})
+	mux.HandleFunc("GET /api/feeds/{id}/events", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewEncoder(w).Encode(f.events)
+	})
+	return mux
+}
+
+func TestRemindCreatesCalendarEvent(t *testing.T) {
+	cal := &fakeCal{}
+	srv := httptest.NewServer(cal.handler())
+	defer srv.Close()
+
+	db, err := database.Open(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	defer db.Close()
+
+	c := New(db, Options{Cal: calclient.New(srv.URL), Location: testNow.Location()})
+	c.now = func() time.Time { return testNow }
+	r := sms.NewRouter()
+	c.Register(r)
+
+	for i := 0; i < 2; i++ {
+		reply, err := r.Dispatch(context.Background(), sms.ParseMessage(url.Values{
+			"From": {"+15555555555"},
+			"Body": {"REMIND dentist friday 3pm"},
+		}))
+		if err != nil {
+			t.Fatalf("dispatch: %v", err)
+		}
+		if !strings.Contains(reply, "Reminder set: dentist, Fri Mar 6 3:00 PM") {
+			t.Errorf("unexpected reply: %q", reply)
+		}
+		if !strings.Contains(reply, "webcal://"+strings.TrimPrefix(srv.URL, "http://")+"/tok-123.ics") {
+			t.Errorf("reply missing subscription URL: %q", reply)
+		}
+	}
+
+	if cal.feeds != 1 {
+		t.Errorf("expected feed to be created once, got %d", cal.feeds)
+	}
+	if len(cal.events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(cal.events))
+	}
+	e := cal.events[0]
+	if e.FeedID != "feed-1" || e.Summary != "dentist" || e.Deadline == nil || !e.Deadline.Equal(e.Start) {
+		t.Errorf("unexpected event: %+v", e)
+	}
+}
+
+func TestRemindRejectsRecurring(t *testing.T) {
+	db, err := database.Open(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	defer db.Close()
+
+	c := New(db, Options{Cal: calclient.New("http://cal.invalid"), Location: testNow.Location()})
+	c.now = func() time.Time { return testNow }
+	r := sms.NewRouter()
+	c.Register(r)
+
+	for body, want := range map[string]string{
+		"REMIND standup every monday 9am": "repeating reminders aren't supported",
+		"REMIND call mom":                 "Usage: REMIND",
+		"REMIND friday 3pm":               "Usage: REMIND",
+	} {
+		reply, err := r.Dispatch(context.Background(), sms.ParseMessage(url.Values{"From": {"+15555555555"}, "Body": {body}}))
+		if err != nil {
+			t.Fatalf("dispatch %q: %v", body, err)
+		}
+		if !strings.Contains(reply, want) {
+			t.Errorf("%q: reply %q does not contain %q", body, reply, want)
+		}
+	}
+}
+
+func TestDispatchReminders(t *testing.T) {
+	due := testNow.Add(-5 * time.Minute)
+	stale := testNow.Add(-2 * time.Hour)
+	later := testNow.Add(time.Hour)
+	cal := &fakeCal{events: []calclient.Event{
+		{ID: "e1", FeedID: "feed-1", Summary: "dentist", Start: due, Deadline: &due, Categories: "reminder"},
+		{ID: "e2", FeedID: "feed-1", Summary: "old", Start: stale, Deadline: &stale, Categories: "reminder"},
+		{ID: "e3", FeedID: "feed-1", Summary: "later", Start: later, Deadline: &later, Categories: "reminder"},
+		{ID: "e4", FeedID: "feed-1", Summary: "meeting", Start: due},
+	}}
+	srv := httptest.NewServer(cal.handler())
+	defer srv.Close()
+
+	db, err := database.Open(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	defer db.Close()
+	if err := db.SaveCalFeed(&database.CalFeed{Phone: "15555555555", FeedID: "feed-1", Token: "tok-123", CreatedAt: testNow}); err != nil {
+		t.Fatalf("save feed: %v", err)
+	}
+
+	sender := sms.NewFakeSender("")
+	c := New(db, Options{Cal: calclient.New(srv.URL), Sender: sender, Location: testNow.Location()})
+	for i := 0; i < 2; i++ {
+		if err := c.DispatchReminders(context.Background(), testNow); err != nil {
+			t.Fatalf("DispatchReminders: %v", err)
+		}
+	}
+
+	sent := sender.Sent()
+	if len(sent) != 1 || sent[0].To != "+15555555555" || sent[0].Body != "Reminder: dentist (9:55 AM)" {
+		t.Errorf("sent = %+v, want one reminder for the dentist", sent)
+	}
+}
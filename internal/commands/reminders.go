@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+// reminderGrace is how late a reminder may still be sent, e.g. after a
+// restart; older ones are dropped rather than delivered out of context.
+const reminderGrace = time.Hour
+
+// DispatchReminders texts the owner of every REMIND reminder whose time
+// has come (within reminderGrace of now) and that hasn't been sent yet.
+// It is meant to run every minute from the job scheduler.
+func (c *Commands) DispatchReminders(ctx context.Context, now time.Time) error {
+	if c.cal == nil || c.sender == nil {
+		return nil
+	}
+	feeds, err := c.db.CalFeeds()
+	if err != nil {
+		return fmt.Errorf("list cal feeds: %w", err)
+	}
+	for _, f := range feeds {
+		events, err := c.cal.ListEvents(ctx, f.FeedID)
+		if err != nil {
+			log.Printf("error listing reminders of feed %s: %v", f.FeedID, err)
+			continue
+		}
+		for _, e := range events {
+			if e.Categories != "reminder" || e.Deadline == nil {
+				continue
+			}
+			due := *e.Deadline
+			if due.After(now) || now.Sub(due) > reminderGrace {
+				continue
+			}
+			fresh, err := c.db.MarkFired("reminder:"+e.ID, now)
+			if err != nil {
+				return err
+			}
+			if !fresh {
+				continue
+			}
+			body := fmt.Sprintf("Reminder: %s (%s)", e.Summary, due.In(c.dates.Location).Format("3:04 PM"))
+			if _, err := c.sender.Send(ctx, &sms.Outbound{To: "+" + f.Phone, Body: body}); err != nil {
+				log.Printf("error sending reminder %s to %s: %v", e.ID, f.Phone, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/ratelimit"
+)
+
+// Block is a number an admin has blocked.
+type Block struct {
+	Phone     string    `json:"phone"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// --- Rate limit buckets ---
+
+// LoadBucket returns the stored token bucket for key, or nil if there is
+// none. It implements ratelimit.BucketStore.
+func (db *DB) LoadBucket(key string) (*ratelimit.BucketState, error) {
+	s := &ratelimit.BucketState{}
+	var notified sql.NullTime
+	err := db.conn.QueryRow(
+		`SELECT tokens, updated_at, notified_at FROM rate_buckets WHERE key = ?`, key,
+	).Scan(&s.Tokens, &s.UpdatedAt, &notified)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.NotifiedAt = notified.Time
+	return s, nil
+}
+
+// SaveBucket stores the token bucket for key. It implements
+// ratelimit.BucketStore.
+func (db *DB) SaveBucket(key string, s *ratelimit.BucketState) error {
+	notified := sql.NullTime{Time: s.NotifiedAt, Valid: !s.NotifiedAt.IsZero()}
+	_, err := db.conn.Exec(
+		`INSERT INTO rate_buckets (key, tokens, updated_at, notified_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(key) DO UPDATE SET tokens = excluded.tokens,
+			updated_at = excluded.updated_at, notified_at = excluded.notified_at`,
+		key, s.Tokens, s.UpdatedAt, notified,
+	)
+	return err
+}
+
+// --- Blocklist ---
+
+// BlockPhone adds phone to the blocklist, updating the reason if it is
+// already blocked.
+func (db *DB) BlockPhone(b *Block) error {
+	_, err := db.conn.Exec(
+		`INSERT INTO blocklist (phone, reason, created_at) VALUES (?, ?, ?)
+		 ON CONFLICT(phone) DO UPDATE SET reason = excluded.reason`,
+		b.Phone, b.Reason, b.CreatedAt,
+	)
+	return err
+}
+
+// UnblockPhone removes phone from the blocklist and reports whether it was
+// blocked.
+func (db *DB) UnblockPhone(phone string) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM blocklist WHERE phone = ?`, phone)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// IsBlocked reports whether phone is on the blocklist.
+func (db *DB) IsBlocked(phone string) (bool, error) {
+	var n int
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM blocklist WHERE phone = ?`, phone).Scan(&n)
+	return n > 0, err
+}
+
+// Blocklist returns blocked numbers, most recently blocked first.
+func (db *DB) Blocklist(limit int) ([]*Block, error) {
+	rows, err := db.conn.Query(
+		`SELECT phone, reason, created_at FROM blocklist ORDER BY created_at DESC, phone LIMIT ?`, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var blocks []*Block
+	for rows.Next() {
+		b := &Block{}
+		if err := rows.Scan(&b.Phone, &b.Reason, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, rows.Err()
+}
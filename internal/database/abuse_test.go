@@ -0,0 +1,75 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/ratelimit"
+)
+
+func TestBucketsPersist(t *testing.T) {
+	path := t.TempDir() + "/test.db"
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	start := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	limiter := ratelimit.NewTokenBucket(1, time.Minute, db)
+	if d, _ := limiter.Take("15555555555", start); d != ratelimit.Allow {
+		t.Fatalf("first message: %v", d)
+	}
+	if d, _ := limiter.Take("15555555555", start.Add(time.Second)); d != ratelimit.Notify {
+		t.Fatalf("second message: %v", d)
+	}
+	db.Close()
+
+	// A restart must not hand out a fresh bucket or a second notice.
+	db, err = Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	limiter = ratelimit.NewTokenBucket(1, time.Minute, db)
+	if d, err := limiter.Take("15555555555", start.Add(2*time.Second)); err != nil || d != ratelimit.Drop {
+		t.Errorf("after restart: %v, %v; want Drop", d, err)
+	}
+	if d, _ := limiter.Take("15555555555", start.Add(2*time.Minute)); d != ratelimit.Allow {
+		t.Errorf("after refill: %v, want Allow", d)
+	}
+}
+
+func TestBlocklist(t *testing.T) {
+	db := testDB(t)
+	now := time.Now().UTC()
+
+	if err := db.BlockPhone(&Block{Phone: "15555555555", Reason: "spam", CreatedAt: now}); err != nil {
+		t.Fatalf("block: %v", err)
+	}
+	if err := db.BlockPhone(&Block{Phone: "15555555555", Reason: "flooding", CreatedAt: now}); err != nil {
+		t.Fatalf("block again: %v", err)
+	}
+	if blocked, _ := db.IsBlocked("15555555555"); !blocked {
+		t.Error("expected number to be blocked")
+	}
+	if blocked, _ := db.IsBlocked("15550000000"); blocked {
+		t.Error("unexpected block")
+	}
+
+	blocks, err := db.Blocklist(10)
+	if err != nil {
+		t.Fatalf("blocklist: %v", err)
+	}
+	if len(blocks) != 1 || blocks[0].Reason != "flooding" {
+		t.Errorf("unexpected blocklist: %+v", blocks)
+	}
+
+	if ok, err := db.UnblockPhone("15555555555"); err != nil || !ok {
+		t.Errorf("unblock = %v, %v", ok, err)
+	}
+	if ok, _ := db.UnblockPhone("15555555555"); ok {
+		t.Error("second unblock should report not blocked")
+	}
+	if blocked, _ := db.IsBlocked("15555555555"); blocked {
+		t.Error("expected number to be unblocked")
+	}
+}
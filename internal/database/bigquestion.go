@@ -0,0 +1,366 @@
+package database
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+)
+
+// BigQuestion is a question in the Big Question bank. Inactive questions
+// are no longer sent but keep their answers.
+type BigQuestion struct {
+	ID        int64     `json:"id"`
+	Text      string    `json:"text"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BigQSubscriber is a phone that gets a Big Question every day at
+// SendMinute minutes after midnight in Timezone. Consent is the explicit
+// opt-in to share answers as training data.
+type BigQSubscriber struct {
+	Phone         string     `json:"phone"`
+	Active        bool       `json:"active"`
+	Timezone      string     `json:"timezone"`
+	SendMinute    int        `json:"send_minute"`
+	Consent       bool       `json:"consent"`
+	ConsentAt     *time.Time `json:"consent_at,omitempty"`
+	ContributorID string     `json:"contributor_id"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// BigQPrompt records a question sent to a phone on a local date.
+type BigQPrompt struct {
+	ID         int64     `json:"id"`
+	Phone      string    `json:"phone"`
+	QuestionID int64     `json:"question_id"`
+	LocalDate  string    `json:"local_date"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+// BigQAnswer is a reply to a prompt. Consent is the subscriber's sharing
+// consent when they answered; Question is filled in by queries.
+type BigQAnswer struct {
+	ID         int64     `json:"id"`
+	PromptID   int64     `json:"prompt_id"`
+	Phone      string    `json:"phone"`
+	QuestionID int64     `json:"question_id"`
+	Question   string    `json:"question,omitempty"`
+	Body       string    `json:"body"`
+	Consent    bool      `json:"consent"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Contribution is a shared answer as exported for training data: the
+// phone number is replaced by the subscriber's contributor ID.
+type Contribution struct {
+	AnswerID    int64     `json:"answer_id"`
+	QuestionID  int64     `json:"question_id"`
+	Question    string    `json:"question"`
+	Answer      string    `json:"answer"`
+	Contributor string    `json:"contributor"`
+	AnsweredAt  time.Time `json:"answered_at"`
+}
+
+// PointsEntry is one change to a phone's points balance.
+type PointsEntry struct {
+	ID        int64     `json:"id"`
+	Phone     string    `json:"phone"`
+	Delta     int       `json:"delta"`
+	Reason    string    `json:"reason"`
+	Ref       string    `json:"ref,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// --- Question bank ---
+
+// CreateBigQuestion adds a question to the bank and sets its ID.
+func (db *DB) CreateBigQuestion(q *BigQuestion) error {
+	res, err := db.conn.Exec(
+		`INSERT INTO bq_questions (text, active, created_at) VALUES (?, ?, ?)`,
+		q.Text, q.Active, q.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	q.ID, err = res.LastInsertId()
+	return err
+}
+
+// BigQuestions returns every question in the bank, oldest first.
+func (db *DB) BigQuestions() ([]*BigQuestion, error) {
+	rows, err := db.conn.Query(`SELECT id, text, active, created_at FROM bq_questions ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	return scanBigQuestions(rows)
+}
+
+// SetBigQuestionActive turns a question on or off and reports whether it
+// exists.
+func (db *DB) SetBigQuestionActive(id int64, active bool) (bool, error) {
+	res, err := db.conn.Exec(`UPDATE bq_questions SET active = ? WHERE id = ?`, active, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// NextBigQuestion returns the oldest active question phone hasn't been
+// sent yet, or nil if it has seen them all.
+func (db *DB) NextBigQuestion(phone string) (*BigQuestion, error) {
+	rows, err := db.conn.Query(
+		`SELECT id, text, active, created_at FROM bq_questions
+		 WHERE active = 1 AND id NOT IN (SELECT question_id FROM bq_prompts WHERE phone = ?)
+		 ORDER BY id LIMIT 1`, phone,
+	)
+	if err != nil {
+		return nil, err
+	}
+	list, err := scanBigQuestions(rows)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[0], nil
+}
+
+func scanBigQuestions(rows *sql.Rows) ([]*BigQuestion, error) {
+	defer rows.Close()
+
+	var list []*BigQuestion
+	for rows.Next() {
+		q := &BigQuestion{}
+		if err := rows.Scan(&q.ID, &q.Text, &q.Active, &q.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, q)
+	}
+	return list, rows.Err()
+}
+
+// --- Subscribers ---
+
+// SaveBigQSubscriber creates or updates a subscriber.
+func (db *DB) SaveBigQSubscriber(s *BigQSubscriber) error {
+	_, err := db.conn.Exec(
+		`INSERT INTO bq_subscribers (phone, active, timezone, send_minute, consent, consent_at, contributor_id, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(phone) DO UPDATE SET active = excluded.active, timezone = excluded.timezone,
+			send_minute = excluded.send_minute, consent = excluded.consent, consent_at = excluded.consent_at`,
+		s.Phone, s.Active, s.Timezone, s.SendMinute, s.Consent, s.ConsentAt, s.ContributorID, s.CreatedAt,
+	)
+	return err
+}
+
+const bigQSubscriberColumns = `phone, active, timezone, send_minute, consent, consent_at, contributor_id, created_at`
+
+// BigQSubscriber returns phone's subscription, or nil if it never had one.
+func (db *DB) BigQSubscriber(phone string) (*BigQSubscriber, error) {
+	rows, err := db.conn.Query(`SELECT `+bigQSubscriberColumns+` FROM bq_subscribers WHERE phone = ?`, phone)
+	if err != nil {
+		return nil, err
+	}
+	list, err := scanBigQSubscribers(rows)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[0], nil
+}
+
+// BigQSubscribers returns every subscriber, or only the active ones.
+func (db *DB) BigQSubscribers(activeOnly bool) ([]*BigQSubscriber, error) {
+	rows, err := db.conn.Query(
+		`SELECT `+bigQSubscriberColumns+` FROM bq_subscribers WHERE active = 1 OR ? = 0 ORDER BY created_at`,
+		activeOnly,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanBigQSubscribers(rows)
+}
+
+func scanBigQSubscribers(rows *sql.Rows) ([]*BigQSubscriber, error) {
+	defer rows.Close()
+
+	var list []*BigQSubscriber
+	for rows.Next() {
+		s := &BigQSubscriber{}
+		var consentAt sql.NullTime
+		if err := rows.Scan(&s.Phone, &s.Active, &s.Timezone, &s.SendMinute, &s.Consent, &consentAt,
+			&s.ContributorID, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		if consentAt.Valid {
+			s.ConsentAt = &consentAt.Time
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// --- Prompts and answers ---
+
+// CreateBigQPrompt records that a question is being sent and sets its ID.
+// It reports false, without recording anything, if phone already has a
+// prompt for p.LocalDate.
+func (db *DB) CreateBigQPrompt(p *BigQPrompt) (bool, error) {
+	res, err := db.conn.Exec(
+		`INSERT INTO bq_prompts (phone, question_id, local_date, sent_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(phone, local_date) DO NOTHING`,
+		p.Phone, p.QuestionID, p.LocalDate, p.SentAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	p.ID, err = res.LastInsertId()
+	return err == nil, err
+}
+
+// DeleteBigQPrompt removes a prompt that could not be sent, so it is
+// retried.
+func (db *DB) DeleteBigQPrompt(id int64) error {
+	_, err := db.conn.Exec(`DELETE FROM bq_prompts WHERE id = ?`, id)
+	return err
+}
+
+// LatestBigQPrompt returns the last question sent to phone and its text,
+// or nil if none has been.
+func (db *DB) LatestBigQPrompt(phone string) (*BigQPrompt, string, error) {
+	p := &BigQPrompt{}
+	var text string
+	err := db.conn.QueryRow(
+		`SELECT p.id, p.phone, p.question_id, p.local_date, p.sent_at, q.text
+		 FROM bq_prompts p JOIN bq_questions q ON q.id = p.question_id
+		 WHERE p.phone = ? ORDER BY p.id DESC LIMIT 1`, phone,
+	).Scan(&p.ID, &p.Phone, &p.QuestionID, &p.LocalDate, &p.SentAt, &text)
+	if err == sql.ErrNoRows {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return p, text, nil
+}
+
+// RecordBigQAnswer stores an answer and, if points > 0, credits them to
+// the phone in the same transaction. It reports false, storing nothing,
+// if the prompt has already been answered.
+func (db *DB) RecordBigQAnswer(a *BigQAnswer, points int, reason string) (bool, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`INSERT INTO bq_answers (prompt_id, phone, question_id, body, consent, created_at) VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(prompt_id) DO NOTHING`,
+		a.PromptID, a.Phone, a.QuestionID, a.Body, a.Consent, a.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	if a.ID, err = res.LastInsertId(); err != nil {
+		return false, err
+	}
+	if points > 0 {
+		if _, err := tx.Exec(
+			`INSERT INTO points_ledger (phone, delta, reason, ref, created_at) VALUES (?, ?, ?, ?, ?)`,
+			a.Phone, points, reason, "answer:"+strconv.FormatInt(a.ID, 10), a.CreatedAt,
+		); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// BigQAnswers returns the most recent answers, newest first, optionally
+// only those to one question (questionID > 0) or from one phone.
+func (db *DB) BigQAnswers(questionID int64, phone string, limit int) ([]*BigQAnswer, error) {
+	rows, err := db.conn.Query(
+		`SELECT a.id, a.prompt_id, a.phone, a.question_id, q.text, a.body, a.consent, a.created_at
+		 FROM bq_answers a JOIN bq_questions q ON q.id = a.question_id
+		 WHERE (? = 0 OR a.question_id = ?) AND (? = '' OR a.phone = ?)
+		 ORDER BY a.id DESC LIMIT ?`,
+		questionID, questionID, phone, phone, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*BigQAnswer
+	for rows.Next() {
+		a := &BigQAnswer{}
+		if err := rows.Scan(&a.ID, &a.PromptID, &a.Phone, &a.QuestionID, &a.Question, &a.Body, &a.Consent, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// Contributions returns every answer shareable as training data, oldest
+// first: given with consent by a subscriber who hasn't since withdrawn it.
+func (db *DB) Contributions() ([]*Contribution, error) {
+	rows, err := db.conn.Query(
+		`SELECT a.id, a.question_id, q.text, a.body, s.contributor_id, a.created_at
+		 FROM bq_answers a
+		 JOIN bq_questions q ON q.id = a.question_id
+		 JOIN bq_subscribers s ON s.phone = a.phone
+		 WHERE a.consent = 1 AND s.consent = 1
+		 ORDER BY a.id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Contribution
+	for rows.Next() {
+		c := &Contribution{}
+		if err := rows.Scan(&c.AnswerID, &c.QuestionID, &c.Question, &c.Answer, &c.Contributor, &c.AnsweredAt); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// --- Points ---
+
+// PointsBalance returns phone's total points.
+func (db *DB) PointsBalance(phone string) (int, error) {
+	var n int
+	err := db.conn.QueryRow(`SELECT COALESCE(SUM(delta), 0) FROM points_ledger WHERE phone = ?`, phone).Scan(&n)
+	return n, err
+}
+
+// PointsLedger returns phone's most recent points entries, newest first.
+func (db *DB) PointsLedger(phone string, limit int) ([]*PointsEntry, error) {
+	rows, err := db.conn.Query(
+		`SELECT id, phone, delta, reason, ref, created_at FROM points_ledger
+		 WHERE phone = ? ORDER BY id DESC LIMIT ?`, phone, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*PointsEntry
+	for rows.Next() {
+		e := &PointsEntry{}
+		if err := rows.Scan(&e.ID, &e.Phone, &e.Delta, &e.Reason, &e.Ref, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
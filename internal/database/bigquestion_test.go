@@ -0,0 +1,102 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBigQuestion(t *testing.T) {
+	db := testDB(t)
+	now := time.Now().UTC()
+
+	q1 := &BigQuestion{Text: "What made you laugh today?", Active: true, CreatedAt: now}
+	q2 := &BigQuestion{Text: "Who taught you to cook?", Active: true, CreatedAt: now}
+	for _, q := range []*BigQuestion{q1, q2} {
+		if err := db.CreateBigQuestion(q); err != nil {
+			t.Fatalf("create question: %v", err)
+		}
+	}
+
+	alice := &BigQSubscriber{Phone: "15555555555", Active: true, Timezone: "UTC", SendMinute: 19 * 60,
+		Consent: true, ConsentAt: &now, ContributorID: "c_alice", CreatedAt: now}
+	bob := &BigQSubscriber{Phone: "15550000000", Active: true, Timezone: "UTC", SendMinute: 8 * 60,
+		ContributorID: "c_bob", CreatedAt: now}
+	for _, s := range []*BigQSubscriber{alice, bob} {
+		if err := db.SaveBigQSubscriber(s); err != nil {
+			t.Fatalf("save subscriber: %v", err)
+		}
+	}
+	got, err := db.BigQSubscriber(alice.Phone)
+	if err != nil || got == nil || !got.Consent || got.ConsentAt == nil || got.ContributorID != "c_alice" {
+		t.Fatalf("BigQSubscriber = %+v, %v", got, err)
+	}
+
+	// Each phone works through the questions in order.
+	next, err := db.NextBigQuestion(alice.Phone)
+	if err != nil || next == nil || next.ID != q1.ID {
+		t.Fatalf("NextBigQuestion = %+v, %v; want q1", next, err)
+	}
+	p := &BigQPrompt{Phone: alice.Phone, QuestionID: q1.ID, LocalDate: "2026-03-04", SentAt: now}
+	if ok, err := db.CreateBigQPrompt(p); !ok || err != nil {
+		t.Fatalf("CreateBigQPrompt = %v, %v", ok, err)
+	}
+	if ok, err := db.CreateBigQPrompt(&BigQPrompt{Phone: alice.Phone, QuestionID: q2.ID, LocalDate: "2026-03-04", SentAt: now}); ok || err != nil {
+		t.Errorf("second prompt on the same day = %v, %v; want false", ok, err)
+	}
+	if next, _ := db.NextBigQuestion(alice.Phone); next == nil || next.ID != q2.ID {
+		t.Errorf("NextBigQuestion after q1 = %+v, want q2", next)
+	}
+	if ok, _ := db.SetBigQuestionActive(q2.ID, false); !ok {
+		t.Error("SetBigQuestionActive(q2) found nothing")
+	}
+	if next, _ := db.NextBigQuestion(alice.Phone); next != nil {
+		t.Errorf("NextBigQuestion with q2 inactive = %+v, want nil", next)
+	}
+
+	latest, text, err := db.LatestBigQPrompt(alice.Phone)
+	if err != nil || latest == nil || latest.ID != p.ID || text != q1.Text {
+		t.Fatalf("LatestBigQPrompt = %+v, %q, %v", latest, text, err)
+	}
+
+	a := &BigQAnswer{PromptID: p.ID, Phone: alice.Phone, QuestionID: q1.ID, Body: "my dog", Consent: true, CreatedAt: now}
+	if ok, err := db.RecordBigQAnswer(a, 10, "contribution"); !ok || err != nil {
+		t.Fatalf("RecordBigQAnswer = %v, %v", ok, err)
+	}
+	if ok, err := db.RecordBigQAnswer(&BigQAnswer{PromptID: p.ID, Phone: alice.Phone, QuestionID: q1.ID, Body: "again", CreatedAt: now}, 10, "contribution"); ok || err != nil {
+		t.Errorf("second answer = %v, %v; want false", ok, err)
+	}
+	if n, err := db.PointsBalance(alice.Phone); n != 10 || err != nil {
+		t.Errorf("PointsBalance = %d, %v; want 10", n, err)
+	}
+	if ledger, _ := db.PointsLedger(alice.Phone, 10); len(ledger) != 1 || ledger[0].Ref == "" {
+		t.Errorf("PointsLedger = %+v", ledger)
+	}
+
+	// Bob answers privately.
+	bp := &BigQPrompt{Phone: bob.Phone, QuestionID: q1.ID, LocalDate: "2026-03-04", SentAt: now}
+	db.CreateBigQPrompt(bp)
+	db.RecordBigQAnswer(&BigQAnswer{PromptID: bp.ID, Phone: bob.Phone, QuestionID: q1.ID, Body: "a movie", CreatedAt: now}, 0, "")
+
+	answers, err := db.BigQAnswers(q1.ID, "", 10)
+	if err != nil || len(answers) != 2 || answers[0].Body != "a movie" || answers[1].Question != q1.Text {
+		t.Errorf("BigQAnswers = %+v, %v", answers, err)
+	}
+	if answers, _ := db.BigQAnswers(0, bob.Phone, 10); len(answers) != 1 {
+		t.Errorf("BigQAnswers(bob) = %d answers, want 1", len(answers))
+	}
+
+	list, err := db.Contributions()
+	if err != nil || len(list) != 1 || list[0].Contributor != "c_alice" || list[0].Answer != "my dog" {
+		t.Fatalf("Contributions = %+v, %v", list, err)
+	}
+	// Withdrawing consent removes earlier answers from the export.
+	alice.Consent, alice.ConsentAt = false, nil
+	db.SaveBigQSubscriber(alice)
+	if list, _ := db.Contributions(); len(list) != 0 {
+		t.Errorf("Contributions after withdrawal = %+v, want none", list)
+	}
+
+	if subs, _ := db.BigQSubscribers(true); len(subs) != 2 {
+		t.Errorf("BigQSubscribers = %d, want 2", len(subs))
+	}
+}
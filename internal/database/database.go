@@ -0,0 +1,598 @@
+// Package database stores the nexus SMS assistant's memory (messages and
+// notes) in SQLite.
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// DB wraps the SQLite connection.
+type DB struct {
+	conn          *sql.DB
+	threadTimeout time.Duration
+}
+
+// Message directions.
+const (
+	DirectionInbound  = "inbound"
+	DirectionOutbound = "outbound"
+)
+
+// Message is a single SMS sent to or from the assistant.
+type Message struct {
+	ID         int64     `json:"id"`
+	Phone      string    `json:"phone"`     // normalized (identity.NormalizePhone)
+	Direction  string    `json:"direction"` // inbound, outbound
+	Body       string    `json:"body"`
+	MessageSID string    `json:"message_sid"`          // Twilio MessageSid, if known
+	UserID     string    `json:"user_id,omitempty"`    // linked portal account, if any
+	ThreadID   int64     `json:"thread_id"`            // set by RecordMessage
+	Status     string    `json:"status,omitempty"`     // delivery status of outbound messages (Status*)
+	ErrorCode  string    `json:"error_code,omitempty"` // Twilio error code for failed deliveries
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Note is a piece of text a user asked the assistant to remember.
+type Note struct {
+	ID        int64     `json:"id"`
+	Phone     string    `json:"phone"` // normalized owner phone
+	Body      string    `json:"body"`
+	MessageID int64     `json:"message_id,omitempty"` // message the note came from; its media belong to the note
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Media is an attachment on an inbound MMS. The bytes live in the blob
+// store under SHA256; SHA256 is empty if the download failed.
+type Media struct {
+	ID          int64     `json:"id"`
+	MessageID   int64     `json:"message_id"`
+	Phone       string    `json:"phone"`
+	URL         string    `json:"url"` // Twilio media URL
+	ContentType string    `json:"content_type"`
+	SHA256      string    `json:"sha256,omitempty"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CalFeed links a phone to its nexus-cal reminder feed.
+type CalFeed struct {
+	Phone     string    `json:"phone"`
+	FeedID    string    `json:"feed_id"`
+	Token     string    `json:"token"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS messages (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	phone       TEXT NOT NULL,
+	direction   TEXT NOT NULL,
+	body        TEXT NOT NULL DEFAULT '',
+	message_sid TEXT NOT NULL DEFAULT '',
+	user_id     TEXT NOT NULL DEFAULT '',
+	thread_id   INTEGER NOT NULL DEFAULT 0,
+	status      TEXT NOT NULL DEFAULT '',
+	error_code  TEXT NOT NULL DEFAULT '',
+	created_at  DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_messages_phone ON messages(phone, created_at);
+CREATE INDEX IF NOT EXISTS idx_messages_thread ON messages(thread_id, id);
+CREATE INDEX IF NOT EXISTS idx_messages_sid ON messages(message_sid) WHERE message_sid != '';
+
+-- A thread is a run of messages with one sender; a gap longer than the
+-- thread timeout starts a new one.
+CREATE TABLE IF NOT EXISTS threads (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	phone           TEXT NOT NULL,
+	user_id         TEXT NOT NULL DEFAULT '',
+	started_at      DATETIME NOT NULL,
+	last_message_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_threads_phone ON threads(phone, id);
+
+CREATE TABLE IF NOT EXISTS notes (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	phone      TEXT NOT NULL,
+	body       TEXT NOT NULL,
+	message_id INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notes_phone ON notes(phone, created_at);
+
+-- Full-text index over note bodies, kept in sync by triggers.
+CREATE VIRTUAL TABLE IF NOT EXISTS notes_fts USING fts5(
+	body,
+	content='notes',
+	content_rowid='id'
+);
+
+CREATE TRIGGER IF NOT EXISTS notes_ai AFTER INSERT ON notes BEGIN
+	INSERT INTO notes_fts(rowid, body) VALUES (new.id, new.body);
+END;
+
+CREATE TRIGGER IF NOT EXISTS notes_ad AFTER DELETE ON notes BEGIN
+	INSERT INTO notes_fts(notes_fts, rowid, body) VALUES ('delete', old.id, old.body);
+END;
+
+CREATE TRIGGER IF NOT EXISTS notes_au AFTER UPDATE ON notes BEGIN
+	INSERT INTO notes_fts(notes_fts, rowid, body) VALUES ('delete', old.id, old.body);
+	INSERT INTO notes_fts(rowid, body) VALUES (new.id, new.body);
+END;
+
+-- MMS attachments; bytes are kept in the content-addressed blob store.
+CREATE TABLE IF NOT EXISTS media (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	message_id   INTEGER NOT NULL REFERENCES messages(id),
+	phone        TEXT NOT NULL,
+	url          TEXT NOT NULL,
+	content_type TEXT NOT NULL DEFAULT '',
+	sha256       TEXT NOT NULL DEFAULT '',
+	size         INTEGER NOT NULL DEFAULT 0,
+	created_at   DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_media_message ON media(message_id);
+CREATE INDEX IF NOT EXISTS idx_media_sha256 ON media(sha256);
+
+-- Numbers that replied STOP. Nothing is sent to them until they reply START.
+CREATE TABLE IF NOT EXISTS opt_outs (
+	phone        TEXT PRIMARY KEY,
+	opted_out_at DATETIME NOT NULL
+);
+
+-- Per-sender token buckets for inbound rate limiting (ratelimit.TokenBucket).
+CREATE TABLE IF NOT EXISTS rate_buckets (
+	key         TEXT PRIMARY KEY,
+	tokens      REAL NOT NULL,
+	updated_at  DATETIME NOT NULL,
+	notified_at DATETIME
+);
+
+-- Numbers blocked by an admin; their messages are dropped unprocessed.
+CREATE TABLE IF NOT EXISTS blocklist (
+	phone      TEXT PRIMARY KEY,
+	reason     TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL
+);
+
+-- Automation rules (internal/rules). Trigger, conditions and actions are
+-- stored as JSON in definition; trigger_event is copied out for lookups.
+CREATE TABLE IF NOT EXISTS rules (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	name          TEXT NOT NULL,
+	enabled       INTEGER NOT NULL DEFAULT 1,
+	trigger_event TEXT NOT NULL,
+	definition    TEXT NOT NULL,
+	created_at    DATETIME NOT NULL,
+	updated_at    DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rules_trigger ON rules(trigger_event, enabled);
+
+CREATE TABLE IF NOT EXISTS rule_runs (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	rule_id     INTEGER NOT NULL REFERENCES rules(id) ON DELETE CASCADE,
+	event       TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	detail      TEXT NOT NULL DEFAULT '',
+	started_at  DATETIME NOT NULL,
+	duration_ms INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_rule_runs_rule ON rule_runs(rule_id, id);
+
+-- Events from polled sources that have already triggered rules.
+CREATE TABLE IF NOT EXISTS fired_events (
+	key      TEXT PRIMARY KEY,
+	fired_at DATETIME NOT NULL
+);
+
+-- Outgoing webhooks (internal/webhooks). events is a comma-separated list
+-- of event types; deliveries are the durable retry queue.
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	url        TEXT NOT NULL,
+	secret     TEXT NOT NULL,
+	events     TEXT NOT NULL,
+	enabled    INTEGER NOT NULL DEFAULT 1,
+	created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	subscription_id INTEGER NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+	event           TEXT NOT NULL,
+	payload         TEXT NOT NULL,
+	status          TEXT NOT NULL,
+	attempts        INTEGER NOT NULL DEFAULT 0,
+	response_code   INTEGER NOT NULL DEFAULT 0,
+	last_error      TEXT NOT NULL DEFAULT '',
+	next_attempt_at DATETIME NOT NULL,
+	created_at      DATETIME NOT NULL,
+	updated_at      DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_sub ON webhook_deliveries(subscription_id, id);
+
+-- The Big Question: an admin-managed question bank, the people who get a
+-- question a day at their local send time, what was sent (one prompt per
+-- phone per local day) and the answers.
+CREATE TABLE IF NOT EXISTS bq_questions (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	text       TEXT NOT NULL,
+	active     INTEGER NOT NULL DEFAULT 1,
+	created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS bq_subscribers (
+	phone          TEXT PRIMARY KEY,
+	active         INTEGER NOT NULL DEFAULT 1,
+	timezone       TEXT NOT NULL,
+	send_minute    INTEGER NOT NULL, -- minutes after local midnight
+	consent        INTEGER NOT NULL DEFAULT 0,
+	consent_at     DATETIME,
+	contributor_id TEXT NOT NULL,    -- pseudonym used in training data exports
+	created_at     DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS bq_prompts (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	phone       TEXT NOT NULL,
+	question_id INTEGER NOT NULL REFERENCES bq_questions(id),
+	local_date  TEXT NOT NULL, -- YYYY-MM-DD in the subscriber's time zone
+	sent_at     DATETIME NOT NULL,
+	UNIQUE(phone, local_date)
+);
+
+CREATE TABLE IF NOT EXISTS bq_answers (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	prompt_id   INTEGER NOT NULL UNIQUE REFERENCES bq_prompts(id),
+	phone       TEXT NOT NULL,
+	question_id INTEGER NOT NULL REFERENCES bq_questions(id),
+	body        TEXT NOT NULL,
+	consent     INTEGER NOT NULL, -- sharing consent when the answer was given
+	created_at  DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_bq_answers_question ON bq_answers(question_id, id);
+
+-- Points earned per phone; the balance is the sum of deltas.
+CREATE TABLE IF NOT EXISTS points_ledger (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	phone      TEXT NOT NULL,
+	delta      INTEGER NOT NULL,
+	reason     TEXT NOT NULL,
+	ref        TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_points_ledger_phone ON points_ledger(phone, id);
+
+-- Phone PINs (bcrypt hashes) callers enter before the voice menu reads
+-- their calendar or records a memo, and the calls that entered one.
+CREATE TABLE IF NOT EXISTS voice_pins (
+	phone      TEXT PRIMARY KEY,
+	pin_hash   TEXT NOT NULL,
+	updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS voice_calls (
+	call_sid    TEXT PRIMARY KEY,
+	phone       TEXT NOT NULL,
+	verified_at DATETIME NOT NULL
+);
+
+-- Each phone gets its own nexus-cal feed for SMS reminders.
+CREATE TABLE IF NOT EXISTS cal_feeds (
+	phone      TEXT PRIMARY KEY,
+	feed_id    TEXT NOT NULL,
+	token      TEXT NOT NULL,
+	created_at DATETIME NOT NULL
+);
+`
+
+// Open creates or opens the SQLite database at path and applies the schema.
+func Open(path string) (*DB, error) {
+	conn, err := sql.Open("sqlite", path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ping database: %w", err)
+	}
+	if _, err := conn.Exec(schema); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("apply schema: %w", err)
+	}
+	return &DB{conn: conn, threadTimeout: DefaultThreadTimeout}, nil
+}
+
+// Close shuts down the database connection.
+func (db *DB) Close() error {
+	return db.conn.Close()
+}
+
+// --- Message operations ---
+
+// RecordMessage inserts a message into the sender's current thread,
+// starting a new thread if the last one has been idle longer than the
+// thread timeout, and sets the message's ID and ThreadID.
+func (db *DB) RecordMessage(m *Message) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if m.ThreadID, err = db.threadFor(tx, m); err != nil {
+		return fmt.Errorf("assign thread: %w", err)
+	}
+	res, err := tx.Exec(
+		`INSERT INTO messages (phone, direction, body, message_sid, user_id, thread_id, status, error_code, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		m.Phone, m.Direction, m.Body, m.MessageSID, m.UserID, m.ThreadID, m.Status, m.ErrorCode, m.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if m.ID, err = res.LastInsertId(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RecordOutbound records a message sent to phone. It implements
+// sms.MessageLog.
+func (db *DB) RecordOutbound(phone, body, sid string, at time.Time) error {
+	return db.RecordMessage(&Message{
+		Phone:      phone,
+		Direction:  DirectionOutbound,
+		Body:       body,
+		MessageSID: sid,
+		CreatedAt:  at,
+	})
+}
+
+// MessagesByPhone returns the most recent messages for a phone, newest first.
+func (db *DB) MessagesByPhone(phone string, limit int) ([]*Message, error) {
+	rows, err := db.conn.Query(
+		`SELECT `+messageColumns+`
+		 FROM messages WHERE phone = ? ORDER BY created_at DESC, id DESC LIMIT ?`,
+		phone, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanMessages(rows)
+}
+
+const messageColumns = `id, phone, direction, body, message_sid, user_id, thread_id, status, error_code, created_at`
+
+func scanMessages(rows *sql.Rows) ([]*Message, error) {
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		m := &Message{}
+		if err := rows.Scan(&m.ID, &m.Phone, &m.Direction, &m.Body, &m.MessageSID, &m.UserID, &m.ThreadID, &m.Status, &m.ErrorCode, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+// --- Note operations ---
+
+// CreateNote inserts a note and sets its ID.
+func (db *DB) CreateNote(n *Note) error {
+	res, err := db.conn.Exec(
+		`INSERT INTO notes (phone, body, message_id, created_at) VALUES (?, ?, ?, ?)`,
+		n.Phone, n.Body, n.MessageID, n.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	n.ID, err = res.LastInsertId()
+	return err
+}
+
+// RecentNotes returns a phone's most recent notes, newest first.
+func (db *DB) RecentNotes(phone string, limit int) ([]*Note, error) {
+	return db.queryNotes(
+		`SELECT id, phone, body, message_id, created_at FROM notes
+		 WHERE phone = ? ORDER BY created_at DESC, id DESC LIMIT ?`,
+		phone, limit,
+	)
+}
+
+// SearchNotes runs a full-text search over a phone's notes and returns the
+// best matches first. Every word in query must appear in a note (prefix
+// matches count, so "dent" finds "dentist").
+func (db *DB) SearchNotes(phone, query string, limit int) ([]*Note, error) {
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+	return db.queryNotes(
+		`SELECT n.id, n.phone, n.body, n.message_id, n.created_at
+		 FROM notes_fts f JOIN notes n ON n.id = f.rowid
+		 WHERE notes_fts MATCH ? AND n.phone = ?
+		 ORDER BY bm25(notes_fts), n.created_at DESC LIMIT ?`,
+		match, phone, limit,
+	)
+}
+
+// DeleteNote removes a note owned by phone.
+func (db *DB) DeleteNote(phone string, id int64) error {
+	_, err := db.conn.Exec(`DELETE FROM notes WHERE id = ? AND phone = ?`, id, phone)
+	return err
+}
+
+func (db *DB) queryNotes(q string, args ...interface{}) ([]*Note, error) {
+	rows, err := db.conn.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []*Note
+	for rows.Next() {
+		n := &Note{}
+		if err := rows.Scan(&n.ID, &n.Phone, &n.Body, &n.MessageID, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+// ftsQuery turns free text into an FTS5 MATCH expression. Each word is
+// quoted (so user input can't inject FTS operators) and made a prefix match;
+// words are implicitly ANDed.
+func ftsQuery(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		word = strings.ReplaceAll(word, `"`, `""`)
+		terms = append(terms, `"`+word+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// --- Media operations ---
+
+// CreateMedia inserts an attachment record and sets its ID.
+func (db *DB) CreateMedia(m *Media) error {
+	res, err := db.conn.Exec(
+		`INSERT INTO media (message_id, phone, url, content_type, sha256, size, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		m.MessageID, m.Phone, m.URL, m.ContentType, m.SHA256, m.Size, m.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	m.ID, err = res.LastInsertId()
+	return err
+}
+
+// MediaByMessage returns a message's attachments in the order received.
+func (db *DB) MediaByMessage(messageID int64) ([]*Media, error) {
+	rows, err := db.conn.Query(
+		`SELECT id, message_id, phone, url, content_type, sha256, size, created_at
+		 FROM media WHERE message_id = ? ORDER BY id`,
+		messageID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var media []*Media
+	for rows.Next() {
+		m := &Media{}
+		if err := rows.Scan(&m.ID, &m.MessageID, &m.Phone, &m.URL, &m.ContentType, &m.SHA256, &m.Size, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		media = append(media, m)
+	}
+	return media, rows.Err()
+}
+
+// MediaBySHA256 returns the first attachment stored under a blob hash, or
+// nil if there is none.
+func (db *DB) MediaBySHA256(sum string) (*Media, error) {
+	m := &Media{}
+	err := db.conn.QueryRow(
+		`SELECT id, message_id, phone, url, content_type, sha256, size, created_at
+		 FROM media WHERE sha256 = ? ORDER BY id LIMIT 1`,
+		sum,
+	).Scan(&m.ID, &m.MessageID, &m.Phone, &m.URL, &m.ContentType, &m.SHA256, &m.Size, &m.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// --- Calendar feed operations ---
+
+// SaveCalFeed stores the reminder feed for a phone.
+func (db *DB) SaveCalFeed(f *CalFeed) error {
+	_, err := db.conn.Exec(
+		`INSERT INTO cal_feeds (phone, feed_id, token, created_at) VALUES (?, ?, ?, ?)`,
+		f.Phone, f.FeedID, f.Token, f.CreatedAt,
+	)
+	return err
+}
+
+// CalFeeds returns every phone's reminder feed.
+func (db *DB) CalFeeds() ([]*CalFeed, error) {
+	rows, err := db.conn.Query(`SELECT phone, feed_id, token, created_at FROM cal_feeds ORDER BY phone`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feeds []*CalFeed
+	for rows.Next() {
+		f := &CalFeed{}
+		if err := rows.Scan(&f.Phone, &f.FeedID, &f.Token, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, f)
+	}
+	return feeds, rows.Err()
+}
+
+// CalFeedByPhone returns the reminder feed for a phone, or nil if it has none.
+func (db *DB) CalFeedByPhone(phone string) (*CalFeed, error) {
+	f := &CalFeed{}
+	err := db.conn.QueryRow(
+		`SELECT phone, feed_id, token, created_at FROM cal_feeds WHERE phone = ?`,
+		phone,
+	).Scan(&f.Phone, &f.FeedID, &f.Token, &f.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// --- Opt-out operations ---
+
+// OptOut records that phone replied STOP.
+func (db *DB) OptOut(phone string, at time.Time) error {
+	_, err := db.conn.Exec(
+		`INSERT INTO opt_outs (phone, opted_out_at) VALUES (?, ?)
+		 ON CONFLICT(phone) DO NOTHING`,
+		phone, at,
+	)
+	return err
+}
+
+// OptIn removes phone from the opt-out list.
+func (db *DB) OptIn(phone string) error {
+	_, err := db.conn.Exec(`DELETE FROM opt_outs WHERE phone = ?`, phone)
+	return err
+}
+
+// IsOptedOut reports whether phone has opted out.
+func (db *DB) IsOptedOut(phone string) (bool, error) {
+	var n int
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM opt_outs WHERE phone = ?`, phone).Scan(&n)
+	return n > 0, err
+}
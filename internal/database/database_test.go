@@ -0,0 +1,184 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func testDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := Open(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestRecordMessage(t *testing.T) {
+	db := testDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for i, body := range []string{"first", "second"} {
+		m := &Message{
+			Phone:     "15555555555",
+			Direction: DirectionInbound,
+			Body:      body,
+			UserID:    "user-1",
+			CreatedAt: now.Add(time.Duration(i) * time.Minute),
+		}
+		if err := db.RecordMessage(m); err != nil {
+			t.Fatalf("record message: %v", err)
+		}
+		if m.ID == 0 {
+			t.Error("expected ID to be set")
+		}
+	}
+
+	msgs, err := db.MessagesByPhone("15555555555", 10)
+	if err != nil {
+		t.Fatalf("messages by phone: %v", err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	if msgs[0].Body != "second" {
+		t.Errorf("expected newest first, got %q", msgs[0].Body)
+	}
+	if msgs[0].UserID != "user-1" {
+		t.Errorf("expected user ID to round-trip, got %q", msgs[0].UserID)
+	}
+
+	other, err := db.MessagesByPhone("15550000000", 10)
+	if err != nil {
+		t.Fatalf("messages for other phone: %v", err)
+	}
+	if len(other) != 0 {
+		t.Errorf("expected no messages for other phone, got %d", len(other))
+	}
+}
+
+func TestNotesSearch(t *testing.T) {
+	db := testDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	notes := []struct {
+		phone string
+		body  string
+	}{
+		{"15555555555", "Dentist appointment is on the 14th"},
+		{"15555555555", "Buy milk, eggs and bread"},
+		{"15555555555", "Wifi password at the cabin is hunter2"},
+		{"15550000000", "Dentist is Dr. Smith"},
+	}
+	for i, n := range notes {
+		if err := db.CreateNote(&Note{Phone: n.phone, Body: n.body, CreatedAt: now.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatalf("create note: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"dentist", []string{"Dentist appointment is on the 14th"}},
+		{"dent", []string{"Dentist appointment is on the 14th"}}, // prefix match
+		{"MILK bread", []string{"Buy milk, eggs and bread"}},
+		{"milk dentist", nil}, // all words must match
+		{`cabin" OR "milk`, nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		got, err := db.SearchNotes("15555555555", tt.query, 10)
+		if err != nil {
+			t.Fatalf("search %q: %v", tt.query, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("search %q: expected %d results, got %d", tt.query, len(tt.want), len(got))
+			continue
+		}
+		for i := range got {
+			if got[i].Body != tt.want[i] {
+				t.Errorf("search %q: result %d = %q, want %q", tt.query, i, got[i].Body, tt.want[i])
+			}
+		}
+	}
+
+	recent, err := db.RecentNotes("15555555555", 2)
+	if err != nil {
+		t.Fatalf("recent notes: %v", err)
+	}
+	if len(recent) != 2 || recent[0].Body != "Wifi password at the cabin is hunter2" {
+		t.Errorf("unexpected recent notes: %+v", recent)
+	}
+
+	// Deleting a note removes it from the full-text index.
+	if err := db.DeleteNote("15555555555", recent[0].ID); err != nil {
+		t.Fatalf("delete note: %v", err)
+	}
+	got, err := db.SearchNotes("15555555555", "cabin", 10)
+	if err != nil {
+		t.Fatalf("search after delete: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected deleted note to be gone from search, got %d results", len(got))
+	}
+}
+
+func TestOptOut(t *testing.T) {
+	db := testDB(t)
+	const phone = "15555555555"
+
+	if out, err := db.IsOptedOut(phone); err != nil || out {
+		t.Fatalf("new number: IsOptedOut = (%v, %v)", out, err)
+	}
+	if err := db.OptOut(phone, time.Now()); err != nil {
+		t.Fatalf("opt out: %v", err)
+	}
+	// Opting out twice is not an error.
+	if err := db.OptOut(phone, time.Now()); err != nil {
+		t.Fatalf("opt out again: %v", err)
+	}
+	if out, _ := db.IsOptedOut(phone); !out {
+		t.Error("expected number to be opted out")
+	}
+	if err := db.OptIn(phone); err != nil {
+		t.Fatalf("opt in: %v", err)
+	}
+	if out, _ := db.IsOptedOut(phone); out {
+		t.Error("expected number to be opted back in")
+	}
+}
+
+func TestMedia(t *testing.T) {
+	db := testDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	msg := &Message{Phone: "15555555555", Direction: DirectionInbound, Body: "receipt", CreatedAt: now}
+	if err := db.RecordMessage(msg); err != nil {
+		t.Fatalf("record message: %v", err)
+	}
+	for _, sum := range []string{"abc", ""} {
+		m := &Media{MessageID: msg.ID, Phone: msg.Phone, URL: "https://api.twilio.com/m/" + sum, ContentType: "image/jpeg", SHA256: sum, CreatedAt: now}
+		if err := db.CreateMedia(m); err != nil {
+			t.Fatalf("create media: %v", err)
+		}
+	}
+
+	media, err := db.MediaByMessage(msg.ID)
+	if err != nil {
+		t.Fatalf("media by message: %v", err)
+	}
+	if len(media) != 2 || media[0].SHA256 != "abc" || media[1].SHA256 != "" {
+		t.Errorf("unexpected media: %+v", media)
+	}
+
+	m, err := db.MediaBySHA256("abc")
+	if err != nil || m == nil || m.ContentType != "image/jpeg" {
+		t.Errorf("media by sha256 = %+v, %v", m, err)
+	}
+	if m, err := db.MediaBySHA256("missing"); err != nil || m != nil {
+		t.Errorf("missing media = %+v, %v; want nil, nil", m, err)
+	}
+}
@@ -0,0 +1,134 @@
+package database
+
+import "database/sql"
+
+// Delivery statuses reported by Twilio status callbacks for outbound
+// messages. Messages sent as inline TwiML replies have no SID and no status.
+const (
+	StatusAccepted    = "accepted"
+	StatusQueued      = "queued"
+	StatusSending     = "sending"
+	StatusSent        = "sent"
+	StatusDelivered   = "delivered"
+	StatusUndelivered = "undelivered"
+	StatusFailed      = "failed"
+	StatusRead        = "read"
+)
+
+// statusRank orders statuses so that callbacks arriving out of order never
+// move a message backwards (e.g. a late "sent" after "delivered"). Final
+// statuses share a rank; see finalStatus for how they are ordered.
+var statusRank = map[string]int{
+	StatusAccepted:    1,
+	StatusQueued:      2,
+	StatusSending:     3,
+	StatusSent:        4,
+	StatusUndelivered: 5,
+	StatusFailed:      5,
+	StatusDelivered:   5,
+	StatusRead:        6,
+}
+
+// finalStatus reports whether status ends a message's delivery. Once a
+// message reaches one, later callbacks are ignored, except that a
+// delivered message can still be read.
+func finalStatus(status string) bool {
+	return statusRank[status] >= statusRank[StatusDelivered]
+}
+
+// KnownStatus reports whether status is a delivery status we track.
+func KnownStatus(status string) bool {
+	_, ok := statusRank[status]
+	return ok
+}
+
+// DeliveryStats summarizes delivery of outbound messages to one recipient.
+// ConsecutiveFailures counts failures since the last successful delivery;
+// a number that keeps failing is probably dead or a landline.
+type DeliveryStats struct {
+	Phone               string `json:"phone"`
+	Sent                int    `json:"sent"`
+	Delivered           int    `json:"delivered"`
+	Failed              int    `json:"failed"` // failed or undelivered
+	Pending             int    `json:"pending"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	LastErrorCode       string `json:"last_error_code,omitempty"`
+}
+
+// UpdateMessageStatus records a delivery status for the message with the
+// given provider SID. A status that ranks below the current one, or any
+// change to a final status other than delivered to read, is ignored. It
+// reports whether a message with that SID exists.
+func (db *DB) UpdateMessageStatus(sid, status, errorCode string) (bool, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRow(`SELECT status FROM messages WHERE message_sid = ?`, sid).Scan(&current)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if statusRank[status] < statusRank[current] {
+		return true, nil
+	}
+	if finalStatus(current) && status != current && !(current == StatusDelivered && status == StatusRead) {
+		return true, nil
+	}
+	if _, err := tx.Exec(
+		`UPDATE messages SET status = ?, error_code = ? WHERE message_sid = ?`,
+		status, errorCode, sid,
+	); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// DeliveryStats returns per-recipient delivery stats for outbound messages
+// sent through the provider API, worst first (most consecutive failures).
+// An empty phone covers every recipient; minFailures filters to recipients
+// with at least that many consecutive failures.
+func (db *DB) DeliveryStats(phone string, minFailures, limit int) ([]*DeliveryStats, error) {
+	rows, err := db.conn.Query(
+		`SELECT phone, sent, delivered, failed, pending, consecutive, last_error FROM (
+			SELECT m.phone AS phone,
+				COUNT(*) AS sent,
+				SUM(m.status IN ('delivered', 'read')) AS delivered,
+				SUM(m.status IN ('failed', 'undelivered')) AS failed,
+				SUM(m.status NOT IN ('delivered', 'read', 'failed', 'undelivered')) AS pending,
+				(SELECT COUNT(*) FROM messages f
+				 WHERE f.phone = m.phone AND f.direction = 'outbound'
+				   AND f.status IN ('failed', 'undelivered')
+				   AND f.id > COALESCE((SELECT MAX(d.id) FROM messages d
+					WHERE d.phone = m.phone AND d.direction = 'outbound'
+					  AND d.status IN ('delivered', 'read')), 0)) AS consecutive,
+				COALESCE((SELECT e.error_code FROM messages e
+				 WHERE e.phone = m.phone AND e.direction = 'outbound' AND e.error_code != ''
+				 ORDER BY e.id DESC LIMIT 1), '') AS last_error
+			FROM messages m
+			WHERE m.direction = 'outbound' AND m.message_sid != '' AND (? = '' OR m.phone = ?)
+			GROUP BY m.phone
+		 ) WHERE consecutive >= ?
+		 ORDER BY consecutive DESC, failed DESC, phone LIMIT ?`,
+		phone, phone, minFailures, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*DeliveryStats
+	for rows.Next() {
+		s := &DeliveryStats{}
+		if err := rows.Scan(&s.Phone, &s.Sent, &s.Delivered, &s.Failed, &s.Pending, &s.ConsecutiveFailures, &s.LastErrorCode); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
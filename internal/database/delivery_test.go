@@ -0,0 +1,124 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpdateMessageStatus(t *testing.T) {
+	db := testDB(t)
+	if err := db.RecordOutbound("15555555555", "reminder", "SM1", time.Now().UTC()); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	tests := []struct {
+		sid, status, errorCode string
+		found                  bool
+		want                   string
+	}{
+		{"SM1", StatusQueued, "", true, StatusQueued},
+		{"SM1", StatusDelivered, "", true, StatusDelivered},
+		{"SM1", StatusSent, "", true, StatusDelivered},        // late callback ignored
+		{"SM1", StatusFailed, "30003", true, StatusDelivered}, // final statuses stick
+		{"SM1", StatusUndelivered, "30005", true, StatusDelivered},
+		{"SM1", StatusRead, "", true, StatusRead},
+		{"SM1", StatusFailed, "30003", true, StatusRead},
+		{"SM404", StatusDelivered, "", false, StatusRead},
+	}
+	for _, tt := range tests {
+		found, err := db.UpdateMessageStatus(tt.sid, tt.status, tt.errorCode)
+		if err != nil {
+			t.Fatalf("update %s %s: %v", tt.sid, tt.status, err)
+		}
+		if found != tt.found {
+			t.Errorf("update %s %s: found = %v, want %v", tt.sid, tt.status, found, tt.found)
+		}
+		msgs, err := db.MessagesByPhone("15555555555", 1)
+		if err != nil {
+			t.Fatalf("messages: %v", err)
+		}
+		if msgs[0].Status != tt.want {
+			t.Errorf("after %s %s: status = %q, want %q", tt.sid, tt.status, msgs[0].Status, tt.want)
+		}
+	}
+}
+
+func TestDeliveryStats(t *testing.T) {
+	db := testDB(t)
+	now := time.Now().UTC()
+	send := func(phone, sid, status, errorCode string) {
+		t.Helper()
+		if err := db.RecordOutbound(phone, "hi", sid, now); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+		if status != "" {
+			if _, err := db.UpdateMessageStatus(sid, status, errorCode); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+		}
+	}
+
+	// alice: fails once, recovers, then fails twice in a row.
+	send("15550000001", "SM1", StatusFailed, "30003")
+	send("15550000001", "SM2", StatusDelivered, "")
+	send("15550000001", "SM3", StatusUndelivered, "30005")
+	send("15550000001", "SM4", StatusFailed, "30006")
+	// bob: healthy, one still in flight.
+	send("15550000002", "SM5", StatusDelivered, "")
+	send("15550000002", "SM6", StatusSent, "")
+	// TwiML replies have no SID and aren't tracked.
+	if err := db.RecordOutbound("15550000003", "inline reply", "", now); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	stats, err := db.DeliveryStats("", 0, 10)
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("got %d recipients, want 2: %+v", len(stats), stats)
+	}
+	want := DeliveryStats{Phone: "15550000001", Sent: 4, Delivered: 1, Failed: 3, ConsecutiveFailures: 2, LastErrorCode: "30006"}
+	if *stats[0] != want {
+		t.Errorf("alice = %+v, want %+v", *stats[0], want)
+	}
+	want = DeliveryStats{Phone: "15550000002", Sent: 2, Delivered: 1, Pending: 1}
+	if *stats[1] != want {
+		t.Errorf("bob = %+v, want %+v", *stats[1], want)
+	}
+
+	dead, err := db.DeliveryStats("", 2, 10)
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if len(dead) != 1 || dead[0].Phone != "15550000001" {
+		t.Errorf("min_failures=2: %+v", dead)
+	}
+
+	one, err := db.DeliveryStats("15550000002", 0, 10)
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if len(one) != 1 || one[0].Phone != "15550000002" {
+		t.Errorf("phone filter: %+v", one)
+	}
+}
+
+func TestUpdateMessageStatus_FailedSticks(t *testing.T) {
+	db := testDB(t)
+	if err := db.RecordOutbound("15555555555", "reminder", "SM1", time.Now().UTC()); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	for _, status := range []string{StatusFailed, StatusDelivered, StatusUndelivered} {
+		if _, err := db.UpdateMessageStatus("SM1", status, "30003"); err != nil {
+			t.Fatalf("update %s: %v", status, err)
+		}
+	}
+	msgs, err := db.MessagesByPhone("15555555555", 1)
+	if err != nil {
+		t.Fatalf("messages: %v", err)
+	}
+	if msgs[0].Status != StatusFailed {
+		t.Errorf("status = %q, want failed", msgs[0].Status)
+	}
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/rules"
+)
+
+// ruleDefinition is the JSON stored in rules.definition.
+type ruleDefinition struct {
+	Trigger    rules.Trigger     `json:"trigger"`
+	Conditions []rules.Condition `json:"conditions,omitempty"`
+	Actions    []rules.Action    `json:"actions"`
+}
+
+// CreateRule stores a new rule and sets its ID.
+func (db *DB) CreateRule(r *rules.Rule) error {
+	def, err := json.Marshal(ruleDefinition{r.Trigger, r.Conditions, r.Actions})
+	if err != nil {
+		return err
+	}
+	res, err := db.conn.Exec(
+		`INSERT INTO rules (name, enabled, trigger_event, definition, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		r.Name, r.Enabled, r.Trigger.Event, string(def), r.CreatedAt, r.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	r.ID, err = res.LastInsertId()
+	return err
+}
+
+// UpdateRule replaces a rule's name, state and definition and reports
+// whether it exists.
+func (db *DB) UpdateRule(r *rules.Rule) (bool, error) {
+	def, err := json.Marshal(ruleDefinition{r.Trigger, r.Conditions, r.Actions})
+	if err != nil {
+		return false, err
+	}
+	res, err := db.conn.Exec(
+		`UPDATE rules SET name = ?, enabled = ?, trigger_event = ?, definition = ?, updated_at = ?
+		 WHERE id = ?`,
+		r.Name, r.Enabled, r.Trigger.Event, string(def), r.UpdatedAt, r.ID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteRule removes a rule and its run history and reports whether it
+// existed.
+func (db *DB) DeleteRule(id int64) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM rules WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+const ruleColumns = `id, name, enabled, definition, created_at, updated_at`
+
+// Rule returns a rule by ID, or nil if it doesn't exist.
+func (db *DB) Rule(id int64) (*rules.Rule, error) {
+	rows, err := db.conn.Query(`SELECT `+ruleColumns+` FROM rules WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	list, err := scanRules(rows)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[0], nil
+}
+
+// Rules returns every rule, oldest first.
+func (db *DB) Rules() ([]*rules.Rule, error) {
+	rows, err := db.conn.Query(`SELECT ` + ruleColumns + ` FROM rules ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	return scanRules(rows)
+}
+
+// EnabledRules returns the enabled rules triggered by event type t. It
+// implements rules.Store.
+func (db *DB) EnabledRules(t string) ([]*rules.Rule, error) {
+	rows, err := db.conn.Query(
+		`SELECT `+ruleColumns+` FROM rules WHERE trigger_event = ? AND enabled = 1 ORDER BY id`, t,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanRules(rows)
+}
+
+func scanRules(rows *sql.Rows) ([]*rules.Rule, error) {
+	defer rows.Close()
+
+	var list []*rules.Rule
+	for rows.Next() {
+		r := &rules.Rule{}
+		var def string
+		if err := rows.Scan(&r.ID, &r.Name, &r.Enabled, &def, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		var d ruleDefinition
+		if err := json.Unmarshal([]byte(def), &d); err != nil {
+			return nil, err
+		}
+		r.Trigger, r.Conditions, r.Actions = d.Trigger, d.Conditions, d.Actions
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+// RecordRun stores a rule execution and sets its ID. It implements
+// rules.Store.
+func (db *DB) RecordRun(run *rules.Run) error {
+	event, err := json.Marshal(run.Event)
+	if err != nil {
+		return err
+	}
+	res, err := db.conn.Exec(
+		`INSERT INTO rule_runs (rule_id, event, status, detail, started_at, duration_ms)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		run.RuleID, string(event), run.Status, run.Detail, run.StartedAt, run.DurationMS,
+	)
+	if err != nil {
+		return err
+	}
+	run.ID, err = res.LastInsertId()
+	return err
+}
+
+// RuleRuns returns a rule's most recent runs, newest first.
+func (db *DB) RuleRuns(ruleID int64, limit int) ([]*rules.Run, error) {
+	rows, err := db.conn.Query(
+		`SELECT id, rule_id, event, status, detail, started_at, duration_ms
+		 FROM rule_runs WHERE rule_id = ? ORDER BY id DESC LIMIT ?`,
+		ruleID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*rules.Run
+	for rows.Next() {
+		run := &rules.Run{}
+		var event string
+		if err := rows.Scan(&run.ID, &run.RuleID, &event, &run.Status, &run.Detail, &run.StartedAt, &run.DurationMS); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(event), &run.Event); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// MarkFired records that a polled event has triggered rules and reports
+// whether it was new. It implements rules.Store.
+func (db *DB) MarkFired(key string, at time.Time) (bool, error) {
+	res, err := db.conn.Exec(
+		`INSERT INTO fired_events (key, fired_at) VALUES (?, ?) ON CONFLICT(key) DO NOTHING`, key, at,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
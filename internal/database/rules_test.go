@@ -0,0 +1,116 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/rules"
+)
+
+func TestRulesCRUD(t *testing.T) {
+	db := testDB(t)
+	now := time.Now().UTC()
+
+	r := &rules.Rule{
+		Name:       "pizza alert",
+		Enabled:    true,
+		Trigger:    rules.Trigger{Event: rules.EventSMSReceived, Pattern: "pizza"},
+		Conditions: []rules.Condition{{Field: "phone", Op: rules.OpEquals, Value: "15555555555"}},
+		Actions:    []rules.Action{{Type: rules.ActionSendSMS, Body: "🍕"}},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := db.CreateRule(r); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	other := &rules.Rule{Name: "signup", Enabled: true, Trigger: rules.Trigger{Event: rules.EventPortalSignup},
+		Actions: []rules.Action{{Type: rules.ActionWebhook, URL: "https://example.com"}}, CreatedAt: now, UpdatedAt: now}
+	if err := db.CreateRule(other); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	got, err := db.Rule(r.ID)
+	if err != nil || got == nil {
+		t.Fatalf("get: %v, %v", got, err)
+	}
+	if got.Trigger.Pattern != "pizza" || len(got.Conditions) != 1 || got.Actions[0].Body != "🍕" {
+		t.Errorf("round trip lost fields: %+v", got)
+	}
+
+	enabled, err := db.EnabledRules(rules.EventSMSReceived)
+	if err != nil || len(enabled) != 1 || enabled[0].ID != r.ID {
+		t.Errorf("enabled sms rules = %+v, %v", enabled, err)
+	}
+
+	r.Enabled = false
+	if ok, err := db.UpdateRule(r); err != nil || !ok {
+		t.Fatalf("update = %v, %v", ok, err)
+	}
+	if enabled, _ := db.EnabledRules(rules.EventSMSReceived); len(enabled) != 0 {
+		t.Errorf("disabled rule still enabled: %+v", enabled)
+	}
+	if ok, _ := db.UpdateRule(&rules.Rule{ID: 999}); ok {
+		t.Error("update of a missing rule reported success")
+	}
+
+	all, err := db.Rules()
+	if err != nil || len(all) != 2 {
+		t.Errorf("rules = %d, %v", len(all), err)
+	}
+
+	if ok, err := db.DeleteRule(r.ID); err != nil || !ok {
+		t.Errorf("delete = %v, %v", ok, err)
+	}
+	if got, _ := db.Rule(r.ID); got != nil {
+		t.Error("rule still exists after delete")
+	}
+}
+
+func TestRuleRuns(t *testing.T) {
+	db := testDB(t)
+	now := time.Now().UTC()
+	r := &rules.Rule{Name: "r", Enabled: true, Trigger: rules.Trigger{Event: rules.EventPortalSignup},
+		Actions: []rules.Action{{Type: rules.ActionSendSMS, Body: "hi"}}, CreatedAt: now, UpdatedAt: now}
+	if err := db.CreateRule(r); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	for i, status := range []string{rules.RunOK, rules.RunError} {
+		run := &rules.Run{
+			RuleID:    r.ID,
+			Event:     rules.Event{Type: rules.EventPortalSignup, Phone: "15555555555", Data: map[string]string{"username": "tex"}, At: now},
+			Status:    status,
+			Detail:    "1 send_sms: done",
+			StartedAt: now.Add(time.Duration(i) * time.Second),
+		}
+		if err := db.RecordRun(run); err != nil {
+			t.Fatalf("record run: %v", err)
+		}
+	}
+
+	runs, err := db.RuleRuns(r.ID, 10)
+	if err != nil {
+		t.Fatalf("runs: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Status != rules.RunError || runs[1].Event.Data["username"] != "tex" {
+		t.Errorf("unexpected runs: %+v", runs)
+	}
+
+	if _, err := db.DeleteRule(r.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if runs, _ := db.RuleRuns(r.ID, 10); len(runs) != 0 {
+		t.Errorf("runs survived rule deletion: %+v", runs)
+	}
+}
+
+func TestMarkFired(t *testing.T) {
+	db := testDB(t)
+	now := time.Now().UTC()
+	if fresh, err := db.MarkFired("cal:a", now); err != nil || !fresh {
+		t.Errorf("first mark = %v, %v", fresh, err)
+	}
+	if fresh, _ := db.MarkFired("cal:a", now); fresh {
+		t.Error("second mark reported fresh")
+	}
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DefaultThreadTimeout is how long a sender can be quiet before their next
+// message starts a new thread.
+const DefaultThreadTimeout = 30 * time.Minute
+
+// Thread groups consecutive messages with one sender.
+type Thread struct {
+	ID            int64     `json:"id"`
+	Phone         string    `json:"phone"`
+	UserID        string    `json:"user_id,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	LastMessageAt time.Time `json:"last_message_at"`
+	MessageCount  int       `json:"message_count"`
+}
+
+// SetThreadTimeout changes the inactivity timeout used by RecordMessage.
+func (db *DB) SetThreadTimeout(d time.Duration) {
+	db.threadTimeout = d
+}
+
+// threadFor returns the thread m belongs to, creating one if the sender
+// has none or their latest has timed out.
+func (db *DB) threadFor(tx *sql.Tx, m *Message) (int64, error) {
+	var id int64
+	var last time.Time
+	err := tx.QueryRow(
+		`SELECT id, last_message_at FROM threads WHERE phone = ? ORDER BY id DESC LIMIT 1`,
+		m.Phone,
+	).Scan(&id, &last)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	if err == sql.ErrNoRows || m.CreatedAt.Sub(last) > db.threadTimeout {
+		res, err := tx.Exec(
+			`INSERT INTO threads (phone, user_id, started_at, last_message_at) VALUES (?, ?, ?, ?)`,
+			m.Phone, m.UserID, m.CreatedAt, m.CreatedAt,
+		)
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	}
+
+	if m.CreatedAt.After(last) {
+		last = m.CreatedAt
+	}
+	_, err = tx.Exec(
+		`UPDATE threads SET last_message_at = ?,
+		 user_id = CASE WHEN ? != '' THEN ? ELSE user_id END
+		 WHERE id = ?`,
+		last, m.UserID, m.UserID, id,
+	)
+	return id, err
+}
+
+const threadColumns = `t.id, t.phone, t.user_id, t.started_at, t.last_message_at,
+	(SELECT COUNT(*) FROM messages m WHERE m.thread_id = t.id)`
+
+// Threads returns the most recently active threads, newest first. An empty
+// phone lists threads for every sender.
+func (db *DB) Threads(phone string, limit int) ([]*Thread, error) {
+	rows, err := db.conn.Query(
+		`SELECT `+threadColumns+` FROM threads t
+		 WHERE ? = '' OR t.phone = ?
+		 ORDER BY t.last_message_at DESC, t.id DESC LIMIT ?`,
+		phone, phone, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var threads []*Thread
+	for rows.Next() {
+		t := &Thread{}
+		if err := rows.Scan(&t.ID, &t.Phone, &t.UserID, &t.StartedAt, &t.LastMessageAt, &t.MessageCount); err != nil {
+			return nil, err
+		}
+		threads = append(threads, t)
+	}
+	return threads, rows.Err()
+}
+
+// Thread returns a thread by ID, or nil if it doesn't exist.
+func (db *DB) Thread(id int64) (*Thread, error) {
+	t := &Thread{}
+	err := db.conn.QueryRow(
+		`SELECT `+threadColumns+` FROM threads t WHERE t.id = ?`, id,
+	).Scan(&t.ID, &t.Phone, &t.UserID, &t.StartedAt, &t.LastMessageAt, &t.MessageCount)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ThreadMessages returns the last limit messages of a thread, oldest first.
+func (db *DB) ThreadMessages(threadID int64, limit int) ([]*Message, error) {
+	rows, err := db.conn.Query(
+		`SELECT * FROM (
+			SELECT `+messageColumns+` FROM messages
+			WHERE thread_id = ? ORDER BY id DESC LIMIT ?
+		 ) ORDER BY id`,
+		threadID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanMessages(rows)
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordMessageThreads(t *testing.T) {
+	db := testDB(t)
+	db.SetThreadTimeout(30 * time.Minute)
+	start := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	record := func(phone, direction, body string, after time.Duration) *Message {
+		t.Helper()
+		m := &Message{Phone: phone, Direction: direction, Body: body, CreatedAt: start.Add(after)}
+		if err := db.RecordMessage(m); err != nil {
+			t.Fatalf("record %q: %v", body, err)
+		}
+		return m
+	}
+
+	a1 := record("15555555555", DirectionInbound, "hi", 0)
+	a2 := record("15555555555", DirectionOutbound, "hello!", time.Second)
+	b1 := record("15550000000", DirectionInbound, "other sender", 2*time.Minute)
+	a3 := record("15555555555", DirectionInbound, "still there?", 29*time.Minute)
+	a4 := record("15555555555", DirectionInbound, "next day", 24*time.Hour)
+
+	if a1.ThreadID == 0 || a2.ThreadID != a1.ThreadID || a3.ThreadID != a1.ThreadID {
+		t.Errorf("expected one thread within the timeout, got %d, %d, %d", a1.ThreadID, a2.ThreadID, a3.ThreadID)
+	}
+	if b1.ThreadID == a1.ThreadID {
+		t.Error("senders must not share threads")
+	}
+	if a4.ThreadID == a1.ThreadID {
+		t.Error("expected a new thread after the timeout")
+	}
+
+	threads, err := db.Threads("15555555555", 10)
+	if err != nil {
+		t.Fatalf("threads: %v", err)
+	}
+	if len(threads) != 2 || threads[0].ID != a4.ThreadID || threads[1].MessageCount != 3 {
+		t.Fatalf("unexpected threads: %+v", threads)
+	}
+	if !threads[1].LastMessageAt.Equal(a3.CreatedAt) || !threads[1].StartedAt.Equal(a1.CreatedAt) {
+		t.Errorf("thread times = %v..%v", threads[1].StartedAt, threads[1].LastMessageAt)
+	}
+	if all, _ := db.Threads("", 10); len(all) != 3 {
+		t.Errorf("expected 3 threads across senders, got %d", len(all))
+	}
+
+	msgs, err := db.ThreadMessages(a1.ThreadID, 2)
+	if err != nil {
+		t.Fatalf("thread messages: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].Body != "hello!" || msgs[1].Body != "still there?" {
+		t.Errorf("expected the last 2 turns oldest first, got %+v", msgs)
+	}
+
+	if th, err := db.Thread(a1.ThreadID); err != nil || th == nil || th.Phone != "15555555555" {
+		t.Errorf("thread = %+v, %v", th, err)
+	}
+	if th, err := db.Thread(999); err != nil || th != nil {
+		t.Errorf("missing thread = %+v, %v; want nil, nil", th, err)
+	}
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// --- Voice PINs ---
+
+// SetVoicePIN stores the bcrypt hash of phone's voice PIN, replacing any
+// previous one.
+func (db *DB) SetVoicePIN(phone, hash string, at time.Time) error {
+	_, err := db.conn.Exec(
+		`INSERT INTO voice_pins (phone, pin_hash, updated_at) VALUES (?, ?, ?)
+		 ON CONFLICT(phone) DO UPDATE SET pin_hash = excluded.pin_hash, updated_at = excluded.updated_at`,
+		phone, hash, at,
+	)
+	return err
+}
+
+// VoicePIN returns the bcrypt hash of phone's voice PIN, or "" if it has
+// none.
+func (db *DB) VoicePIN(phone string) (string, error) {
+	var hash string
+	err := db.conn.QueryRow(`SELECT pin_hash FROM voice_pins WHERE phone = ?`, phone).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return hash, err
+}
+
+// VerifyCall records that the caller on callSID entered phone's PIN.
+// Calls verified more than a day before at are forgotten.
+func (db *DB) VerifyCall(callSID, phone string, at time.Time) error {
+	if _, err := db.conn.Exec(`DELETE FROM voice_calls WHERE verified_at < ?`, at.Add(-24*time.Hour)); err != nil {
+		return err
+	}
+	_, err := db.conn.Exec(
+		`INSERT INTO voice_calls (call_sid, phone, verified_at) VALUES (?, ?, ?)
+		 ON CONFLICT(call_sid) DO UPDATE SET phone = excluded.phone, verified_at = excluded.verified_at`,
+		callSID, phone, at,
+	)
+	return err
+}
+
+// CallVerified reports whether the caller on callSID entered phone's PIN
+// at or after since.
+func (db *DB) CallVerified(callSID, phone string, since time.Time) (bool, error) {
+	var n int
+	err := db.conn.QueryRow(
+		`SELECT COUNT(*) FROM voice_calls WHERE call_sid = ? AND phone = ? AND verified_at >= ?`,
+		callSID, phone, since,
+	).Scan(&n)
+	return n > 0, err
+}
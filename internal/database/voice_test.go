@@ -0,0 +1,48 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVoicePIN(t *testing.T) {
+	db := testDB(t)
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	if hash, err := db.VoicePIN("15555555555"); err != nil || hash != "" {
+		t.Fatalf("no PIN: %q, %v", hash, err)
+	}
+	if err := db.SetVoicePIN("15555555555", "hash-1", now); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := db.SetVoicePIN("15555555555", "hash-2", now); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if hash, _ := db.VoicePIN("15555555555"); hash != "hash-2" {
+		t.Errorf("VoicePIN = %q, want hash-2", hash)
+	}
+}
+
+func TestCallVerified(t *testing.T) {
+	db := testDB(t)
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	if err := db.VerifyCall("CA1", "15555555555", now); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	tests := []struct {
+		sid, phone string
+		since      time.Time
+		want       bool
+	}{
+		{"CA1", "15555555555", now.Add(-time.Minute), true},
+		{"CA1", "15550000000", now.Add(-time.Minute), false}, // other number
+		{"CA2", "15555555555", now.Add(-time.Minute), false}, // other call
+		{"CA1", "15555555555", now.Add(time.Minute), false},  // expired
+	}
+	for _, tt := range tests {
+		if got, err := db.CallVerified(tt.sid, tt.phone, tt.since); err != nil || got != tt.want {
+			t.Errorf("CallVerified(%s, %s, %v) = %v, %v; want %v", tt.sid, tt.phone, tt.since, got, err, tt.want)
+		}
+	}
+}
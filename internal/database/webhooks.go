@@ -0,0 +1,191 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/webhooks"
+)
+
+// --- Subscriptions ---
+
+// CreateSubscription stores a new webhook subscription and sets its ID.
+func (db *DB) CreateSubscription(s *webhooks.Subscription) error {
+	res, err := db.conn.Exec(
+		`INSERT INTO webhook_subscriptions (url, secret, events, enabled, created_at) VALUES (?, ?, ?, ?, ?)`,
+		s.URL, s.Secret, strings.Join(s.Events, ","), s.Enabled, s.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	s.ID, err = res.LastInsertId()
+	return err
+}
+
+// DeleteSubscription removes a subscription and its deliveries and reports
+// whether it existed.
+func (db *DB) DeleteSubscription(id int64) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM webhook_subscriptions WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+const subscriptionColumns = `id, url, secret, events, enabled, created_at`
+
+// Subscription returns a subscription by ID, or nil if it doesn't exist.
+// It implements webhooks.Store.
+func (db *DB) Subscription(id int64) (*webhooks.Subscription, error) {
+	rows, err := db.conn.Query(`SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	list, err := scanSubscriptions(rows)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[0], nil
+}
+
+// Subscriptions returns every subscription, oldest first.
+func (db *DB) Subscriptions() ([]*webhooks.Subscription, error) {
+	rows, err := db.conn.Query(`SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	return scanSubscriptions(rows)
+}
+
+// SubscriptionsFor returns the enabled subscriptions that want event type
+// t. It implements webhooks.Store.
+func (db *DB) SubscriptionsFor(t string) ([]*webhooks.Subscription, error) {
+	rows, err := db.conn.Query(
+		`SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions WHERE enabled = 1 ORDER BY id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	all, err := scanSubscriptions(rows)
+	if err != nil {
+		return nil, err
+	}
+	var list []*webhooks.Subscription
+	for _, s := range all {
+		if s.Wants(t) {
+			list = append(list, s)
+		}
+	}
+	return list, nil
+}
+
+func scanSubscriptions(rows *sql.Rows) ([]*webhooks.Subscription, error) {
+	defer rows.Close()
+
+	var list []*webhooks.Subscription
+	for rows.Next() {
+		s := &webhooks.Subscription{}
+		var events string
+		if err := rows.Scan(&s.ID, &s.URL, &s.Secret, &events, &s.Enabled, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.Events = strings.Split(events, ",")
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// --- Delivery queue ---
+
+// EnqueueDelivery adds a delivery to the queue and sets its ID. It
+// implements webhooks.Store.
+func (db *DB) EnqueueDelivery(d *webhooks.Delivery) error {
+	res, err := db.conn.Exec(
+		`INSERT INTO webhook_deliveries
+			(subscription_id, event, payload, status, attempts, next_attempt_at, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		d.SubscriptionID, d.Event, string(d.Payload), d.Status, d.Attempts, d.NextAttemptAt, d.CreatedAt, d.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	d.ID, err = res.LastInsertId()
+	return err
+}
+
+// UpdateDelivery stores the outcome of a delivery attempt. It implements
+// webhooks.Store.
+func (db *DB) UpdateDelivery(d *webhooks.Delivery) error {
+	_, err := db.conn.Exec(
+		`UPDATE webhook_deliveries SET status = ?, attempts = ?, response_code = ?, last_error = ?,
+			next_attempt_at = ?, updated_at = ?
+		 WHERE id = ?`,
+		d.Status, d.Attempts, d.ResponseCode, d.LastError, d.NextAttemptAt, d.UpdatedAt, d.ID,
+	)
+	return err
+}
+
+// RetryDelivery puts a delivery back in the queue for an immediate attempt
+// with a fresh set of retries and reports whether it exists.
+func (db *DB) RetryDelivery(id int64, now time.Time) (bool, error) {
+	res, err := db.conn.Exec(
+		`UPDATE webhook_deliveries SET status = ?, attempts = 0, next_attempt_at = ?, updated_at = ?
+		 WHERE id = ?`,
+		webhooks.StatusPending, now, now, id,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+const deliveryColumns = `id, subscription_id, event, payload, status, attempts, response_code, last_error,
+	next_attempt_at, created_at, updated_at`
+
+// DueDeliveries returns up to limit pending deliveries whose next attempt
+// is at or before now, oldest first. It implements webhooks.Store.
+func (db *DB) DueDeliveries(now time.Time, limit int) ([]*webhooks.Delivery, error) {
+	rows, err := db.conn.Query(
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		 WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT ?`,
+		webhooks.StatusPending, now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows)
+}
+
+// Deliveries returns a subscription's most recent deliveries, newest
+// first, optionally only those with the given status.
+func (db *DB) Deliveries(subscriptionID int64, status string, limit int) ([]*webhooks.Delivery, error) {
+	rows, err := db.conn.Query(
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		 WHERE subscription_id = ? AND (? = '' OR status = ?) ORDER BY id DESC LIMIT ?`,
+		subscriptionID, status, status, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows)
+}
+
+func scanDeliveries(rows *sql.Rows) ([]*webhooks.Delivery, error) {
+	defer rows.Close()
+
+	var list []*webhooks.Delivery
+	for rows.Next() {
+		d := &webhooks.Delivery{}
+		var payload string
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.Event, &payload, &d.Status, &d.Attempts,
+			&d.ResponseCode, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		d.Payload = []byte(payload)
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/webhooks"
+)
+
+func TestWebhookQueue(t *testing.T) {
+	db := testDB(t)
+	now := time.Now().UTC()
+
+	sub := &webhooks.Subscription{URL: "https://example.com/hook", Secret: "whsec_a",
+		Events: []string{webhooks.EventSMSReceived, webhooks.EventCalCreated}, Enabled: true, CreatedAt: now}
+	if err := db.CreateSubscription(sub); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	off := &webhooks.Subscription{URL: "https://example.com/off", Secret: "whsec_b",
+		Events: []string{webhooks.EventSMSReceived}, CreatedAt: now}
+	if err := db.CreateSubscription(off); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	for _, tt := range []struct {
+		event string
+		want  int
+	}{
+		{webhooks.EventSMSReceived, 1},
+		{webhooks.EventCalCreated, 1},
+		{webhooks.EventPortalSignup, 0},
+	} {
+		subs, err := db.SubscriptionsFor(tt.event)
+		if err != nil || len(subs) != tt.want {
+			t.Errorf("SubscriptionsFor(%s) = %d subscriptions, %v; want %d", tt.event, len(subs), err, tt.want)
+		}
+	}
+	got, err := db.Subscription(sub.ID)
+	if err != nil || got == nil || got.Secret != "whsec_a" || len(got.Events) != 2 {
+		t.Fatalf("Subscription = %+v, %v", got, err)
+	}
+
+	later := &webhooks.Delivery{SubscriptionID: sub.ID, Event: webhooks.EventSMSReceived, Payload: []byte(`{"n":2}`),
+		Status: webhooks.StatusPending, NextAttemptAt: now.Add(time.Minute), CreatedAt: now, UpdatedAt: now}
+	due := &webhooks.Delivery{SubscriptionID: sub.ID, Event: webhooks.EventSMSReceived, Payload: []byte(`{"n":1}`),
+		Status: webhooks.StatusPending, NextAttemptAt: now, CreatedAt: now, UpdatedAt: now}
+	for _, d := range []*webhooks.Delivery{later, due} {
+		if err := db.EnqueueDelivery(d); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+
+	list, err := db.DueDeliveries(now.Add(time.Second), 10)
+	if err != nil || len(list) != 1 || list[0].ID != due.ID || string(list[0].Payload) != `{"n":1}` {
+		t.Fatalf("DueDeliveries = %+v, %v", list, err)
+	}
+
+	due.Status, due.Attempts, due.ResponseCode, due.LastError = webhooks.StatusFailed, 8, 503, "receiver returned status 503"
+	if err := db.UpdateDelivery(due); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	failed, err := db.Deliveries(sub.ID, webhooks.StatusFailed, 10)
+	if err != nil || len(failed) != 1 || failed[0].ResponseCode != 503 || failed[0].Attempts != 8 {
+		t.Fatalf("failed deliveries = %+v, %v", failed, err)
+	}
+
+	if ok, err := db.RetryDelivery(due.ID, now); err != nil || !ok {
+		t.Fatalf("RetryDelivery = %v, %v", ok, err)
+	}
+	list, _ = db.DueDeliveries(now.Add(2*time.Minute), 10)
+	if len(list) != 2 || list[0].ID != due.ID || list[0].Attempts != 0 {
+		t.Errorf("after retry, due = %+v", list)
+	}
+
+	if ok, err := db.DeleteSubscription(sub.ID); err != nil || !ok {
+		t.Fatalf("delete: %v, %v", ok, err)
+	}
+	if all, _ := db.Deliveries(sub.ID, "", 10); len(all) != 0 {
+		t.Errorf("deliveries survived their subscription: %+v", all)
+	}
+}
@@ -0,0 +1,84 @@
+// Package escalation decides which inbound texts are too sensitive for the
+// assistant to answer, so they can be passed to a person instead.
+package escalation
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// Classifier decides whether a message needs a human. reason is a short
+// description for whoever picks it up, e.g. `keyword "lawyer"`.
+type Classifier interface {
+	Classify(ctx context.Context, text string) (reason string, escalate bool, err error)
+}
+
+// ClassifierFunc adapts a function to Classifier.
+type ClassifierFunc func(ctx context.Context, text string) (string, bool, error)
+
+// Classify calls f.
+func (f ClassifierFunc) Classify(ctx context.Context, text string) (string, bool, error) {
+	return f(ctx, text)
+}
+
+// DefaultKeywords are the phrases the default classifier escalates on:
+// crises, and medical, legal and financial questions the assistant
+// shouldn't answer alone.
+var DefaultKeywords = []string{
+	"suicide", "suicidal", "kill myself", "self harm", "hurt myself", "overdose",
+	"abuse", "abused", "assault", "domestic violence",
+	"diagnosis", "prescription", "pregnant", "chest pain",
+	"lawyer", "lawsuit", "arrested", "custody", "divorce", "eviction",
+	"bankrupt", "bankruptcy", "debt collector",
+}
+
+// Keywords escalates messages that contain any of a list of words or
+// phrases. Matching ignores case and punctuation and only matches whole
+// words, so "abuse" doesn't match "abusers" but does match "Abuse!".
+type Keywords struct {
+	phrases []string
+}
+
+// NewKeywords returns a classifier for phrases. Empty phrases are ignored.
+func NewKeywords(phrases []string) *Keywords {
+	k := &Keywords{}
+	for _, p := range phrases {
+		if p = normalize(p); p != "" {
+			k.phrases = append(k.phrases, p)
+		}
+	}
+	return k
+}
+
+// Classify reports the first phrase found in text.
+func (k *Keywords) Classify(ctx context.Context, text string) (string, bool, error) {
+	padded := " " + normalize(text) + " "
+	for _, p := range k.phrases {
+		if strings.Contains(padded, " "+p+" ") {
+			return `keyword "` + p + `"`, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// normalize lowercases s and reduces it to words separated by single
+// spaces.
+func normalize(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	return strings.Join(words, " ")
+}
+
+// ParseKeywords parses a comma-separated phrase list, as in the
+// ESCALATION_KEYWORDS setting.
+func ParseKeywords(list string) []string {
+	var phrases []string
+	for _, p := range strings.Split(list, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			phrases = append(phrases, p)
+		}
+	}
+	return phrases
+}
@@ -0,0 +1,38 @@
+package escalation
+
+import (
+	"context"
+	"testing"
+)
+
+func TestKeywords(t *testing.T) {
+	k := NewKeywords(append(DefaultKeywords, "  ", "Side Effects"))
+	tests := []struct {
+		text   string
+		reason string
+	}{
+		{"what's the weather tomorrow", ""},
+		{"I think I need a LAWYER.", `keyword "lawyer"`},
+		{"sometimes I want to kill   myself", `keyword "kill myself"`},
+		{"any side-effects of ibuprofen?", `keyword "side effects"`},
+		{"my kids love the lawyered-up cartoon", ""},
+		{"the abusers were caught", ""},
+		{"Abuse!", `keyword "abuse"`},
+	}
+	for _, tt := range tests {
+		reason, escalate, err := k.Classify(context.Background(), tt.text)
+		if err != nil {
+			t.Fatalf("Classify(%q): %v", tt.text, err)
+		}
+		if reason != tt.reason || escalate != (tt.reason != "") {
+			t.Errorf("Classify(%q) = %q, %v; want %q", tt.text, reason, escalate, tt.reason)
+		}
+	}
+}
+
+func TestParseKeywords(t *testing.T) {
+	got := ParseKeywords(" lawyer, ,kill myself,")
+	if len(got) != 2 || got[0] != "lawyer" || got[1] != "kill myself" {
+		t.Errorf("ParseKeywords = %q", got)
+	}
+}
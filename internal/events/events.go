@@ -0,0 +1,112 @@
+// Package events names the things that happen across nexus and reports
+// those that happen in other services (portal signups, giveaway claims,
+// calendar events, ...) to the SMS server's POST /events, where they
+// trigger automation rules and webhook deliveries.
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Event types. The rules engine and webhook subscriptions use the same
+// names, each for the subset it supports.
+const (
+	SMSReceived     = "sms.received"           // data: body, command
+	CalStarting     = "cal.event.starting"     // data: summary, start, location, event_id
+	CalCreated      = "cal.event.created"      // data: event_id, feed_id, summary, start, end, location
+	PortalSignup    = "portal.user.signup"     // data: user_id, username, name
+	GiveawayClaimed = "giveaway.claim.created" // data: claim_id, item_id, item_title, name, email
+)
+
+// aliases maps earlier spellings of event types to their current names.
+var aliases = map[string]string{
+	"cal.event_starting":     CalStarting,
+	"cal.event_created":      CalCreated,
+	"portal.signup":          PortalSignup,
+	"giveaway.claim_created": GiveawayClaimed,
+}
+
+// Canonical returns the current name of event type t, which may be an
+// earlier spelling still sent by older services or stored in rules and
+// subscriptions. Other names are returned unchanged.
+func Canonical(t string) string {
+	if c, ok := aliases[t]; ok {
+		return c
+	}
+	return t
+}
+
+// Event is the body of POST /events; it mirrors rules.Event.
+type Event struct {
+	Type  string            `json:"type"`
+	Phone string            `json:"phone,omitempty"`
+	Data  map[string]string `json:"data,omitempty"`
+	At    time.Time         `json:"at"`
+}
+
+// Publisher posts events to a nexus SMS server.
+type Publisher struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewPublisher creates a Publisher for the SMS server at baseURL,
+// authenticating with the shared INTERNAL_API_TOKEN.
+func NewPublisher(baseURL, token string) *Publisher {
+	return &Publisher{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Publish posts ev and waits for the server to accept it. A zero At is set
+// to the current time.
+func (p *Publisher) Publish(ctx context.Context, ev *Event) error {
+	if ev.At.IsZero() {
+		ev.At = time.Now().UTC()
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/events", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.token)
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("publish %s: %w", ev.Type, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("publish %s: status %d", ev.Type, resp.StatusCode)
+	}
+	return nil
+}
+
+// Go publishes ev in the background and logs failures, so a slow or
+// unreachable SMS server never holds up the request the event came from.
+func (p *Publisher) Go(ev *Event) {
+	if ev.At.IsZero() {
+		ev.At = time.Now().UTC()
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := p.Publish(ctx, ev); err != nil {
+			log.Printf("error publishing event: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,68 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPublish(t *testing.T) {
+	got := make(chan Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/events" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var ev Event
+		json.NewDecoder(r.Body).Decode(&ev)
+		got <- ev
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	p := NewPublisher(srv.URL+"/", "secret")
+	err := p.Publish(context.Background(), &Event{Type: "portal.user.signup", Phone: "15555555555", Data: map[string]string{"username": "tex"}})
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	ev := <-got
+	if ev.Type != "portal.user.signup" || ev.Phone != "15555555555" || ev.Data["username"] != "tex" || ev.At.IsZero() {
+		t.Errorf("received %+v", ev)
+	}
+
+	if err := NewPublisher(srv.URL, "wrong").Publish(context.Background(), &Event{Type: "portal.user.signup"}); err == nil {
+		t.Error("expected an error for a rejected event")
+	}
+
+	p.Go(&Event{Type: "giveaway.claim.created"})
+	select {
+	case ev := <-got:
+		if ev.Type != "giveaway.claim.created" {
+			t.Errorf("background event = %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("background event never arrived")
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	for in, want := range map[string]string{
+		"portal.signup":          PortalSignup,
+		"cal.event_created":      CalCreated,
+		"cal.event_starting":     CalStarting,
+		"giveaway.claim_created": GiveawayClaimed,
+		GiveawayClaimed:          GiveawayClaimed,
+		"sms.received":           SMSReceived,
+		"bogus":                  "bogus",
+	} {
+		if got := Canonical(in); got != want {
+			t.Errorf("Canonical(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
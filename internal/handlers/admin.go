@@ -0,0 +1,171 @@
+// nexus - Personal AI assistant system
+// Copyright (C) 2025  nexus contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+// Admin listing limits.
+const (
+	defaultAdminLimit = 50
+	maxAdminLimit     = 500
+)
+
+// AdminThreads lists conversation threads, most recently active first.
+// Query parameters: phone (any format) filters by sender, limit caps the
+// number of threads.
+// GET /admin/threads
+func (h *Handler) AdminThreads(w http.ResponseWriter, r *http.Request) {
+	phone := r.URL.Query().Get("phone")
+	if phone != "" {
+		phone = identity.NormalizePhone(phone)
+	}
+
+	threads, err := h.db.Threads(phone, queryLimit(r))
+	if err != nil {
+		log.Printf("error listing threads: %v", err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if threads == nil {
+		threads = []*database.Thread{}
+	}
+	writeJSON(w, http.StatusOK, threads)
+}
+
+// AdminThread returns one thread with its latest messages, oldest first.
+// GET /admin/threads/{id}
+func (h *Handler) AdminThread(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "invalid thread id", http.StatusBadRequest)
+		return
+	}
+
+	thread, err := h.db.Thread(id)
+	if err != nil {
+		log.Printf("error loading thread %d: %v", id, err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if thread == nil {
+		jsonError(w, "thread not found", http.StatusNotFound)
+		return
+	}
+
+	msgs, err := h.db.ThreadMessages(id, queryLimit(r))
+	if err != nil {
+		log.Printf("error loading messages for thread %d: %v", id, err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if msgs == nil {
+		msgs = []*database.Message{}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		*database.Thread
+		Messages []*database.Message `json:"messages"`
+	}{thread, msgs})
+}
+
+// AdminBlocklist lists blocked numbers, most recently blocked first.
+// GET /admin/blocklist
+func (h *Handler) AdminBlocklist(w http.ResponseWriter, r *http.Request) {
+	blocks, err := h.db.Blocklist(queryLimit(r))
+	if err != nil {
+		log.Printf("error listing blocklist: %v", err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if blocks == nil {
+		blocks = []*database.Block{}
+	}
+	writeJSON(w, http.StatusOK, blocks)
+}
+
+// AdminBlock adds a number to the blocklist. Body: {"phone", "reason"}.
+// POST /admin/blocklist
+func (h *Handler) AdminBlock(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Phone  string `json:"phone"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	phone := identity.NormalizePhone(req.Phone)
+	if phone == "" {
+		jsonError(w, "phone is required", http.StatusBadRequest)
+		return
+	}
+
+	b := &database.Block{Phone: phone, Reason: req.Reason, CreatedAt: time.Now().UTC()}
+	if err := h.db.BlockPhone(b); err != nil {
+		log.Printf("error blocking %s: %v", phone, err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("blocked %s: %s", phone, req.Reason)
+	writeJSON(w, http.StatusCreated, b)
+}
+
+// AdminUnblock removes a number from the blocklist.
+// DELETE /admin/blocklist/{phone}
+func (h *Handler) AdminUnblock(w http.ResponseWriter, r *http.Request) {
+	phone := identity.NormalizePhone(r.PathValue("phone"))
+	ok, err := h.db.UnblockPhone(phone)
+	if err != nil {
+		log.Printf("error unblocking %s: %v", phone, err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		jsonError(w, "number is not blocked", http.StatusNotFound)
+		return
+	}
+	log.Printf("unblocked %s", phone)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// queryLimit reads the "limit" query parameter, clamped to a sane range.
+func queryLimit(r *http.Request) int {
+	n, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || n <= 0 {
+		return defaultAdminLimit
+	}
+	return min(n, maxAdminLimit)
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("error encoding response: %v", err)
+	}
+}
+
+// jsonError writes a JSON error response.
+func jsonError(w http.ResponseWriter, msg string, status int) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+func adminMux(h *Handler, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/threads", h.AdminThreads)
+	mux.HandleFunc("GET /admin/threads/{id}", h.AdminThread)
+	mux.HandleFunc("GET /admin/delivery", h.AdminDelivery)
+	mux.HandleFunc("GET /admin/blocklist", h.AdminBlocklist)
+	mux.HandleFunc("POST /admin/blocklist", h.AdminBlock)
+	mux.HandleFunc("DELETE /admin/blocklist/{phone}", h.AdminUnblock)
+	return AdminTokenMiddleware(token)(mux)
+}
+
+func adminGet(t *testing.T, h http.Handler, path, token string, out interface{}) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if out != nil && w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+	}
+	return w.Code
+}
+
+func TestAdminThreads(t *testing.T) {
+	router := sms.NewRouter()
+	router.Fallback(func(ctx context.Context, msg *sms.Message) (string, error) {
+		return "ok", nil
+	})
+	h := New(testDB(t), router, Options{})
+	postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"first"}})
+	postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"second"}})
+	postSMS(t, h.SMS, url.Values{"From": {"+15550000000"}, "Body": {"other"}})
+
+	mux := adminMux(h, "admin-secret")
+
+	var threads []struct {
+		ID           int64  `json:"id"`
+		Phone        string `json:"phone"`
+		MessageCount int    `json:"message_count"`
+	}
+	if code := adminGet(t, mux, "/admin/threads?phone=(555)555-5555", "admin-secret", &threads); code != http.StatusOK {
+		t.Fatalf("list threads: status %d", code)
+	}
+	if len(threads) != 1 || threads[0].Phone != "15555555555" || threads[0].MessageCount != 4 {
+		t.Fatalf("unexpected threads: %+v", threads)
+	}
+
+	var thread struct {
+		ID       int64 `json:"id"`
+		Messages []struct {
+			Direction string `json:"direction"`
+			Body      string `json:"body"`
+		} `json:"messages"`
+	}
+	path := "/admin/threads/" + strconv.FormatInt(threads[0].ID, 10)
+	if code := adminGet(t, mux, path, "admin-secret", &thread); code != http.StatusOK {
+		t.Fatalf("get thread: status %d", code)
+	}
+	if len(thread.Messages) != 4 || thread.Messages[0].Body != "first" || thread.Messages[1].Direction != "outbound" {
+		t.Errorf("unexpected thread: %+v", thread)
+	}
+
+	for _, tt := range []struct {
+		path, token string
+		want        int
+	}{
+		{"/admin/threads", "", http.StatusUnauthorized},
+		{"/admin/threads", "wrong", http.StatusUnauthorized},
+		{"/admin/threads/999", "admin-secret", http.StatusNotFound},
+		{"/admin/threads/abc", "admin-secret", http.StatusBadRequest},
+	} {
+		if code := adminGet(t, mux, tt.path, tt.token, nil); code != tt.want {
+			t.Errorf("GET %s (token %q): status %d, want %d", tt.path, tt.token, code, tt.want)
+		}
+	}
+
+	if code := adminGet(t, adminMux(h, ""), "/admin/threads", "", nil); code != http.StatusNotFound {
+		t.Errorf("admin API without a token configured: status %d, want 404", code)
+	}
+}
+
+func adminDo(h http.Handler, method, path, body string) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAdminBlocklist(t *testing.T) {
+	router := sms.NewRouter()
+	router.Fallback(func(ctx context.Context, msg *sms.Message) (string, error) {
+		return "ok", nil
+	})
+	h := New(testDB(t), router, Options{})
+	mux := adminMux(h, "admin-secret")
+
+	if code := adminDo(mux, http.MethodPost, "/admin/blocklist", `{"phone":"(555) 555-5555","reason":"spam"}`); code != http.StatusCreated {
+		t.Fatalf("block: status %d", code)
+	}
+	if code := adminDo(mux, http.MethodPost, "/admin/blocklist", `{"reason":"no phone"}`); code != http.StatusBadRequest {
+		t.Errorf("block without phone: status %d, want 400", code)
+	}
+
+	var blocks []struct {
+		Phone  string `json:"phone"`
+		Reason string `json:"reason"`
+	}
+	if code := adminGet(t, mux, "/admin/blocklist", "admin-secret", &blocks); code != http.StatusOK {
+		t.Fatalf("list: status %d", code)
+	}
+	if len(blocks) != 1 || blocks[0].Phone != "15555555555" || blocks[0].Reason != "spam" {
+		t.Fatalf("unexpected blocklist: %+v", blocks)
+	}
+
+	w := postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"hello"}})
+	if strings.Contains(w.Body.String(), "<Message>") {
+		t.Errorf("blocked number got a reply:\n%s", w.Body.String())
+	}
+
+	if code := adminDo(mux, http.MethodDelete, "/admin/blocklist/+15555555555", ""); code != http.StatusNoContent {
+		t.Errorf("unblock: status %d", code)
+	}
+	if code := adminDo(mux, http.MethodDelete, "/admin/blocklist/+15555555555", ""); code != http.StatusNotFound {
+		t.Errorf("second unblock: status %d, want 404", code)
+	}
+
+	w = postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"hello"}})
+	if !strings.Contains(w.Body.String(), "<Message>ok</Message>") {
+		t.Errorf("unblocked number got no reply:\n%s", w.Body.String())
+	}
+}
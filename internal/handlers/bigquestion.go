@@ -0,0 +1,149 @@
+// nexus - Personal AI assistant system
+// Copyright (C) 2025  nexus contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/database"
+)
+
+// AdminBigQuestions lists the Big Question bank, oldest first.
+// GET /admin/bigq/questions
+func (h *Handler) AdminBigQuestions(w http.ResponseWriter, r *http.Request) {
+	list, err := h.db.BigQuestions()
+	if err != nil {
+		log.Printf("error listing big questions: %v", err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []*database.BigQuestion{}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// AdminCreateBigQuestion adds a question to the end of the bank.
+// Body: {"text": "..."}.
+// POST /admin/bigq/questions
+func (h *Handler) AdminCreateBigQuestion(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	if req.Text == "" {
+		jsonError(w, "text is required", http.StatusBadRequest)
+		return
+	}
+	q := &database.BigQuestion{Text: req.Text, Active: true, CreatedAt: time.Now().UTC()}
+	if err := h.db.CreateBigQuestion(q); err != nil {
+		log.Printf("error creating big question: %v", err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, q)
+}
+
+// AdminRetireBigQuestion stops a question from being sent. Its answers
+// are kept.
+// DELETE /admin/bigq/questions/{id}
+func (h *Handler) AdminRetireBigQuestion(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "invalid question id")
+	if !ok {
+		return
+	}
+	found, err := h.db.SetBigQuestionActive(id, false)
+	if err != nil {
+		log.Printf("error retiring big question %d: %v", id, err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		jsonError(w, "question not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminBigQAnswers returns the most recent answers, newest first.
+// ?question_id= and ?phone= filter them.
+// GET /admin/bigq/answers
+func (h *Handler) AdminBigQAnswers(w http.ResponseWriter, r *http.Request) {
+	var questionID int64
+	if s := r.URL.Query().Get("question_id"); s != "" {
+		var err error
+		if questionID, err = strconv.ParseInt(s, 10, 64); err != nil {
+			jsonError(w, "invalid question_id", http.StatusBadRequest)
+			return
+		}
+	}
+	list, err := h.db.BigQAnswers(questionID, r.URL.Query().Get("phone"), queryLimit(r))
+	if err != nil {
+		log.Printf("error listing big question answers: %v", err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []*database.BigQAnswer{}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// AdminBigQSubscribers lists every subscriber with their send time and
+// whether, and since when, they consent to sharing answers.
+// GET /admin/bigq/subscribers
+func (h *Handler) AdminBigQSubscribers(w http.ResponseWriter, r *http.Request) {
+	list, err := h.db.BigQSubscribers(false)
+	if err != nil {
+		log.Printf("error listing big question subscribers: %v", err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []*database.BigQSubscriber{}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// AdminBigQExport streams every answer shared as training data as
+// newline-delimited JSON. Phone numbers are replaced by contributor
+// pseudonyms, and answers from subscribers who have since withdrawn
+// consent are left out.
+// GET /admin/bigq/export
+func (h *Handler) AdminBigQExport(w http.ResponseWriter, r *http.Request) {
+	list, err := h.db.Contributions()
+	if err != nil {
+		log.Printf("error exporting big question contributions: %v", err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="bigq-contributions.ndjson"`)
+	enc := json.NewEncoder(w)
+	for _, c := range list {
+		if err := enc.Encode(c); err != nil {
+			log.Printf("error writing big question export: %v", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+func TestAdminBigQuestion(t *testing.T) {
+	db := testDB(t)
+	h := New(db, sms.NewRouter(), Options{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/bigq/questions", h.AdminBigQuestions)
+	mux.HandleFunc("POST /admin/bigq/questions", h.AdminCreateBigQuestion)
+	mux.HandleFunc("DELETE /admin/bigq/questions/{id}", h.AdminRetireBigQuestion)
+	mux.HandleFunc("GET /admin/bigq/answers", h.AdminBigQAnswers)
+	mux.HandleFunc("GET /admin/bigq/subscribers", h.AdminBigQSubscribers)
+	mux.HandleFunc("GET /admin/bigq/export", h.AdminBigQExport)
+
+	if code := doJSON(t, mux, http.MethodPost, "/admin/bigq/questions", "", `{"text":"  "}`, nil); code != http.StatusBadRequest {
+		t.Errorf("create empty question: status %d, want 400", code)
+	}
+	var q database.BigQuestion
+	if code := doJSON(t, mux, http.MethodPost, "/admin/bigq/questions", "", `{"text":"What made you laugh today?"}`, &q); code != http.StatusCreated || !q.Active {
+		t.Fatalf("create: status %d, %+v", code, q)
+	}
+
+	// Two subscribers answer; only one shares.
+	now := time.Now().UTC()
+	for i, s := range []*database.BigQSubscriber{
+		{Phone: "15555555555", Active: true, Timezone: "UTC", Consent: true, ConsentAt: &now, ContributorID: "c_alice", CreatedAt: now},
+		{Phone: "15550000000", Active: true, Timezone: "UTC", ContributorID: "c_bob", CreatedAt: now},
+	} {
+		if err := db.SaveBigQSubscriber(s); err != nil {
+			t.Fatalf("save subscriber: %v", err)
+		}
+		p := &database.BigQPrompt{Phone: s.Phone, QuestionID: q.ID, LocalDate: "2026-03-04", SentAt: now}
+		if _, err := db.CreateBigQPrompt(p); err != nil {
+			t.Fatalf("create prompt: %v", err)
+		}
+		a := &database.BigQAnswer{PromptID: p.ID, Phone: s.Phone, QuestionID: q.ID, Body: "answer " + strconv.Itoa(i), Consent: s.Consent, CreatedAt: now}
+		if _, err := db.RecordBigQAnswer(a, 0, ""); err != nil {
+			t.Fatalf("record answer: %v", err)
+		}
+	}
+
+	var answers []database.BigQAnswer
+	path := "/admin/bigq/answers?question_id=" + strconv.FormatInt(q.ID, 10)
+	if doJSON(t, mux, http.MethodGet, path, "", "", &answers); len(answers) != 2 || answers[0].Question != q.Text {
+		t.Errorf("answers = %+v", answers)
+	}
+	if doJSON(t, mux, http.MethodGet, "/admin/bigq/answers?phone=15550000000", "", "", &answers); len(answers) != 1 {
+		t.Errorf("answers for one phone = %+v", answers)
+	}
+	if code := doJSON(t, mux, http.MethodGet, "/admin/bigq/answers?question_id=x", "", "", nil); code != http.StatusBadRequest {
+		t.Errorf("bad question_id: status %d, want 400", code)
+	}
+
+	var subs []database.BigQSubscriber
+	if doJSON(t, mux, http.MethodGet, "/admin/bigq/subscribers", "", "", &subs); len(subs) != 2 || !subs[0].Consent || subs[0].ConsentAt == nil {
+		t.Errorf("subscribers = %+v", subs)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/bigq/export", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("export Content-Type = %q", ct)
+	}
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("export line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 1 || lines[0]["contributor"] != "c_alice" || lines[0]["answer"] != "answer 0" {
+		t.Errorf("export = %+v, want alice's shared answer", lines)
+	}
+	if _, ok := lines[0]["phone"]; ok {
+		t.Errorf("export includes phone numbers: %+v", lines[0])
+	}
+
+	if code := doJSON(t, mux, http.MethodDelete, "/admin/bigq/questions/"+strconv.FormatInt(q.ID, 10), "", "", nil); code != http.StatusNoContent {
+		t.Errorf("retire: status %d", code)
+	}
+	if code := doJSON(t, mux, http.MethodDelete, "/admin/bigq/questions/999", "", "", nil); code != http.StatusNotFound {
+		t.Errorf("retire missing: status %d, want 404", code)
+	}
+	var list []database.BigQuestion
+	if doJSON(t, mux, http.MethodGet, "/admin/bigq/questions", "", "", &list); len(list) != 1 || list[0].Active {
+		t.Errorf("questions = %+v, want one retired question", list)
+	}
+}
@@ -0,0 +1,60 @@
+// nexus - Personal AI assistant system
+// Copyright (C) 2025  nexus contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+// Escalator queues a message for a person to answer and returns the
+// escalation's ID. *portalclient.Client implements it.
+type Escalator interface {
+	Escalate(ctx context.Context, phone, hash, body, reason string) (string, error)
+}
+
+// escalatedReply tells the sender a parked message went to a person.
+const escalatedReply = "That's one for a real person, so I've passed it on. Someone will text you back soon."
+
+// escalate classifies msg and queues it for a person if it is flagged.
+// parked reports whether the router holds the message rather than
+// dispatching it; the sender then gets escalatedReply, or err if it could
+// not be queued. Messages for commands that still run are queued on a best
+// effort basis. Classifier errors are logged and treated as "no", so the
+// sender still gets an answer.
+func (h *Handler) escalate(ctx context.Context, msg *sms.Message) (parked bool, err error) {
+	if h.classifier == nil || h.escalator == nil || msg.Body == "" {
+		return false, nil
+	}
+	body := msg.Redacted()
+	reason, ok, err := h.classifier.Classify(ctx, body)
+	if err != nil {
+		log.Printf("error classifying message from %s: %v", msg.From, err)
+		return false, nil
+	}
+	if !ok {
+		return false, nil
+	}
+	parked = h.router.Parks(msg)
+	if _, err := h.escalator.Escalate(ctx, msg.Phone, identity.HashIdentifier(msg.Phone), body, reason); err != nil {
+		if parked {
+			return true, err
+		}
+		log.Printf("error escalating %q from %s: %v", msg.Command, msg.From, err)
+	}
+	return parked, nil
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jredh-dev/nexus/internal/escalation"
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+// fakeEscalator records escalated messages, failing if err is set.
+type fakeEscalator struct {
+	queued []string
+	err    error
+}
+
+func (f *fakeEscalator) Escalate(ctx context.Context, phone, hash, body, reason string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	f.queued = append(f.queued, body+" ("+reason+")")
+	return "esc-1", nil
+}
+
+func TestSMS_Escalation(t *testing.T) {
+	var handled []string
+	record := func(ctx context.Context, msg *sms.Message) (string, error) {
+		handled = append(handled, msg.Body)
+		return "done", nil
+	}
+	router := sms.NewRouter()
+	router.Handle("NOTE", "NOTE <text> - save a note", record)
+	router.Handle("ASK", "ASK <question> - ask your circle", record)
+	router.Park("ASK")
+	router.Fallback(record)
+	esc := &fakeEscalator{}
+	h := New(testDB(t), router, Options{
+		Classifier:  escalation.NewKeywords(escalation.DefaultKeywords),
+		Escalations: esc,
+	})
+
+	tests := []struct {
+		body    string
+		reply   string
+		handled bool
+		queued  bool
+	}{
+		{"how are you", "done", true, false},
+		{"My landlord sent an eviction notice", "passed it on", false, true},
+		{"ASK does anyone know a lawyer?", "passed it on", false, true},
+		{"NOTE call the lawyer", "done", true, true},
+		{"NOTE buy milk", "done", true, false},
+	}
+	for _, tt := range tests {
+		handled, esc.queued = nil, nil
+		body := postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {tt.body}}).Body.String()
+		if !strings.Contains(body, tt.reply) {
+			t.Errorf("%q: reply %s, want %q", tt.body, body, tt.reply)
+		}
+		if (len(handled) == 1) != tt.handled {
+			t.Errorf("%q: handled = %v, want %v", tt.body, handled, tt.handled)
+		}
+		if (len(esc.queued) == 1) != tt.queued {
+			t.Errorf("%q: queued = %v, want %v", tt.body, esc.queued, tt.queued)
+		}
+	}
+
+	// A parked message that can't be queued fails rather than going to
+	// the handler; a running command is unaffected.
+	esc.err = errors.New("portal down")
+	handled = nil
+	if body := postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"I need a lawyer"}}).Body.String(); !strings.Contains(body, "something went wrong") || len(handled) != 0 {
+		t.Errorf("parked with portal down: reply %s, handled %v", body, handled)
+	}
+	if body := postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"NOTE call the lawyer"}}).Body.String(); !strings.Contains(body, "done") {
+		t.Errorf("running command with portal down: reply %s", body)
+	}
+}
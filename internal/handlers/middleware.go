@@ -0,0 +1,97 @@
+// nexus - Personal AI assistant system
+// Copyright (C) 2025  nexus contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+package handlers
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jredh-dev/nexus/internal/twilio"
+)
+
+// TwilioSignatureMiddleware rejects webhook requests whose X-Twilio-Signature
+// header does not match the HMAC-SHA1 of the request URL and form parameters.
+//
+// baseURL is the public URL Twilio was configured with (e.g. the ngrok URL).
+// Requests behind a tunnel or proxy arrive with a different host than the one
+// Twilio signed, so the signed URL is rebuilt from baseURL plus the request
+// path and query. If baseURL is empty, the URL is derived from the request.
+func TwilioSignatureMiddleware(authToken, baseURL string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Failed to parse form", http.StatusBadRequest)
+				return
+			}
+
+			signature := r.Header.Get(twilio.SignatureHeader)
+			fullURL := requestURL(r, baseURL)
+			if !twilio.ValidateSignature(authToken, fullURL, r.PostForm, signature) {
+				log.Printf("Rejected webhook with invalid Twilio signature for %s", fullURL)
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestURL reconstructs the absolute URL Twilio used to call us.
+func requestURL(r *http.Request, baseURL string) string {
+	if baseURL != "" {
+		return strings.TrimRight(baseURL, "/") + r.URL.RequestURI()
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
+
+// AdminTokenMiddleware guards debugging endpoints with a bearer token. When
+// token is empty the endpoints are disabled and answer 404.
+func AdminTokenMiddleware(token string) func(http.Handler) http.Handler {
+	return bearerToken(token)
+}
+
+// InternalAPIMiddleware guards endpoints other nexus services call (e.g.
+// event ingest from the portal) with the shared INTERNAL_API_TOKEN. When
+// token is empty the endpoints are disabled and answer 404.
+func InternalAPIMiddleware(token string) func(http.Handler) http.Handler {
+	return bearerToken(token)
+}
+
+func bearerToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				http.NotFound(w, r)
+				return
+			}
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				jsonError(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
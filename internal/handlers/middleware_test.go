@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jredh-dev/nexus/internal/twilio"
+)
+
+const (
+	testAuthToken = "test-auth-token"
+	testBaseURL   = "https://abc123.ngrok.io"
+)
+
+func signedRequest(t *testing.T, path string, form url.Values, token string) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(twilio.SignatureHeader, twilio.Signature(token, testBaseURL+path, form))
+	return req
+}
+
+func TestTwilioSignatureMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	h := TwilioSignatureMiddleware(testAuthToken, testBaseURL)(ok)
+
+	form := url.Values{"From": {"+15555555555"}, "Body": {"hello"}}
+
+	t.Run("valid signature", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, signedRequest(t, "/sms", form, testAuthToken))
+		if w.Code != http.StatusOK {
+			t.Errorf("expected 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("wrong token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, signedRequest(t, "/sms", form, "other-token"))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/sms", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		req := signedRequest(t, "/sms", form, testAuthToken)
+		tampered := url.Values{"From": {"+15550000000"}, "Body": {"hello"}}
+		req.Body = io.NopCloser(strings.NewReader(tampered.Encode()))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected 403, got %d", w.Code)
+		}
+	})
+}
+
+func TestRequestURL_DerivedFromRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/sms?x=1", nil)
+	req.Host = "nexus.example.com"
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	if got, want := requestURL(req, ""), "https://nexus.example.com/sms?x=1"; got != want {
+		t.Errorf("requestURL = %q, want %q", got, want)
+	}
+	if got, want := requestURL(req, "https://abc123.ngrok.io/"), "https://abc123.ngrok.io/sms?x=1"; got != want {
+		t.Errorf("requestURL with base = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+// nexus - Personal AI assistant system
+// Copyright (C) 2025  nexus contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/jredh-dev/nexus/internal/calclient"
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+// InternalNotes lists a phone's most recent notes, newest first, for other
+// nexus services (the portal draws security questions from them). Query
+// parameters: phone (any format, required), limit.
+// GET /internal/notes
+func (h *Handler) InternalNotes(w http.ResponseWriter, r *http.Request) {
+	phone := r.URL.Query().Get("phone")
+	if phone == "" {
+		jsonError(w, "phone is required", http.StatusBadRequest)
+		return
+	}
+
+	notes, err := h.db.RecentNotes(identity.NormalizePhone(phone), queryLimit(r))
+	if err != nil {
+		log.Printf("error listing notes: %v", err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if notes == nil {
+		notes = []*database.Note{}
+	}
+	writeJSON(w, http.StatusOK, notes)
+}
+
+// InternalReminders lists the events in a phone's nexus-cal reminder feed,
+// most recently created first, for other nexus services (the portal draws
+// security questions from them). Query parameters: phone (any format,
+// required), limit.
+// GET /internal/reminders
+func (h *Handler) InternalReminders(w http.ResponseWriter, r *http.Request) {
+	phone := r.URL.Query().Get("phone")
+	if phone == "" {
+		jsonError(w, "phone is required", http.StatusBadRequest)
+		return
+	}
+	if h.calendar == nil {
+		jsonError(w, "calendar is disabled", http.StatusNotFound)
+		return
+	}
+
+	events := []calclient.Event{}
+	feed, err := h.db.CalFeedByPhone(identity.NormalizePhone(phone))
+	if err != nil {
+		log.Printf("error looking up cal feed: %v", err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if feed != nil {
+		if events, err = h.calendar.ListEvents(r.Context(), feed.FeedID); err != nil {
+			log.Printf("error listing events of feed %s: %v", feed.FeedID, err)
+			jsonError(w, "calendar unavailable", http.StatusBadGateway)
+			return
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].CreatedAt.After(events[j].CreatedAt) })
+	if limit := queryLimit(r); len(events) > limit {
+		events = events[:limit]
+	}
+	writeJSON(w, http.StatusOK, events)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/calclient"
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+func TestInternalNotes(t *testing.T) {
+	db := testDB(t)
+	h := New(db, sms.NewRouter(), Options{})
+	mux := http.NewServeMux()
+	mux.Handle("GET /internal/notes", InternalAPIMiddleware("internal-secret")(http.HandlerFunc(h.InternalNotes)))
+
+	base := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	for i, n := range []database.Note{
+		{Phone: "15555555555", Body: "dentist on friday"},
+		{Phone: "15555555555", Body: "pick up kumquats"},
+		{Phone: "15550000000", Body: "someone else's"},
+	} {
+		n.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := db.CreateNote(&n); err != nil {
+			t.Fatalf("create note: %v", err)
+		}
+	}
+
+	var notes []database.Note
+	if code := doJSON(t, mux, http.MethodGet, "/internal/notes?phone=%2B1%20555-555-5555", "internal-secret", "", &notes); code != http.StatusOK {
+		t.Fatalf("list notes: status %d", code)
+	}
+	if len(notes) != 2 || notes[0].Body != "pick up kumquats" {
+		t.Errorf("notes = %+v, want the two of 15555555555, newest first", notes)
+	}
+	if code := doJSON(t, mux, http.MethodGet, "/internal/notes", "internal-secret", "", nil); code != http.StatusBadRequest {
+		t.Errorf("missing phone: status %d, want 400", code)
+	}
+	if code := doJSON(t, mux, http.MethodGet, "/internal/notes?phone=15555555555", "", "", nil); code != http.StatusUnauthorized {
+		t.Errorf("no token: status %d, want 401", code)
+	}
+}
+
+func TestInternalReminders(t *testing.T) {
+	db := testDB(t)
+	base := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	cal := fakeCalendar{"feed-1": {
+		{ID: "e1", Summary: "dentist", Start: base.AddDate(0, 0, 2), CreatedAt: base},
+		{ID: "e2", Summary: "pick up kumquats", Start: base.AddDate(0, 0, 1), CreatedAt: base.Add(time.Hour)},
+	}}
+	if err := db.SaveCalFeed(&database.CalFeed{Phone: "15555555555", FeedID: "feed-1", Token: "tok", CreatedAt: base}); err != nil {
+		t.Fatalf("save cal feed: %v", err)
+	}
+	h := New(db, sms.NewRouter(), Options{Calendar: cal})
+	mux := http.NewServeMux()
+	mux.Handle("GET /internal/reminders", InternalAPIMiddleware("internal-secret")(http.HandlerFunc(h.InternalReminders)))
+
+	var events []calclient.Event
+	if code := doJSON(t, mux, http.MethodGet, "/internal/reminders?phone=5555555555&limit=1", "internal-secret", "", &events); code != http.StatusOK {
+		t.Fatalf("list reminders: status %d", code)
+	}
+	if len(events) != 1 || events[0].ID != "e2" {
+		t.Errorf("events = %+v, want the most recently created", events)
+	}
+	if doJSON(t, mux, http.MethodGet, "/internal/reminders?phone=15550000000", "internal-secret", "", &events); len(events) != 0 {
+		t.Errorf("events without a feed = %+v, want none", events)
+	}
+	if code := doJSON(t, mux, http.MethodGet, "/internal/reminders", "internal-secret", "", nil); code != http.StatusBadRequest {
+		t.Errorf("missing phone: status %d, want 400", code)
+	}
+}
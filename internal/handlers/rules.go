@@ -0,0 +1,256 @@
+// nexus - Personal AI assistant system
+// Copyright (C) 2025  nexus contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/events"
+	"github.com/jredh-dev/nexus/internal/rules"
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/internal/webhooks"
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+// ruleTimeout bounds rule execution triggered by an inbound text, which
+// runs after the webhook has been answered.
+const ruleTimeout = 30 * time.Second
+
+// fireSMS triggers sms.received rules for msg in the background so slow
+// actions never delay the reply.
+func (h *Handler) fireSMS(msg *sms.Message) {
+	if h.rules == nil {
+		return
+	}
+	ev := &rules.Event{
+		Type:  rules.EventSMSReceived,
+		Phone: msg.Phone,
+		Data:  map[string]string{"body": msg.Redacted(), "command": msg.Command},
+		At:    time.Now().UTC(),
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), ruleTimeout)
+		defer cancel()
+		if _, err := h.rules.Fire(ctx, ev); err != nil {
+			log.Printf("error running rules for %s: %v", msg.From, err)
+		}
+	}()
+}
+
+// Events ingests an event from another service (portal signups, giveaway
+// claims, new calendar events, ...), runs the rules it triggers and queues
+// it for webhook subscribers. Body: rules.Event; earlier spellings of the
+// type are accepted (see events.Canonical).
+// POST /events
+func (h *Handler) Events(w http.ResponseWriter, r *http.Request) {
+	if h.rules == nil && h.webhooks == nil {
+		jsonError(w, "event ingest is disabled", http.StatusNotFound)
+		return
+	}
+	var ev rules.Event
+	if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+		jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	ev.Type = events.Canonical(ev.Type)
+	if !rules.KnownEvent(ev.Type) && !webhooks.KnownEvent(ev.Type) {
+		jsonError(w, "unknown event type", http.StatusBadRequest)
+		return
+	}
+	if ev.Phone != "" {
+		ev.Phone = identity.NormalizePhone(ev.Phone)
+	}
+
+	if webhooks.KnownEvent(ev.Type) {
+		data := map[string]string{}
+		if ev.Phone != "" {
+			data["phone"] = ev.Phone
+		}
+		for k, v := range ev.Data {
+			data[k] = v
+		}
+		h.publish(ev.Type, data)
+	}
+
+	runs := []*rules.Run{}
+	if h.rules != nil && rules.KnownEvent(ev.Type) {
+		fired, err := h.rules.Fire(r.Context(), &ev)
+		if err != nil {
+			log.Printf("error running rules for %s: %v", ev.Type, err)
+			jsonError(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if fired != nil {
+			runs = fired
+		}
+	}
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"runs": runs})
+}
+
+// AdminRules lists every rule.
+// GET /admin/rules
+func (h *Handler) AdminRules(w http.ResponseWriter, r *http.Request) {
+	list, err := h.db.Rules()
+	if err != nil {
+		log.Printf("error listing rules: %v", err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []*rules.Rule{}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// AdminCreateRule creates a rule. Body: rules.Rule without id or
+// timestamps; enabled defaults to true. Webhook actions without a secret
+// get a new one, returned with the rule.
+// POST /admin/rules
+func (h *Handler) AdminCreateRule(w http.ResponseWriter, r *http.Request) {
+	rule := &rules.Rule{Enabled: true}
+	if !decodeRule(w, r, rule) {
+		return
+	}
+	rule.CreatedAt = time.Now().UTC()
+	rule.UpdatedAt = rule.CreatedAt
+	if err := h.db.CreateRule(rule); err != nil {
+		log.Printf("error creating rule: %v", err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, rule)
+}
+
+// AdminRule returns one rule.
+// GET /admin/rules/{id}
+func (h *Handler) AdminRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.loadRule(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, rule)
+}
+
+// AdminUpdateRule replaces a rule's name, enabled flag, trigger,
+// conditions and actions.
+// PUT /admin/rules/{id}
+func (h *Handler) AdminUpdateRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.loadRule(w, r)
+	if !ok {
+		return
+	}
+	created := rule.CreatedAt
+	*rule = rules.Rule{ID: rule.ID, Enabled: true}
+	if !decodeRule(w, r, rule) {
+		return
+	}
+	rule.CreatedAt = created
+	rule.UpdatedAt = time.Now().UTC()
+	if _, err := h.db.UpdateRule(rule); err != nil {
+		log.Printf("error updating rule %d: %v", rule.ID, err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, rule)
+}
+
+// AdminDeleteRule deletes a rule and its run history.
+// DELETE /admin/rules/{id}
+func (h *Handler) AdminDeleteRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.loadRule(w, r)
+	if !ok {
+		return
+	}
+	if _, err := h.db.DeleteRule(rule.ID); err != nil {
+		log.Printf("error deleting rule %d: %v", rule.ID, err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminRuleRuns returns a rule's most recent runs, newest first.
+// GET /admin/rules/{id}/runs
+func (h *Handler) AdminRuleRuns(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.loadRule(w, r)
+	if !ok {
+		return
+	}
+	runs, err := h.db.RuleRuns(rule.ID, queryLimit(r))
+	if err != nil {
+		log.Printf("error loading runs of rule %d: %v", rule.ID, err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if runs == nil {
+		runs = []*rules.Run{}
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
+
+// loadRule looks up the rule named by the {id} path value, writing an
+// error response if there is none.
+func (h *Handler) loadRule(w http.ResponseWriter, r *http.Request) (*rules.Rule, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, "invalid rule id", http.StatusBadRequest)
+		return nil, false
+	}
+	rule, err := h.db.Rule(id)
+	if err != nil {
+		log.Printf("error loading rule %d: %v", id, err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return nil, false
+	}
+	if rule == nil {
+		jsonError(w, "rule not found", http.StatusNotFound)
+		return nil, false
+	}
+	return rule, true
+}
+
+// decodeRule reads and validates a rule from the request body and gives
+// webhook actions without a secret a new one, writing an error response
+// if it fails.
+func decodeRule(w http.ResponseWriter, r *http.Request, rule *rules.Rule) bool {
+	id := rule.ID
+	if err := json.NewDecoder(r.Body).Decode(rule); err != nil {
+		jsonError(w, "invalid request body", http.StatusBadRequest)
+		return false
+	}
+	rule.ID = id
+	if err := rule.Validate(); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	for i := range rule.Actions {
+		a := &rule.Actions[i]
+		if a.Type != rules.ActionWebhook || a.Secret != "" {
+			continue
+		}
+		secret, err := webhooks.NewSecret()
+		if err != nil {
+			log.Printf("error generating webhook secret: %v", err)
+			jsonError(w, "internal error", http.StatusInternalServerError)
+			return false
+		}
+		a.Secret = secret
+	}
+	return true
+}
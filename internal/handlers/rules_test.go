@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/rules"
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+func rulesMux(h *Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/rules", h.AdminRules)
+	mux.HandleFunc("POST /admin/rules", h.AdminCreateRule)
+	mux.HandleFunc("GET /admin/rules/{id}", h.AdminRule)
+	mux.HandleFunc("PUT /admin/rules/{id}", h.AdminUpdateRule)
+	mux.HandleFunc("DELETE /admin/rules/{id}", h.AdminDeleteRule)
+	mux.HandleFunc("GET /admin/rules/{id}/runs", h.AdminRuleRuns)
+	mux.Handle("POST /events", InternalAPIMiddleware("internal-secret")(http.HandlerFunc(h.Events)))
+	return mux
+}
+
+func doJSON(t *testing.T, h http.Handler, method, path, token, body string, out interface{}) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if out != nil && w.Code < 300 {
+		if err := json.NewDecoder(w.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s %s: %v", method, path, err)
+		}
+	}
+	return w.Code
+}
+
+func TestAdminRules(t *testing.T) {
+	db := testDB(t)
+	sender := sms.NewFakeSender("")
+	h := New(db, sms.NewRouter(), Options{Rules: rules.NewEngine(db, rules.Deps{Sender: sender})})
+	mux := rulesMux(h)
+
+	var rule rules.Rule
+	body := `{"name":"welcome","trigger":{"event":"portal.user.signup"},"actions":[{"type":"send_sms","body":"Welcome {{.username}}!"}]}`
+	if code := doJSON(t, mux, http.MethodPost, "/admin/rules", "", body, &rule); code != http.StatusCreated {
+		t.Fatalf("create: status %d", code)
+	}
+	if rule.ID == 0 || !rule.Enabled {
+		t.Errorf("created rule = %+v", rule)
+	}
+	path := "/admin/rules/" + strconv.FormatInt(rule.ID, 10)
+
+	// Webhook actions get a signing secret unless one is given.
+	var hooked rules.Rule
+	hookBody := `{"name":"hook","enabled":false,"trigger":{"event":"sms.received"},"actions":[{"type":"webhook","url":"https://example.com/a"},{"type":"webhook","url":"https://example.com/b","secret":"mine"}]}`
+	if code := doJSON(t, mux, http.MethodPost, "/admin/rules", "", hookBody, &hooked); code != http.StatusCreated {
+		t.Fatalf("create webhook rule: status %d", code)
+	}
+	if len(hooked.Actions) != 2 || hooked.Actions[0].Secret == "" || hooked.Actions[1].Secret != "mine" {
+		t.Errorf("webhook secrets = %+v", hooked.Actions)
+	}
+	doJSON(t, mux, http.MethodDelete, "/admin/rules/"+strconv.FormatInt(hooked.ID, 10), "", "", nil)
+
+	for _, bad := range []string{`{`, `{"name":"x","trigger":{"event":"nope"},"actions":[{"type":"send_sms","body":"x"}]}`} {
+		if code := doJSON(t, mux, http.MethodPost, "/admin/rules", "", bad, nil); code != http.StatusBadRequest {
+			t.Errorf("create %s: status %d, want 400", bad, code)
+		}
+	}
+
+	// Ingest requires the internal token.
+	event := `{"type":"portal.user.signup","phone":"(555) 555-5555","data":{"username":"tex"}}`
+	if code := doJSON(t, mux, http.MethodPost, "/events", "", event, nil); code != http.StatusUnauthorized {
+		t.Errorf("ingest without token: status %d", code)
+	}
+	var fired struct{ Runs []rules.Run }
+	if code := doJSON(t, mux, http.MethodPost, "/events", "internal-secret", event, &fired); code != http.StatusAccepted {
+		t.Fatalf("ingest: status %d", code)
+	}
+	if len(fired.Runs) != 1 || fired.Runs[0].Status != rules.RunOK {
+		t.Errorf("runs = %+v", fired.Runs)
+	}
+	if sent := sender.Sent(); len(sent) != 1 || sent[0].To != "+15555555555" || sent[0].Body != "Welcome tex!" {
+		t.Errorf("sent = %+v", sent)
+	}
+	if code := doJSON(t, mux, http.MethodPost, "/events", "internal-secret", `{"type":"bogus"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("unknown event type: status %d", code)
+	}
+
+	var runs []rules.Run
+	if code := doJSON(t, mux, http.MethodGet, path+"/runs", "", "", &runs); code != http.StatusOK || len(runs) != 1 {
+		t.Errorf("runs: status %d, %+v", code, runs)
+	}
+
+	// Earlier spellings of event types are still accepted.
+	legacy := `{"type":"portal.signup","phone":"(555) 555-5555","data":{"username":"tex"}}`
+	if code := doJSON(t, mux, http.MethodPost, "/events", "internal-secret", legacy, &fired); code != http.StatusAccepted || len(fired.Runs) != 1 {
+		t.Errorf("legacy ingest: status %d, runs %+v", code, fired.Runs)
+	}
+
+	update := `{"name":"welcome","enabled":false,"trigger":{"event":"portal.user.signup"},"actions":[{"type":"send_sms","body":"hi"}]}`
+	if code := doJSON(t, mux, http.MethodPut, path, "", update, &rule); code != http.StatusOK || rule.Enabled {
+		t.Errorf("update: status %d, %+v", code, rule)
+	}
+	doJSON(t, mux, http.MethodPost, "/events", "internal-secret", event, &fired)
+	if len(fired.Runs) != 0 {
+		t.Errorf("disabled rule ran: %+v", fired.Runs)
+	}
+
+	var list []rules.Rule
+	if code := doJSON(t, mux, http.MethodGet, "/admin/rules", "", "", &list); code != http.StatusOK || len(list) != 1 {
+		t.Errorf("list: status %d, %+v", code, list)
+	}
+	if code := doJSON(t, mux, http.MethodDelete, path, "", "", nil); code != http.StatusNoContent {
+		t.Errorf("delete: status %d", code)
+	}
+	if code := doJSON(t, mux, http.MethodGet, path, "", "", nil); code != http.StatusNotFound {
+		t.Errorf("get deleted: status %d", code)
+	}
+}
+
+func TestSMS_FiresRules(t *testing.T) {
+	db := testDB(t)
+	sender := sms.NewFakeSender("")
+	router := sms.NewRouter()
+	router.Fallback(func(ctx context.Context, msg *sms.Message) (string, error) {
+		return "ok", nil
+	})
+	h := New(db, router, Options{Rules: rules.NewEngine(db, rules.Deps{Sender: sender})})
+
+	now := time.Now().UTC()
+	if err := db.CreateRule(&rules.Rule{
+		Name: "pizza", Enabled: true,
+		Trigger:   rules.Trigger{Event: rules.EventSMSReceived, Pattern: `\bpizza\b`},
+		Actions:   []rules.Action{{Type: rules.ActionSendSMS, To: "+15550001111", Body: "{{.phone}} wants pizza: {{.body}}"}},
+		CreatedAt: now, UpdatedAt: now,
+	}); err != nil {
+		t.Fatalf("create rule: %v", err)
+	}
+
+	postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"no thanks"}})
+	postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"Pizza tonight?"}})
+
+	// Rules run in the background.
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sender.Sent()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	sent := sender.Sent()
+	if len(sent) != 1 || sent[0].To != "+15550001111" || sent[0].Body != "15555555555 wants pizza: Pizza tonight?" {
+		t.Errorf("sent = %+v", sent)
+	}
+}
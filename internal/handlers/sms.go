@@ -14,32 +14,451 @@
 package handlers
 
 import (
-	"fmt"
+	"context"
+	"log"
 	"net/http"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/escalation"
+	"github.com/jredh-dev/nexus/internal/media"
+	"github.com/jredh-dev/nexus/internal/portalclient"
+	"github.com/jredh-dev/nexus/internal/ratelimit"
+	"github.com/jredh-dev/nexus/internal/rules"
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/internal/twiml"
+	"github.com/jredh-dev/nexus/internal/webhooks"
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
 )
 
-// SMSHandler handles incoming SMS messages from Twilio
-// For now, responds with "world" to any incoming message
-func SMSHandler(w http.ResponseWriter, r *http.Request) {
+// AccountLookup resolves a phone hash (identity.PhoneHash) to the portal
+// account that registered it. *portalclient.Client implements it.
+type AccountLookup interface {
+	UserByPhoneHash(ctx context.Context, hash string) (*portalclient.User, error)
+}
+
+// Options configures optional integrations of the SMS handler.
+type Options struct {
+	// Accounts links senders to portal accounts. When set, only numbers
+	// registered in the portal can use commands; everyone else gets an
+	// onboarding reply pointing at SignupURL.
+	Accounts  AccountLookup
+	SignupURL string
+
+	// Media and Fetcher store MMS attachments from senders who haven't
+	// opted out and, with Accounts set, are linked, and voice memos from
+	// verified callers. Other attachments are recorded by URL only. MediaLinks checks the signed links GET /media
+	// serves stored attachments by; without it nothing is served.
+	Media      *media.Store
+	Fetcher    media.Fetcher
+	MediaLinks *media.Links
+
+	// HistoryTurns is how many recent turns of the sender's thread are
+	// passed to command handlers (default DefaultHistoryTurns).
+	HistoryTurns int
+
+	// ReplyMode decides what happens to replies longer than one SMS
+	// segment: ReplySplit (the default) sends them as up to MaxSegments
+	// numbered messages, ReplyCap shortens them to MaxSegments
+	// concatenated segments. MaxSegments < 1 means no limit.
+	ReplyMode   string
+	MaxSegments int
+
+	// Calendar and Location let callers hear today's events from their
+	// nexus-cal reminder feed. Location defaults to UTC. Callers must be
+	// linked through Accounts and enter their PIN first.
+	Calendar Calendar
+	Location *time.Location
+
+	// Rules, if set, runs automation rules: sms.received for every
+	// admitted text, and whatever other services post to /events.
+	Rules *rules.Engine
+
+	// Webhooks, if set, queues sms.received for webhook subscribers, along
+	// with the portal events posted to /events.
+	Webhooks *webhooks.Dispatcher
+
+	// Classifier flags texts that need a person; they are queued on
+	// Escalations for an admin to answer. Flagged messages for parked
+	// commands (sms.Router.Park) and for the fallback are held there
+	// instead of handled; other commands still run. Either nil disables
+	// escalation.
+	Classifier  escalation.Classifier
+	Escalations Escalator
+
+	// Limiter throttles each sender. Over-limit messages are dropped
+	// unprocessed; the sender is told once per throttling window. Calls
+	// are limited separately, and over-limit calls are cut off.
+	Limiter *ratelimit.TokenBucket
+}
+
+// DefaultHistoryTurns is the default conversation context window.
+const DefaultHistoryTurns = 10
+
+// Reply modes.
+const (
+	ReplySplit = "split"
+	ReplyCap   = "cap"
+)
+
+// Handler holds dependencies for HTTP handlers.
+type Handler struct {
+	db         *database.DB
+	router     *sms.Router
+	accounts   AccountLookup
+	signupURL  string
+	media      *media.Store
+	fetcher    media.Fetcher
+	links      *media.Links
+	turns      int
+	replyMode  string
+	maxSegs    int
+	limiter    *ratelimit.TokenBucket
+	pinGuesses *ratelimit.Window
+	calendar   Calendar
+	loc        *time.Location
+	rules      *rules.Engine
+	webhooks   *webhooks.Dispatcher
+	classifier escalation.Classifier
+	escalator  Escalator
+}
+
+// New creates a new Handler that records messages in db and dispatches SMS
+// commands through router.
+func New(db *database.DB, router *sms.Router, opts Options) *Handler {
+	h := &Handler{
+		db:         db,
+		router:     router,
+		accounts:   opts.Accounts,
+		signupURL:  opts.SignupURL,
+		media:      opts.Media,
+		fetcher:    opts.Fetcher,
+		links:      opts.MediaLinks,
+		turns:      opts.HistoryTurns,
+		replyMode:  opts.ReplyMode,
+		maxSegs:    opts.MaxSegments,
+		limiter:    opts.Limiter,
+		pinGuesses: ratelimit.NewWindow(maxPINGuesses, pinGuessPeriod),
+		calendar:   opts.Calendar,
+		loc:        opts.Location,
+		rules:      opts.Rules,
+		webhooks:   opts.Webhooks,
+		classifier: opts.Classifier,
+		escalator:  opts.Escalations,
+	}
+	if h.turns <= 0 {
+		h.turns = DefaultHistoryTurns
+	}
+	if h.loc == nil {
+		h.loc = time.UTC
+	}
+	return h
+}
+
+// SMS handles incoming SMS messages from Twilio.
+// Messages from blocked numbers and senders over the rate limit are dropped
+// first. Every other message is recorded in the sender's conversation thread, then
+// routed by its first word to a command handler, which sees the thread's
+// recent turns; its reply is recorded and sent back as TwiML. STOP and
+// START maintain the opt-out list; opted-out numbers get no replies. With
+// account linking enabled, messages are attributed to the sender's portal
+// account and unknown numbers are asked to sign up. With escalation
+// enabled, every message is classified before it is dispatched.
+// POST /sms
+func (h *Handler) SMS(w http.ResponseWriter, r *http.Request) {
 	// Parse form data (Twilio sends webhook as POST form data)
 	if err := r.ParseForm(); err != nil {
 		http.Error(w, "Failed to parse form", http.StatusBadRequest)
 		return
 	}
 
-	// Get the incoming message body (optional for now, but good for logging)
-	from := r.FormValue("From")
-	body := r.FormValue("Body")
+	msg := sms.ParseMessage(r.PostForm)
+	log.Printf("SMS received from %s: %s", msg.From, msg.Redacted())
 
-	// Log incoming message (for debugging)
-	fmt.Printf("SMS received from %s: %s\n", from, body)
+	if ok, notice := h.admit(msg); !ok {
+		writeTwiML(w, h.segment(notice)...)
+		return
+	}
 
-	// Respond with TwiML (Twilio Markup Language)
-	// This tells Twilio to send "world" back to the sender
-	w.Header().Set("Content-Type", "text/xml")
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
-<Response>
-    <Message>world</Message>
-</Response>`)
+	linkErr := h.link(r.Context(), msg)
+	if linkErr != nil {
+		log.Printf("error looking up account for %s: %v", msg.From, linkErr)
+	}
+
+	rec := &database.Message{
+		Phone:      msg.Phone,
+		Direction:  database.DirectionInbound,
+		Body:       msg.Redacted(),
+		MessageSID: msg.SID,
+		UserID:     msg.AccountID(),
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := h.db.RecordMessage(rec); err != nil {
+		log.Printf("error recording message from %s: %v", msg.From, err)
+	} else {
+		msg.ID, msg.ThreadID = rec.ID, rec.ThreadID
+		if len(msg.Media) > 0 {
+			h.saveMedia(r.Context(), msg, rec.CreatedAt, h.mayFetchMedia(msg))
+		}
+		h.loadHistory(msg)
+	}
+	h.fireSMS(msg)
+	h.publish(webhooks.EventSMSReceived, map[string]string{
+		"phone": msg.Phone, "body": msg.Redacted(), "command": msg.Command,
+	})
+
+	reply, err := h.reply(r, msg, linkErr)
+	if err != nil {
+		log.Printf("error handling %q from %s: %v", msg.Command, msg.From, err)
+		reply = "Sorry, something went wrong. Please try again later."
+	}
+
+	if reply != "" {
+		if err := h.db.RecordMessage(&database.Message{
+			Phone:     msg.Phone,
+			Direction: database.DirectionOutbound,
+			Body:      reply,
+			UserID:    msg.AccountID(),
+			CreatedAt: time.Now().UTC(),
+		}); err != nil {
+			log.Printf("error recording reply to %s: %v", msg.From, err)
+		}
+	}
+
+	writeTwiML(w, h.segment(reply)...)
+}
+
+// segment fits reply into SMS segments according to the reply mode.
+func (h *Handler) segment(reply string) []string {
+	if reply == "" {
+		return nil
+	}
+	if h.replyMode == ReplyCap {
+		return []string{sms.CapReply(reply, h.maxSegs)}
+	}
+	return sms.SplitReply(reply, h.maxSegs)
+}
+
+// loadHistory fills msg.History with the latest turns of its thread.
+func (h *Handler) loadHistory(msg *sms.Message) {
+	msgs, err := h.db.ThreadMessages(msg.ThreadID, h.turns)
+	if err != nil {
+		log.Printf("error loading thread %d: %v", msg.ThreadID, err)
+		return
+	}
+	for _, m := range msgs {
+		msg.History = append(msg.History, sms.Turn{
+			Inbound: m.Direction == database.DirectionInbound,
+			Body:    m.Body,
+			At:      m.CreatedAt,
+		})
+	}
+}
+
+// saveMedia records msg's attachments against the message and, if fetch
+// is set, downloads them into the blob store. Otherwise, or if a download
+// fails, the attachment is recorded by URL only.
+func (h *Handler) saveMedia(ctx context.Context, msg *sms.Message, at time.Time, fetch bool) {
+	fetch = fetch && h.media != nil && h.fetcher != nil
+	for _, att := range msg.Media {
+		m := &database.Media{
+			MessageID:   msg.ID,
+			Phone:       msg.Phone,
+			URL:         att.URL,
+			ContentType: att.ContentType,
+			CreatedAt:   at,
+		}
+		if fetch {
+			sum, size, err := h.fetchMedia(ctx, att.URL)
+			if err != nil {
+				log.Printf("error fetching media %s from %s: %v", att.URL, msg.From, err)
+			}
+			m.SHA256, m.Size = sum, size
+		}
+		if err := h.db.CreateMedia(m); err != nil {
+			log.Printf("error recording media from %s: %v", msg.From, err)
+		}
+	}
+}
+
+// mayFetchMedia reports whether a text's attachments may be downloaded: its
+// sender must not have opted out and, with account linking enabled, must
+// be linked, so unknown numbers can't fill the store.
+func (h *Handler) mayFetchMedia(msg *sms.Message) bool {
+	if h.accounts != nil && msg.Account == nil {
+		return false
+	}
+	optedOut, err := h.db.IsOptedOut(msg.Phone)
+	if err != nil {
+		log.Printf("error checking opt-out for %s: %v", msg.From, err)
+		return false
+	}
+	return !optedOut
+}
+
+func (h *Handler) fetchMedia(ctx context.Context, url string) (string, int64, error) {
+	body, err := h.fetcher.Fetch(ctx, url)
+	if err != nil {
+		return "", 0, err
+	}
+	defer body.Close()
+	return h.media.Put(body)
+}
+
+// Media serves a stored MMS attachment by its SHA-256 through a signed,
+// expiring link made by media.Links (query parameters exp and sig), so
+// links can be texted back to users without exposing the store.
+// GET /media/{sum}
+func (h *Handler) Media(w http.ResponseWriter, r *http.Request) {
+	sum := r.PathValue("sum")
+	if h.media == nil || h.links == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if q := r.URL.Query(); !h.links.Valid(sum, q.Get("exp"), q.Get("sig")) {
+		http.Error(w, "Link expired or invalid", http.StatusForbidden)
+		return
+	}
+	m, err := h.db.MediaBySHA256(sum)
+	if err != nil {
+		log.Printf("error looking up media %s: %v", sum, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if m == nil {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := h.media.Open(sum)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	if m.ContentType != "" {
+		w.Header().Set("Content-Type", m.ContentType)
+	}
+	w.Header().Set("Cache-Control", "private, max-age=86400, immutable")
+	http.ServeContent(w, r, "", m.CreatedAt, f)
+}
+
+// throttledReply is sent, once per throttling window, to senders over the
+// rate limit.
+const throttledReply = "You're sending messages too quickly, so some were ignored. Please wait a few minutes and try again."
+
+// admit applies the blocklist and the per-sender rate limit. It reports
+// whether msg should be processed and, if not, the notice to reply with
+// ("" for none). STOP and START are never throttled. Lookup errors fail
+// open so a database hiccup doesn't silence everyone.
+func (h *Handler) admit(msg *sms.Message) (bool, string) {
+	blocked, err := h.db.IsBlocked(msg.Phone)
+	if err != nil {
+		log.Printf("error checking blocklist for %s: %v", msg.From, err)
+	} else if blocked {
+		log.Printf("dropping message from blocked %s", msg.From)
+		return false, ""
+	}
+
+	if h.limiter == nil || sms.IsStop(msg) || sms.IsStart(msg) {
+		return true, ""
+	}
+	d, err := h.limiter.Take(msg.Phone, time.Now().UTC())
+	if err != nil {
+		log.Printf("error rate limiting %s: %v", msg.From, err)
+		return true, ""
+	}
+	switch d {
+	case ratelimit.Notify:
+		log.Printf("rate limiting %s", msg.From)
+		if optedOut, err := h.db.IsOptedOut(msg.Phone); err != nil || optedOut {
+			return false, ""
+		}
+		return false, throttledReply
+	case ratelimit.Drop:
+		log.Printf("dropping message from rate-limited %s", msg.From)
+		return false, ""
+	}
+	return true, ""
+}
+
+// Replies to the carrier opt-out keywords.
+const (
+	optOutReply = "You have been unsubscribed and will receive no further messages. Reply START to resubscribe."
+	optInReply  = "You have been resubscribed. Reply HELP for help or STOP to unsubscribe."
+)
+
+// link sets msg.Account from the portal when account linking is enabled.
+func (h *Handler) link(ctx context.Context, msg *sms.Message) error {
+	if h.accounts == nil {
+		return nil
+	}
+	user, err := h.accounts.UserByPhoneHash(ctx, identity.HashIdentifier(msg.Phone))
+	if err != nil || user == nil {
+		return err
+	}
+	msg.Account = &sms.Account{ID: user.ID, Username: user.Username, Name: user.Name}
+	return nil
+}
+
+// onboardingReply is sent to numbers that aren't linked to an account.
+func (h *Handler) onboardingReply() string {
+	text := "Welcome to nexus! This number isn't linked to an account yet."
+	if h.signupURL != "" {
+		text += " Sign up with this phone number at " + h.signupURL + ", then text HELP."
+	}
+	return text
+}
+
+// reply handles the STOP/START opt-out keywords, suppresses replies to
+// opted-out numbers (except HELP), asks unknown numbers to sign up when
+// account linking is enabled, and otherwise dispatches msg to the command
+// router. linkErr is the error, if any, from looking up msg's account.
+func (h *Handler) reply(r *http.Request, msg *sms.Message, linkErr error) (string, error) {
+	switch {
+	case sms.IsStop(msg):
+		if err := h.db.OptOut(msg.Phone, time.Now().UTC()); err != nil {
+			return "", err
+		}
+		return optOutReply, nil
+	case sms.IsStart(msg):
+		if err := h.db.OptIn(msg.Phone); err != nil {
+			return "", err
+		}
+		return optInReply, nil
+	}
+
+	optedOut, err := h.db.IsOptedOut(msg.Phone)
+	if err != nil {
+		return "", err
+	}
+	if optedOut && msg.Command != "HELP" {
+		log.Printf("suppressing reply to opted-out %s", msg.From)
+		return "", nil
+	}
+
+	// HELP stays available to everyone, as carriers require.
+	if h.accounts != nil && msg.Command != "HELP" {
+		if linkErr != nil {
+			return "", linkErr
+		}
+		if msg.Account == nil {
+			return h.onboardingReply(), nil
+		}
+	}
+
+	if parked, err := h.escalate(r.Context(), msg); parked {
+		return escalatedReply, err
+	}
+	return h.router.Dispatch(r.Context(), msg)
+}
+
+// writeTwiML writes a TwiML response with one <Message> per part. No
+// parts produces an empty <Response> so Twilio sends nothing back.
+func writeTwiML(w http.ResponseWriter, parts ...string) {
+	resp := twiml.NewResponse()
+	for _, part := range parts {
+		resp.Add(twiml.Message{Body: part})
+	}
+	twiml.Write(w, resp)
 }
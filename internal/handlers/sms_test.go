@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/media"
+	"github.com/jredh-dev/nexus/internal/portalclient"
+	"github.com/jredh-dev/nexus/internal/ratelimit"
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+func testDB(t *testing.T) *database.DB {
+	t.Helper()
+	db, err := database.Open(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func postSMS(t *testing.T, h http.HandlerFunc, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/sms", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h(w, req)
+	return w
+}
+
+func TestSMS_EscapesReply(t *testing.T) {
+	router := sms.NewRouter()
+	router.Fallback(func(ctx context.Context, msg *sms.Message) (string, error) {
+		return "you said: " + msg.Body, nil
+	})
+	h := New(testDB(t), router, Options{})
+
+	w := postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"<b>Tom & Jerry</b>"}})
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/xml" {
+		t.Errorf("expected text/xml, got %q", ct)
+	}
+	want := "<Message>you said: &lt;b&gt;Tom &amp; Jerry&lt;/b&gt;</Message>"
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("body missing escaped reply %q:\n%s", want, w.Body.String())
+	}
+}
+
+func TestSMS_EmptyReply(t *testing.T) {
+	router := sms.NewRouter()
+	router.Fallback(func(ctx context.Context, msg *sms.Message) (string, error) {
+		return "", nil
+	})
+	h := New(testDB(t), router, Options{})
+
+	w := postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"hi"}})
+	if strings.Contains(w.Body.String(), "<Message>") {
+		t.Errorf("expected no <Message>, got:\n%s", w.Body.String())
+	}
+}
+
+func TestSMS_LongReply(t *testing.T) {
+	long := strings.TrimSpace(strings.Repeat("the quick brown fox jumps over the lazy dog ", 10))
+	router := sms.NewRouter()
+	router.Fallback(func(ctx context.Context, msg *sms.Message) (string, error) {
+		return long, nil
+	})
+
+	tests := []struct {
+		name     string
+		opts     Options
+		messages int
+		want     string
+	}{
+		{"split", Options{MaxSegments: 3}, 3, "<Message>(1/3) the quick brown fox"},
+		{"split capped", Options{MaxSegments: 2}, 2, "...</Message></Response>"},
+		{"cap", Options{ReplyMode: ReplyCap, MaxSegments: 2}, 1, "<Message>the quick brown fox"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(testDB(t), router, tt.opts)
+			body := postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"hi"}}).Body.String()
+			if n := strings.Count(body, "<Message>"); n != tt.messages {
+				t.Errorf("got %d <Message> elements, want %d:\n%s", n, tt.messages, body)
+			}
+			if !strings.Contains(body, tt.want) {
+				t.Errorf("body missing %q:\n%s", tt.want, body)
+			}
+		})
+	}
+}
+
+func TestSMS_RateLimit(t *testing.T) {
+	db := testDB(t)
+	var handled int
+	router := sms.NewRouter()
+	router.Fallback(func(ctx context.Context, msg *sms.Message) (string, error) {
+		handled++
+		return "ok", nil
+	})
+	h := New(db, router, Options{Limiter: ratelimit.NewTokenBucket(2, time.Hour, db)})
+
+	var replies []string
+	for _, body := range []string{"one", "two", "three", "four", "STOP"} {
+		w := postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {body}})
+		replies = append(replies, w.Body.String())
+	}
+
+	if handled != 2 {
+		t.Errorf("handled %d messages, want 2", handled)
+	}
+	if !strings.Contains(replies[2], "too quickly") {
+		t.Errorf("first over-limit message got no notice:\n%s", replies[2])
+	}
+	if strings.Contains(replies[3], "<Message>") {
+		t.Errorf("second over-limit message got a reply:\n%s", replies[3])
+	}
+	if !strings.Contains(replies[4], "unsubscribed") {
+		t.Errorf("STOP must bypass the rate limit:\n%s", replies[4])
+	}
+
+	w := postSMS(t, h.SMS, url.Values{"From": {"+15550000000"}, "Body": {"hi"}})
+	if !strings.Contains(w.Body.String(), "<Message>ok</Message>") {
+		t.Errorf("other senders must not be limited:\n%s", w.Body.String())
+	}
+}
+
+func TestSMS_HandlerError(t *testing.T) {
+	router := sms.NewRouter()
+	router.Fallback(func(ctx context.Context, msg *sms.Message) (string, error) {
+		return "", errors.New("boom")
+	})
+	h := New(testDB(t), router, Options{})
+
+	w := postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"hi"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "something went wrong") {
+		t.Errorf("expected error reply, got:\n%s", w.Body.String())
+	}
+}
+
+func TestSMS_RecordsConversation(t *testing.T) {
+	db := testDB(t)
+	var history []sms.Turn
+	router := sms.NewRouter()
+	router.Fallback(func(ctx context.Context, msg *sms.Message) (string, error) {
+		history = msg.History
+		return "got it", nil
+	})
+	h := New(db, router, Options{HistoryTurns: 3})
+
+	postSMS(t, h.SMS, url.Values{"MessageSid": {"SM1"}, "From": {"+1 (555) 555-5555"}, "Body": {"remember me"}})
+
+	msgs, err := db.MessagesByPhone("15555555555", 10)
+	if err != nil {
+		t.Fatalf("messages by phone: %v", err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("expected inbound message and reply, got %d", len(msgs))
+	}
+	in, out := msgs[1], msgs[0]
+	if in.Body != "remember me" || in.Direction != database.DirectionInbound || in.MessageSID != "SM1" {
+		t.Errorf("unexpected inbound message: %+v", in)
+	}
+	if out.Body != "got it" || out.Direction != database.DirectionOutbound || out.ThreadID != in.ThreadID {
+		t.Errorf("unexpected reply record: %+v", out)
+	}
+
+	// Later messages see the thread's latest turns, oldest first.
+	postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"and me"}})
+	if len(history) != 3 || history[0].Body != "remember me" || history[1].Inbound || history[2].Body != "and me" {
+		t.Errorf("unexpected history: %+v", history)
+	}
+}
+
+func TestSMS_OptOutFlow(t *testing.T) {
+	db := testDB(t)
+	router := sms.NewRouter()
+	router.Fallback(func(ctx context.Context, msg *sms.Message) (string, error) {
+		return "echo: " + msg.Body, nil
+	})
+	h := New(db, router, Options{})
+	const from = "+15555555555"
+
+	send := func(body string) string {
+		t.Helper()
+		return postSMS(t, h.SMS, url.Values{"From": {from}, "Body": {body}}).Body.String()
+	}
+
+	if body := send("hello"); !strings.Contains(body, "echo: hello") {
+		t.Fatalf("expected echo before opt-out, got:\n%s", body)
+	}
+
+	if body := send("Stop"); !strings.Contains(body, "unsubscribed") {
+		t.Errorf("expected opt-out confirmation, got:\n%s", body)
+	}
+	if out, _ := db.IsOptedOut("15555555555"); !out {
+		t.Fatal("expected number to be opted out")
+	}
+
+	if body := send("hello again"); strings.Contains(body, "<Message>") {
+		t.Errorf("expected no reply while opted out, got:\n%s", body)
+	}
+	if body := send("HELP"); !strings.Contains(body, "<Message>") {
+		t.Errorf("expected HELP to be answered while opted out, got:\n%s", body)
+	}
+
+	if body := send("start"); !strings.Contains(body, "resubscribed") {
+		t.Errorf("expected opt-in confirmation, got:\n%s", body)
+	}
+	// "stop" inside a sentence is not an opt-out keyword.
+	if body := send("stop by the store"); !strings.Contains(body, "echo: stop by the store") {
+		t.Errorf("expected sentence starting with stop to be dispatched, got:\n%s", body)
+	}
+}
+
+// fakeAccounts maps phone hashes to portal users.
+type fakeAccounts struct {
+	users map[string]*portalclient.User
+	err   error
+}
+
+func (f *fakeAccounts) UserByPhoneHash(ctx context.Context, hash string) (*portalclient.User, error) {
+	return f.users[hash], f.err
+}
+
+func TestSMS_AccountLinking(t *testing.T) {
+	db := testDB(t)
+	router := sms.NewRouter()
+	router.Fallback(func(ctx context.Context, msg *sms.Message) (string, error) {
+		return "hi " + msg.Account.FirstName(), nil
+	})
+	accounts := &fakeAccounts{users: map[string]*portalclient.User{
+		identity.PhoneHash("5551112222"): {ID: "user-1", Username: "tex", Name: "Tex Ter"},
+	}}
+	h := New(db, router, Options{Accounts: accounts, SignupURL: "https://portal.example.com/signup"})
+
+	// A known sender is attributed and dispatched.
+	body := postSMS(t, h.SMS, url.Values{"From": {"+15551112222"}, "Body": {"hello"}}).Body.String()
+	if !strings.Contains(body, "hi Tex") {
+		t.Errorf("expected personalized reply, got:\n%s", body)
+	}
+	msgs, _ := db.MessagesByPhone("15551112222", 1)
+	if len(msgs) != 1 || msgs[0].UserID != "user-1" {
+		t.Errorf("expected message attributed to user-1, got %+v", msgs)
+	}
+
+	// An unknown sender gets the signup link instead of a command reply.
+	body = postSMS(t, h.SMS, url.Values{"From": {"+15553334444"}, "Body": {"hello"}}).Body.String()
+	if !strings.Contains(body, "https://portal.example.com/signup") {
+		t.Errorf("expected onboarding reply, got:\n%s", body)
+	}
+	msgs, _ = db.MessagesByPhone("15553334444", 1)
+	if len(msgs) != 1 || msgs[0].UserID != "" {
+		t.Errorf("expected unattributed message, got %+v", msgs)
+	}
+
+	// HELP is still answered for unknown senders.
+	body = postSMS(t, h.SMS, url.Values{"From": {"+15553334444"}, "Body": {"help"}}).Body.String()
+	if !strings.Contains(body, "Commands:") {
+		t.Errorf("expected HELP reply for unknown sender, got:\n%s", body)
+	}
+
+	// A failed lookup is an error, not an onboarding prompt.
+	accounts.err = errors.New("portal down")
+	body = postSMS(t, h.SMS, url.Values{"From": {"+15551112222"}, "Body": {"hello"}}).Body.String()
+	if !strings.Contains(body, "something went wrong") {
+		t.Errorf("expected error reply when lookup fails, got:\n%s", body)
+	}
+}
+
+func TestSMS_StoresMedia(t *testing.T) {
+	db := testDB(t)
+	store, err := media.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	fetcher := media.NewStubFetcher()
+	fetcher.Add("https://api.twilio.com/Media/ME1", []byte("jpeg bytes"))
+
+	var got *sms.Message
+	router := sms.NewRouter()
+	router.Fallback(func(ctx context.Context, msg *sms.Message) (string, error) {
+		got = msg
+		return "", nil
+	})
+	links := media.NewLinks("/media/", "secret", time.Hour)
+	h := New(db, router, Options{Media: store, Fetcher: fetcher, MediaLinks: links})
+
+	postSMS(t, h.SMS, url.Values{
+		"From":              {"+15555555555"},
+		"Body":              {"costco receipt"},
+		"NumMedia":          {"2"},
+		"MediaUrl0":         {"https://api.twilio.com/Media/ME1"},
+		"MediaContentType0": {"image/jpeg"},
+		"MediaUrl1":         {"https://api.twilio.com/Media/missing"},
+		"MediaContentType1": {"image/png"},
+	})
+
+	if got == nil || got.ID == 0 || len(got.Media) != 2 {
+		t.Fatalf("expected dispatched message with ID and 2 media, got %+v", got)
+	}
+	saved, err := db.MediaByMessage(got.ID)
+	if err != nil {
+		t.Fatalf("media by message: %v", err)
+	}
+	if len(saved) != 2 {
+		t.Fatalf("expected 2 media records, got %d", len(saved))
+	}
+	if saved[0].SHA256 == "" || saved[0].Size != int64(len("jpeg bytes")) || saved[0].ContentType != "image/jpeg" {
+		t.Errorf("unexpected stored media: %+v", saved[0])
+	}
+	if saved[1].SHA256 != "" || saved[1].URL != "https://api.twilio.com/Media/missing" {
+		t.Errorf("failed download should be recorded by URL only: %+v", saved[1])
+	}
+
+	// The stored blob is served by hash, through a signed link only.
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /media/{sum}", h.Media)
+	for path, want := range map[string]int{
+		links.URL(saved[0].SHA256):         http.StatusOK,
+		"/media/" + saved[0].SHA256:        http.StatusForbidden,
+		links.URL(strings.Repeat("0", 64)): http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Errorf("GET %s: status %d, want %d", path, w.Code, want)
+		}
+		if want == http.StatusOK && (w.Body.String() != "jpeg bytes" || w.Header().Get("Content-Type") != "image/jpeg") {
+			t.Errorf("GET /media: got %q (%s)", w.Body.String(), w.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestSMS_MediaOnlyFromLinkedSenders(t *testing.T) {
+	db := testDB(t)
+	store, err := media.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	fetcher := media.NewStubFetcher()
+	fetcher.Add("https://api.twilio.com/Media/ME1", []byte("jpeg bytes"))
+	accounts := &fakeAccounts{users: map[string]*portalclient.User{
+		identity.PhoneHash("5555555555"): {ID: "user-1", Username: "tex"},
+		identity.PhoneHash("5556666666"): {ID: "user-2", Username: "ann"},
+	}}
+	h := New(db, sms.NewRouter(), Options{Accounts: accounts, Media: store, Fetcher: fetcher})
+	if err := db.OptOut("15556666666", time.Now().UTC()); err != nil {
+		t.Fatalf("opt out: %v", err)
+	}
+
+	for phone, fetched := range map[string]bool{
+		"15555555555": true,  // linked
+		"15557777777": false, // unknown number
+		"15556666666": false, // opted out
+	} {
+		postSMS(t, h.SMS, url.Values{
+			"From":              {"+" + phone},
+			"Body":              {"photo"},
+			"NumMedia":          {"1"},
+			"MediaUrl0":         {"https://api.twilio.com/Media/ME1"},
+			"MediaContentType0": {"image/jpeg"},
+		})
+		msgs, err := db.MessagesByPhone(phone, 2)
+		if err != nil || len(msgs) == 0 {
+			t.Fatalf("%s: messages: %v", phone, err)
+		}
+		text := msgs[len(msgs)-1] // before any reply
+		saved, err := db.MediaByMessage(text.ID)
+		if err != nil || len(saved) != 1 {
+			t.Fatalf("%s: media = %+v, %v", phone, saved, err)
+		}
+		if got := saved[0].SHA256 != ""; got != fetched {
+			t.Errorf("%s: fetched = %v, want %v", phone, got, fetched)
+		}
+	}
+}
+
+func TestSMS_RedactsPIN(t *testing.T) {
+	db := testDB(t)
+	router := sms.NewRouter()
+	var got string
+	router.Handle("PIN", "PIN <digits>", func(ctx context.Context, msg *sms.Message) (string, error) {
+		got = msg.Args
+		return "PIN set", nil
+	})
+	h := New(db, router, Options{})
+
+	postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"PIN 2468"}})
+	if got != "2468" {
+		t.Errorf("handler saw %q, want 2468", got)
+	}
+	msgs, _ := db.MessagesByPhone("15555555555", 2)
+	for _, m := range msgs {
+		if strings.Contains(m.Body, "2468") {
+			t.Errorf("PIN stored in %s message %q", m.Direction, m.Body)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// nexus - Personal AI assistant system
+// Copyright (C) 2025  nexus contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+// SMSStatus receives Twilio delivery status callbacks for messages sent
+// through the REST API and records the status against the message's SID.
+// Callbacks for unknown SIDs are acknowledged so Twilio doesn't retry them.
+// POST /sms/status
+func (h *Handler) SMSStatus(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	sid := r.PostForm.Get("MessageSid")
+	status := r.PostForm.Get("MessageStatus")
+	if status == "" {
+		status = r.PostForm.Get("SmsStatus") // older callback parameter
+	}
+	if sid == "" || status == "" {
+		http.Error(w, "MessageSid and MessageStatus are required", http.StatusBadRequest)
+		return
+	}
+	if !database.KnownStatus(status) {
+		log.Printf("ignoring unknown status %q for %s", status, sid)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	errorCode := r.PostForm.Get("ErrorCode")
+	found, err := h.db.UpdateMessageStatus(sid, status, errorCode)
+	if err != nil {
+		log.Printf("error updating status of %s: %v", sid, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		log.Printf("status %s for unknown message %s", status, sid)
+	} else if errorCode != "" {
+		log.Printf("message %s to %s %s: error %s", sid, r.PostForm.Get("To"), status, errorCode)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminDelivery lists delivery stats per recipient, most consecutive
+// failures first, to spot dead numbers. Query parameters: phone filters by
+// recipient, min_failures keeps recipients with at least that many
+// failures since their last delivery, limit caps the number of rows.
+// GET /admin/delivery
+func (h *Handler) AdminDelivery(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	phone := q.Get("phone")
+	if phone != "" {
+		phone = identity.NormalizePhone(phone)
+	}
+	minFailures, _ := strconv.Atoi(q.Get("min_failures"))
+
+	stats, err := h.db.DeliveryStats(phone, minFailures, queryLimit(r))
+	if err != nil {
+		log.Printf("error loading delivery stats: %v", err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if stats == nil {
+		stats = []*database.DeliveryStats{}
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
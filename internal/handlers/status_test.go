@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/internal/twilio"
+)
+
+func postStatus(t *testing.T, h http.Handler, form url.Values) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/sms/status", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestSMSStatus(t *testing.T) {
+	db := testDB(t)
+	h := New(db, sms.NewRouter(), Options{})
+	if err := db.RecordOutbound("15555555555", "reminder", "SM1", time.Now().UTC()); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	handler := http.HandlerFunc(h.SMSStatus)
+
+	tests := []struct {
+		name string
+		form url.Values
+		code int
+	}{
+		{"sent", url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"sent"}}, http.StatusNoContent},
+		{"failed", url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"failed"}, "ErrorCode": {"30003"}}, http.StatusNoContent},
+		{"unknown sid", url.Values{"MessageSid": {"SM404"}, "MessageStatus": {"delivered"}}, http.StatusNoContent},
+		{"unknown status", url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"exploded"}}, http.StatusNoContent},
+		{"missing status", url.Values{"MessageSid": {"SM1"}}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if code := postStatus(t, handler, tt.form); code != tt.code {
+			t.Errorf("%s: status %d, want %d", tt.name, code, tt.code)
+		}
+	}
+
+	msgs, err := db.MessagesByPhone("15555555555", 1)
+	if err != nil {
+		t.Fatalf("messages: %v", err)
+	}
+	if msgs[0].Status != database.StatusFailed || msgs[0].ErrorCode != "30003" {
+		t.Errorf("message status = %q (%q), want failed (30003)", msgs[0].Status, msgs[0].ErrorCode)
+	}
+
+	var stats []database.DeliveryStats
+	if code := adminGet(t, adminMux(h, "admin-secret"), "/admin/delivery?min_failures=1", "admin-secret", &stats); code != http.StatusOK {
+		t.Fatalf("delivery stats: status %d", code)
+	}
+	if len(stats) != 1 || stats[0].ConsecutiveFailures != 1 || stats[0].LastErrorCode != "30003" {
+		t.Errorf("unexpected delivery stats: %+v", stats)
+	}
+}
+
+func TestSMSStatus_RequiresSignature(t *testing.T) {
+	h := New(testDB(t), sms.NewRouter(), Options{})
+	handler := TwilioSignatureMiddleware("auth-token", "https://nexus.example.com")(http.HandlerFunc(h.SMSStatus))
+
+	form := url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"delivered"}}
+	if code := postStatus(t, handler, form); code != http.StatusForbidden {
+		t.Errorf("unsigned callback: status %d, want 403", code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/sms/status", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(twilio.SignatureHeader, twilio.Signature("auth-token", "https://nexus.example.com/sms/status", form))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("signed callback: status %d, want 204", w.Code)
+	}
+}
@@ -0,0 +1,408 @@
+// nexus - Personal AI assistant system
+// Copyright (C) 2025  nexus contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jredh-dev/nexus/internal/calclient"
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/ratelimit"
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/internal/twiml"
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+// Calendar lists the events in a nexus-cal feed. *calclient.Client
+// implements it.
+type Calendar interface {
+	ListEvents(ctx context.Context, feedID string) ([]calclient.Event, error)
+}
+
+// Voice menu prompts.
+const (
+	voiceGreeting  = "Hi, this is nexus."
+	voicePINPrompt = "Enter your PIN, then press the pound key."
+	voiceMenu      = "Press 1 or say calendar to hear today's events. Press 2 or say memo to leave a voice memo."
+	voiceMemoTip   = "Leave your memo after the beep. Press the pound key when you're done."
+	voiceGoodbye   = "Goodbye."
+)
+
+// maxMemoSeconds caps the length of a voice memo.
+const maxMemoSeconds = 120
+
+// Caller ID is easily spoofed, so the menu, which reads the caller's
+// calendar and writes to their notes, is only offered once the caller has
+// entered the PIN they set by text. A call stays verified for
+// verifiedCallTTL; each call gets maxPINAttempts tries, and each number
+// maxPINGuesses per pinGuessPeriod across calls.
+const (
+	verifiedCallTTL = 30 * time.Minute
+	maxPINAttempts  = 3
+	maxPINGuesses   = 10
+	pinGuessPeriod  = time.Hour
+)
+
+// Voice answers inbound calls. Blocked and rate-limited numbers are
+// rejected; callers whose number is linked to a portal account and has a
+// PIN are asked for it, everyone else is told how to set one up.
+// POST /voice
+func (h *Handler) Voice(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+	phone := identity.NormalizePhone(r.PostForm.Get("From"))
+	log.Printf("Call from %s", r.PostForm.Get("From"))
+	if !h.admitCall(phone) {
+		twiml.Write(w, twiml.NewResponse(twiml.Reject{}))
+		return
+	}
+
+	resp := twiml.NewResponse(twiml.Say{Text: voiceGreeting})
+	if text := h.callerSetup(r.Context(), phone); text != "" {
+		resp.Add(twiml.Say{Text: text}, twiml.Say{Text: voiceGoodbye}, twiml.Hangup{})
+	} else {
+		pinPrompt(resp, 1)
+	}
+	twiml.Write(w, resp)
+}
+
+// callerSetup checks that phone is linked to a portal account with a PIN.
+// It returns what to tell the caller if not, or "" if they may go on.
+func (h *Handler) callerSetup(ctx context.Context, phone string) string {
+	if h.accounts == nil {
+		return "Calling in isn't available right now."
+	}
+	user, err := h.accounts.UserByPhoneHash(ctx, identity.HashIdentifier(phone))
+	if err != nil {
+		log.Printf("error looking up account for caller %s: %v", phone, err)
+		return "Sorry, something went wrong. Please try again later."
+	}
+	if user == nil {
+		return "This number isn't linked to an account. Sign up with this phone number, then text PIN and a code of four to eight digits to set up calling in."
+	}
+	hash, err := h.db.VoicePIN(phone)
+	if err != nil {
+		log.Printf("error loading voice PIN for %s: %v", phone, err)
+		return "Sorry, something went wrong. Please try again later."
+	}
+	if hash == "" {
+		return "To use nexus by phone, first text PIN and a code of four to eight digits to this number, then call back."
+	}
+	return ""
+}
+
+// pinPrompt adds the PIN prompt for the given attempt.
+func pinPrompt(resp *twiml.Response, attempt int) {
+	resp.Add(
+		twiml.Gather{
+			Action:      "/voice/pin?attempt=" + strconv.Itoa(attempt),
+			Input:       "dtmf",
+			Timeout:     10,
+			FinishOnKey: "#",
+			Prompts:     []twiml.Say{{Text: voicePINPrompt}},
+		},
+		twiml.Say{Text: "I didn't get a PIN. " + voiceGoodbye},
+		twiml.Hangup{},
+	)
+}
+
+// VoicePIN checks the PIN the caller entered and, if it matches, marks the
+// call verified and offers the menu.
+// POST /voice/pin
+func (h *Handler) VoicePIN(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+	phone := identity.NormalizePhone(r.PostForm.Get("From"))
+	if !h.admitCall(phone) {
+		hangup(w)
+		return
+	}
+
+	resp := twiml.NewResponse()
+	if !h.pinGuesses.Allow(phone, time.Now()) {
+		log.Printf("too many voice PIN attempts from %s", phone)
+		resp.Add(twiml.Say{Text: "Too many wrong PINs. Please try again later. " + voiceGoodbye}, twiml.Hangup{})
+		twiml.Write(w, resp)
+		return
+	}
+	callSID := r.PostForm.Get("CallSid")
+	hash, err := h.db.VoicePIN(phone)
+	if err != nil {
+		log.Printf("error loading voice PIN for %s: %v", phone, err)
+		resp.Add(twiml.Say{Text: "Sorry, something went wrong. " + voiceGoodbye}, twiml.Hangup{})
+		twiml.Write(w, resp)
+		return
+	}
+	digits := r.PostForm.Get("Digits")
+	if callSID != "" && hash != "" && digits != "" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(digits)) == nil {
+		if err := h.db.VerifyCall(callSID, phone, time.Now().UTC()); err != nil {
+			log.Printf("error verifying call %s from %s: %v", callSID, phone, err)
+			resp.Add(twiml.Say{Text: "Sorry, something went wrong. " + voiceGoodbye}, twiml.Hangup{})
+		} else {
+			menu(resp)
+		}
+		twiml.Write(w, resp)
+		return
+	}
+
+	log.Printf("wrong voice PIN from %s", phone)
+	attempt, _ := strconv.Atoi(r.URL.Query().Get("attempt"))
+	if attempt < 1 || attempt >= maxPINAttempts {
+		resp.Add(twiml.Say{Text: "That PIN isn't right. " + voiceGoodbye}, twiml.Hangup{})
+	} else {
+		resp.Add(twiml.Say{Text: "That PIN isn't right."})
+		pinPrompt(resp, attempt+1)
+	}
+	twiml.Write(w, resp)
+}
+
+// admitCall applies the blocklist and the per-caller rate limit to every
+// step of a call and reports whether it may go on. Like admit, lookup
+// errors fail open.
+func (h *Handler) admitCall(phone string) bool {
+	if blocked, err := h.db.IsBlocked(phone); err != nil {
+		log.Printf("error checking blocklist for %s: %v", phone, err)
+	} else if blocked {
+		log.Printf("rejecting call from blocked %s", phone)
+		return false
+	}
+
+	if h.limiter == nil {
+		return true
+	}
+	d, err := h.limiter.Take(callKey(phone), time.Now().UTC())
+	if err != nil {
+		log.Printf("error rate limiting caller %s: %v", phone, err)
+		return true
+	}
+	if d != ratelimit.Allow {
+		log.Printf("rejecting call from rate-limited %s", phone)
+		return false
+	}
+	return true
+}
+
+// hangup ends a call that is already in progress.
+func hangup(w http.ResponseWriter) {
+	twiml.Write(w, twiml.NewResponse(twiml.Hangup{}))
+}
+
+// callKey is the rate limit bucket for calls from phone, kept apart from
+// its texts so a call doesn't use up the caller's SMS allowance.
+func callKey(phone string) string {
+	return "call:" + phone
+}
+
+// verified reports whether the call has entered the caller's PIN. If not,
+// it writes a response sending the caller back to the start.
+func (h *Handler) verified(w http.ResponseWriter, r *http.Request, phone string) bool {
+	callSID := r.PostForm.Get("CallSid")
+	if callSID != "" {
+		ok, err := h.db.CallVerified(callSID, phone, time.Now().UTC().Add(-verifiedCallTTL))
+		if err != nil {
+			log.Printf("error checking call %s from %s: %v", callSID, phone, err)
+		} else if ok {
+			return true
+		}
+	}
+	log.Printf("unverified call from %s", phone)
+	twiml.Write(w, twiml.NewResponse(twiml.Redirect{URL: "/voice"}))
+	return false
+}
+
+// menu adds the menu prompt, and a retry when the caller says nothing.
+func menu(resp *twiml.Response) {
+	resp.Add(
+		twiml.Gather{
+			Action:    "/voice/menu",
+			Input:     "dtmf speech",
+			NumDigits: 1,
+			Timeout:   5,
+			Hints:     "calendar, memo",
+			Prompts:   []twiml.Say{{Text: voiceMenu}},
+		},
+		twiml.Say{Text: "Sorry, I didn't catch that."},
+		twiml.Redirect{URL: "/voice/menu"},
+	)
+}
+
+// VoiceMenu routes a verified caller's menu choice.
+// POST /voice/menu
+func (h *Handler) VoiceMenu(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+	phone := identity.NormalizePhone(r.PostForm.Get("From"))
+	if !h.admitCall(phone) {
+		hangup(w)
+		return
+	}
+	if !h.verified(w, r, phone) {
+		return
+	}
+
+	resp := twiml.NewResponse()
+	switch menuChoice(r.PostForm.Get("Digits"), r.PostForm.Get("SpeechResult")) {
+	case "1":
+		resp.Add(
+			twiml.Say{Text: h.todaysEvents(r.Context(), phone)},
+			twiml.Say{Text: voiceGoodbye},
+			twiml.Hangup{},
+		)
+	case "2":
+		resp.Add(
+			twiml.Say{Text: voiceMemoTip},
+			twiml.Record{Action: "/voice/memo", MaxLength: maxMemoSeconds, FinishOnKey: "#", PlayBeep: true},
+			twiml.Say{Text: "I didn't hear anything. " + voiceGoodbye},
+		)
+	default:
+		menu(resp)
+	}
+	twiml.Write(w, resp)
+}
+
+// Spoken words that select each menu option.
+var menuWords = map[string]string{
+	"one": "1", "calendar": "1", "events": "1", "schedule": "1",
+	"two": "2", "memo": "2", "note": "2", "record": "2",
+}
+
+// menuChoice maps a keypress or spoken answer to a menu option, or "".
+func menuChoice(digits, speech string) string {
+	if digits != "" {
+		return digits
+	}
+	for _, word := range strings.FieldsFunc(strings.ToLower(speech), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if choice, ok := menuWords[word]; ok {
+			return choice
+		}
+	}
+	return ""
+}
+
+// todaysEvents describes the caller's events for today from their nexus-cal
+// reminder feed.
+func (h *Handler) todaysEvents(ctx context.Context, phone string) string {
+	if h.calendar == nil {
+		return "The calendar isn't available right now."
+	}
+	feed, err := h.db.CalFeedByPhone(phone)
+	if err != nil {
+		log.Printf("error looking up cal feed for %s: %v", phone, err)
+		return "Sorry, I couldn't reach your calendar."
+	}
+	if feed == nil {
+		return "You don't have a calendar yet. Text REMIND to add your first event."
+	}
+	events, err := h.calendar.ListEvents(ctx, feed.FeedID)
+	if err != nil {
+		log.Printf("error listing events for %s: %v", phone, err)
+		return "Sorry, I couldn't reach your calendar."
+	}
+
+	now := time.Now().In(h.loc)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, h.loc)
+	end := start.AddDate(0, 0, 1)
+	var parts []string
+	for _, e := range events {
+		at := e.Start.In(h.loc)
+		if at.Before(start) || !at.Before(end) {
+			continue
+		}
+		if e.AllDay {
+			parts = append(parts, "All day, "+e.Summary+".")
+		} else {
+			parts = append(parts, "At "+at.Format("3:04 PM")+", "+e.Summary+".")
+		}
+	}
+	switch len(parts) {
+	case 0:
+		return "You have nothing on your calendar today."
+	case 1:
+		return "You have one event today. " + parts[0]
+	default:
+		return fmt.Sprintf("You have %d events today. %s", len(parts), strings.Join(parts, " "))
+	}
+}
+
+// VoiceMemo stores a verified caller's finished recording in the memory
+// store: it is recorded as a message from the caller with the audio
+// attached, and saved as a note so it shows up in LIST and RECALL.
+// POST /voice/memo
+func (h *Handler) VoiceMemo(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+	form := r.PostForm
+	phone := identity.NormalizePhone(form.Get("From"))
+	if !h.admitCall(phone) {
+		hangup(w)
+		return
+	}
+	if !h.verified(w, r, phone) {
+		return
+	}
+
+	resp := twiml.NewResponse()
+	if url := form.Get("RecordingUrl"); url != "" {
+		if err := h.saveMemo(r.Context(), phone, form.Get("RecordingSid"), url+".mp3", form.Get("RecordingDuration")); err != nil {
+			log.Printf("error saving voice memo from %s: %v", phone, err)
+			resp.Add(twiml.Say{Text: "Sorry, I couldn't save your memo."})
+		} else {
+			resp.Add(twiml.Say{Text: "Your memo is saved."})
+		}
+	}
+	resp.Add(twiml.Say{Text: voiceGoodbye}, twiml.Hangup{})
+	twiml.Write(w, resp)
+}
+
+func (h *Handler) saveMemo(ctx context.Context, phone, sid, url, seconds string) error {
+	now := time.Now().UTC()
+	body := "Voice memo"
+	if seconds != "" {
+		body += " (" + seconds + "s)"
+	}
+
+	rec := &database.Message{
+		Phone:      phone,
+		Direction:  database.DirectionInbound,
+		Body:       body,
+		MessageSID: sid,
+		CreatedAt:  now,
+	}
+	if err := h.db.RecordMessage(rec); err != nil {
+		return err
+	}
+	msg := &sms.Message{ID: rec.ID, From: phone, Phone: phone, Media: []sms.Media{{URL: url, ContentType: "audio/mpeg"}}}
+	h.saveMedia(ctx, msg, now, true) // the caller entered their PIN
+
+	return h.db.CreateNote(&database.Note{Phone: phone, Body: body, MessageID: rec.ID, CreatedAt: now})
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jredh-dev/nexus/internal/calclient"
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/media"
+	"github.com/jredh-dev/nexus/internal/portalclient"
+	"github.com/jredh-dev/nexus/internal/ratelimit"
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+type fakeCalendar map[string][]calclient.Event
+
+func (f fakeCalendar) ListEvents(ctx context.Context, feedID string) ([]calclient.Event, error) {
+	return f[feedID], nil
+}
+
+func postVoice(t *testing.T, h http.HandlerFunc, path string, form url.Values) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST %s: status %d", path, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/xml" {
+		t.Errorf("POST %s: Content-Type %q", path, ct)
+	}
+	return w.Body.String()
+}
+
+// voiceHandler returns a handler whose caller +15555555555 is linked to a
+// portal account with PIN 2468.
+func voiceHandler(t *testing.T, db *database.DB, opts Options) *Handler {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("2468"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash PIN: %v", err)
+	}
+	if err := db.SetVoicePIN("15555555555", string(hash), time.Now().UTC()); err != nil {
+		t.Fatalf("set PIN: %v", err)
+	}
+	opts.Accounts = &fakeAccounts{users: map[string]*portalclient.User{
+		identity.PhoneHash("5555555555"): {ID: "user-1", Username: "tex"},
+		identity.PhoneHash("5550000000"): {ID: "user-2", Username: "ann"},
+	}}
+	return New(db, sms.NewRouter(), opts)
+}
+
+// caller returns the form Twilio posts for call sid from +15555555555.
+func caller(sid string, kv ...string) url.Values {
+	form := url.Values{"From": {"+15555555555"}, "CallSid": {sid}}
+	for i := 0; i+1 < len(kv); i += 2 {
+		form.Set(kv[i], kv[i+1])
+	}
+	return form
+}
+
+func TestVoice_PIN(t *testing.T) {
+	db := testDB(t)
+	h := voiceHandler(t, db, Options{})
+
+	body := postVoice(t, h.Voice, "/voice", caller("CA1"))
+	for _, want := range []string{"<Say>Hi, this is nexus.</Say>", `<Gather action="/voice/pin?attempt=1" input="dtmf" timeout="10" finishOnKey="#"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("greeting missing %q:\n%s", want, body)
+		}
+	}
+
+	// The menu is off limits until the call has entered the PIN.
+	if body := postVoice(t, h.VoiceMenu, "/voice/menu", caller("CA1", "Digits", "1")); !strings.Contains(body, "<Redirect>/voice</Redirect>") {
+		t.Errorf("unverified menu not redirected:\n%s", body)
+	}
+
+	body = postVoice(t, h.VoicePIN, "/voice/pin?attempt=1", caller("CA1", "Digits", "1111"))
+	if !strings.Contains(body, "That PIN") || !strings.Contains(body, `action="/voice/pin?attempt=2"`) {
+		t.Errorf("wrong PIN not retried:\n%s", body)
+	}
+	body = postVoice(t, h.VoicePIN, "/voice/pin?attempt=3", caller("CA1", "Digits", "1111"))
+	if !strings.Contains(body, "<Hangup>") || strings.Contains(body, "<Gather") {
+		t.Errorf("last wrong PIN did not hang up:\n%s", body)
+	}
+
+	body = postVoice(t, h.VoicePIN, "/voice/pin?attempt=1", caller("CA1", "Digits", "2468"))
+	if !strings.Contains(body, `<Gather action="/voice/menu" input="dtmf speech" numDigits="1" timeout="5"`) {
+		t.Errorf("right PIN did not offer the menu:\n%s", body)
+	}
+
+	// Verification belongs to the call and the number it was made from.
+	if body := postVoice(t, h.VoiceMenu, "/voice/menu", caller("CA2", "Digits", "2")); strings.Contains(body, "<Record ") {
+		t.Errorf("another call got in:\n%s", body)
+	}
+	other := caller("CA1", "Digits", "2")
+	other.Set("From", "+15550000000")
+	if body := postVoice(t, h.VoiceMenu, "/voice/menu", other); strings.Contains(body, "<Record ") {
+		t.Errorf("another number got in:\n%s", body)
+	}
+
+	tests := []struct {
+		name string
+		form url.Values
+		want string
+	}{
+		{"press 2", caller("CA1", "Digits", "2"), `<Record action="/voice/memo" maxLength="120" finishOnKey="#"`},
+		{"say memo", caller("CA1", "SpeechResult", "Memo, please."), "<Record "},
+		{"press 9", caller("CA1", "Digits", "9"), "<Gather "},
+		{"silence", caller("CA1"), "<Gather "},
+	}
+	for _, tt := range tests {
+		if body := postVoice(t, h.VoiceMenu, "/voice/menu", tt.form); !strings.Contains(body, tt.want) {
+			t.Errorf("%s: missing %q:\n%s", tt.name, tt.want, body)
+		}
+	}
+}
+
+func TestVoice_Setup(t *testing.T) {
+	db := testDB(t)
+
+	// Without account linking nobody can use the menu.
+	h := New(db, sms.NewRouter(), Options{})
+	if body := postVoice(t, h.Voice, "/voice", caller("CA1")); !strings.Contains(body, "available right now") || strings.Contains(body, "<Gather") {
+		t.Errorf("calling in without accounts:\n%s", body)
+	}
+
+	h = voiceHandler(t, db, Options{})
+	unknown := url.Values{"From": {"+15553334444"}, "CallSid": {"CA2"}}
+	if body := postVoice(t, h.Voice, "/voice", unknown); !strings.Contains(body, "linked to an account") {
+		t.Errorf("unlinked caller:\n%s", body)
+	}
+	noPIN := url.Values{"From": {"+15550000000"}, "CallSid": {"CA3"}}
+	if body := postVoice(t, h.Voice, "/voice", noPIN); !strings.Contains(body, "first text PIN") {
+		t.Errorf("caller without a PIN:\n%s", body)
+	}
+}
+
+func TestVoice_Abuse(t *testing.T) {
+	db := testDB(t)
+	limiter := ratelimit.NewTokenBucket(3, time.Hour, ratelimit.NewMemoryStore())
+	h := voiceHandler(t, db, Options{Limiter: limiter})
+
+	postVoice(t, h.Voice, "/voice", caller("CA1"))
+	postVoice(t, h.VoicePIN, "/voice/pin?attempt=1", caller("CA1", "Digits", "2468"))
+	postVoice(t, h.VoiceMenu, "/voice/menu", caller("CA1"))
+	if body := postVoice(t, h.VoiceMenu, "/voice/menu", caller("CA1", "Digits", "1")); !strings.Contains(body, "<Hangup>") || strings.Contains(body, "events") {
+		t.Errorf("rate-limited caller not cut off:\n%s", body)
+	}
+
+	h = voiceHandler(t, db, Options{})
+	if err := db.BlockPhone(&database.Block{Phone: "15555555555", CreatedAt: time.Now().UTC()}); err != nil {
+		t.Fatalf("block: %v", err)
+	}
+	if body := postVoice(t, h.Voice, "/voice", caller("CA2")); !strings.Contains(body, "<Reject>") {
+		t.Errorf("blocked caller not rejected:\n%s", body)
+	}
+	for _, path := range []string{"/voice/pin", "/voice/menu", "/voice/memo"} {
+		handler := map[string]http.HandlerFunc{"/voice/pin": h.VoicePIN, "/voice/menu": h.VoiceMenu, "/voice/memo": h.VoiceMemo}[path]
+		if body := postVoice(t, handler, path, caller("CA1", "Digits", "2468", "RecordingUrl", "https://api.twilio.com/Recordings/RE1")); !strings.Contains(body, "<Hangup>") || strings.Contains(body, "<Gather") || strings.Contains(body, "saved") {
+			t.Errorf("%s: blocked caller not cut off:\n%s", path, body)
+		}
+	}
+}
+
+func TestVoice_PINGuessLimit(t *testing.T) {
+	db := testDB(t)
+	h := voiceHandler(t, db, Options{})
+	for i := 0; i < maxPINGuesses; i++ {
+		postVoice(t, h.VoicePIN, "/voice/pin?attempt=1", caller("CA1", "Digits", "0000"))
+	}
+	if body := postVoice(t, h.VoicePIN, "/voice/pin?attempt=1", caller("CA1", "Digits", "2468")); !strings.Contains(body, "Too many wrong PINs") {
+		t.Errorf("PIN guesses not limited:\n%s", body)
+	}
+}
+
+func TestVoice_TodaysEvents(t *testing.T) {
+	db := testDB(t)
+	if err := db.SaveCalFeed(&database.CalFeed{Phone: "15555555555", FeedID: "feed-1", Token: "tok", CreatedAt: time.Now().UTC()}); err != nil {
+		t.Fatalf("save feed: %v", err)
+	}
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 15, 0, 0, 0, time.UTC)
+	cal := fakeCalendar{"feed-1": {
+		{Summary: "Dentist & cleaning", Start: today},
+		{Summary: "Trash day", Start: today.Truncate(24 * time.Hour), AllDay: true},
+		{Summary: "Tomorrow's thing", Start: today.AddDate(0, 0, 1)},
+	}}
+	h := voiceHandler(t, db, Options{Calendar: cal, Location: time.UTC})
+	if err := db.VerifyCall("CA1", "15555555555", now); err != nil {
+		t.Fatalf("verify call: %v", err)
+	}
+
+	body := postVoice(t, h.VoiceMenu, "/voice/menu", caller("CA1", "Digits", "1"))
+	want := "<Say>You have 2 events today. At 3:00 PM, Dentist &amp; cleaning. All day, Trash day.</Say>"
+	if !strings.Contains(body, want) {
+		t.Errorf("missing %q:\n%s", want, body)
+	}
+	if !strings.Contains(body, "<Hangup>") {
+		t.Errorf("expected hangup:\n%s", body)
+	}
+
+	if err := db.VerifyCall("CA2", "15550000000", now); err != nil {
+		t.Fatalf("verify call: %v", err)
+	}
+	body = postVoice(t, h.VoiceMenu, "/voice/menu", url.Values{"From": {"+15550000000"}, "CallSid": {"CA2"}, "SpeechResult": {"calendar"}})
+	if !strings.Contains(body, "have a calendar yet") {
+		t.Errorf("caller without a feed:\n%s", body)
+	}
+}
+
+func TestVoice_Memo(t *testing.T) {
+	db := testDB(t)
+	store, err := media.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	fetcher := media.NewStubFetcher()
+	fetcher.Add("https://api.twilio.com/Recordings/RE1.mp3", []byte("ID3 audio"))
+	h := voiceHandler(t, db, Options{Media: store, Fetcher: fetcher})
+
+	memo := caller("CA1",
+		"RecordingSid", "RE1",
+		"RecordingUrl", "https://api.twilio.com/Recordings/RE1",
+		"RecordingDuration", "42",
+	)
+	if body := postVoice(t, h.VoiceMemo, "/voice/memo", memo); strings.Contains(body, "saved") {
+		t.Fatalf("memo from an unverified call was saved:\n%s", body)
+	}
+	if err := db.VerifyCall("CA1", "15555555555", time.Now().UTC()); err != nil {
+		t.Fatalf("verify call: %v", err)
+	}
+	if body := postVoice(t, h.VoiceMemo, "/voice/memo", memo); !strings.Contains(body, "Your memo is saved.") {
+		t.Errorf("unexpected reply:\n%s", body)
+	}
+	notes, err := db.RecentNotes("15555555555", 10)
+	if err != nil {
+		t.Fatalf("notes: %v", err)
+	}
+	if len(notes) != 1 || notes[0].Body != "Voice memo (42s)" {
+		t.Fatalf("unexpected notes: %+v", notes)
+	}
+	attached, err := db.MediaByMessage(notes[0].MessageID)
+	if err != nil {
+		t.Fatalf("media: %v", err)
+	}
+	if len(attached) != 1 || attached[0].ContentType != "audio/mpeg" || attached[0].SHA256 == "" {
+		t.Errorf("unexpected media: %+v", attached)
+	}
+}
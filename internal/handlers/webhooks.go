@@ -0,0 +1,159 @@
+// nexus - Personal AI assistant system
+// Copyright (C) 2025  nexus contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/webhooks"
+)
+
+// publish queues an event for webhook subscribers, logging failures so
+// they never affect the caller.
+func (h *Handler) publish(t string, data interface{}) {
+	if h.webhooks == nil {
+		return
+	}
+	if err := h.webhooks.Publish(t, data); err != nil {
+		log.Printf("error queueing %s webhooks: %v", t, err)
+	}
+}
+
+// AdminWebhooks lists every webhook subscription. Secrets are only shown
+// when a subscription is created.
+// GET /admin/webhooks
+func (h *Handler) AdminWebhooks(w http.ResponseWriter, r *http.Request) {
+	list, err := h.db.Subscriptions()
+	if err != nil {
+		log.Printf("error listing webhook subscriptions: %v", err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []*webhooks.Subscription{}
+	}
+	for _, s := range list {
+		s.Secret = ""
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// AdminCreateWebhook registers a subscription and returns it with its
+// signing secret. Body: {"url": "...", "events": ["sms.received", ...]}.
+// POST /admin/webhooks
+func (h *Handler) AdminCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	sub := &webhooks.Subscription{URL: req.URL, Events: req.Events, Enabled: true, CreatedAt: time.Now().UTC()}
+	if err := sub.Validate(); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		log.Printf("error generating webhook secret: %v", err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	sub.Secret = secret
+	if err := h.db.CreateSubscription(sub); err != nil {
+		log.Printf("error creating webhook subscription: %v", err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, sub)
+}
+
+// AdminDeleteWebhook removes a subscription and its queued deliveries.
+// DELETE /admin/webhooks/{id}
+func (h *Handler) AdminDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "invalid subscription id")
+	if !ok {
+		return
+	}
+	found, err := h.db.DeleteSubscription(id)
+	if err != nil {
+		log.Printf("error deleting webhook subscription %d: %v", id, err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		jsonError(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminWebhookDeliveries returns a subscription's most recent deliveries
+// with their attempts and last response code, newest first. ?status=
+// filters by pending, delivered or failed.
+// GET /admin/webhooks/{id}/deliveries
+func (h *Handler) AdminWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "invalid subscription id")
+	if !ok {
+		return
+	}
+	list, err := h.db.Deliveries(id, r.URL.Query().Get("status"), queryLimit(r))
+	if err != nil {
+		log.Printf("error listing deliveries of webhook subscription %d: %v", id, err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []*webhooks.Delivery{}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// AdminRetryDelivery requeues a delivery, typically a failed one, for an
+// immediate attempt.
+// POST /admin/webhooks/deliveries/{id}/retry
+func (h *Handler) AdminRetryDelivery(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "invalid delivery id")
+	if !ok {
+		return
+	}
+	found, err := h.db.RetryDelivery(id, time.Now().UTC())
+	if err != nil {
+		log.Printf("error retrying webhook delivery %d: %v", id, err)
+		jsonError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		jsonError(w, "delivery not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// pathID parses the {id} path value, writing msg as a 400 if it isn't a
+// number.
+func pathID(w http.ResponseWriter, r *http.Request, msg string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		jsonError(w, msg, http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/rules"
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/internal/webhooks"
+)
+
+func TestWebhooks(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		secret   string
+		received []webhooks.Envelope
+		status   = http.StatusOK
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		if err := webhooks.Verify(secret, r.Header.Get(webhooks.HeaderSignature), body, time.Minute, time.Now()); err != nil {
+			t.Errorf("receiver: %v", err)
+		}
+		var env webhooks.Envelope
+		_ = json.Unmarshal(body, &env)
+		received = append(received, env)
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+
+	db := testDB(t)
+	router := sms.NewRouter()
+	router.Fallback(func(ctx context.Context, msg *sms.Message) (string, error) {
+		return "ok", nil
+	})
+	hooks := webhooks.NewDispatcher(db, nil)
+	h := New(db, router, Options{Webhooks: hooks})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/webhooks", h.AdminWebhooks)
+	mux.HandleFunc("POST /admin/webhooks", h.AdminCreateWebhook)
+	mux.HandleFunc("DELETE /admin/webhooks/{id}", h.AdminDeleteWebhook)
+	mux.HandleFunc("GET /admin/webhooks/{id}/deliveries", h.AdminWebhookDeliveries)
+	mux.HandleFunc("POST /admin/webhooks/deliveries/{id}/retry", h.AdminRetryDelivery)
+	mux.Handle("POST /events", InternalAPIMiddleware("internal-secret")(http.HandlerFunc(h.Events)))
+
+	for _, bad := range []string{
+		`{"url":"not a url","events":["sms.received"]}`,
+		`{"url":"https://example.com","events":["sms.sent"]}`,
+	} {
+		if code := doJSON(t, mux, http.MethodPost, "/admin/webhooks", "", bad, nil); code != http.StatusBadRequest {
+			t.Errorf("create %s: status %d, want 400", bad, code)
+		}
+	}
+	var sub webhooks.Subscription
+	// portal.signup is an earlier spelling of portal.user.signup.
+	body := `{"url":"` + receiver.URL + `","events":["sms.received","portal.signup"]}`
+	if code := doJSON(t, mux, http.MethodPost, "/admin/webhooks", "", body, &sub); code != http.StatusCreated {
+		t.Fatalf("create: status %d", code)
+	}
+	if sub.Secret == "" || !sub.Enabled || sub.Events[1] != webhooks.EventPortalSignup {
+		t.Fatalf("created subscription = %+v", sub)
+	}
+	secret = sub.Secret
+
+	var list []webhooks.Subscription
+	if doJSON(t, mux, http.MethodGet, "/admin/webhooks", "", "", &list); len(list) != 1 || list[0].Secret != "" {
+		t.Errorf("list = %+v, want one subscription without its secret", list)
+	}
+
+	postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"hello"}})
+	event := `{"type":"portal.user.signup","phone":"(555) 555-5555","data":{"username":"tex"}}`
+	if code := doJSON(t, mux, http.MethodPost, "/events", "internal-secret", event, nil); code != http.StatusAccepted {
+		t.Fatalf("ingest: status %d", code)
+	}
+	if n, err := hooks.Flush(context.Background()); err != nil || n != 2 {
+		t.Fatalf("Flush = %d, %v", n, err)
+	}
+
+	mu.Lock()
+	if len(received) != 2 || received[0].Type != webhooks.EventSMSReceived || received[1].Type != webhooks.EventPortalSignup {
+		t.Fatalf("received = %+v", received)
+	}
+	if data, _ := received[1].Data.(map[string]interface{}); data["phone"] != "15555555555" || data["username"] != "tex" {
+		t.Errorf("signup data = %+v", received[1].Data)
+	}
+	status = http.StatusServiceUnavailable
+	mu.Unlock()
+
+	postSMS(t, h.SMS, url.Values{"From": {"+15555555555"}, "Body": {"again"}})
+	hooks.Flush(context.Background())
+
+	path := "/admin/webhooks/" + strconv.FormatInt(sub.ID, 10)
+	var pending []webhooks.Delivery
+	doJSON(t, mux, http.MethodGet, path+"/deliveries?status=pending", "", "", &pending)
+	if len(pending) != 1 || pending[0].ResponseCode != 503 || pending[0].Attempts != 1 {
+		t.Fatalf("pending deliveries = %+v", pending)
+	}
+	var all []webhooks.Delivery
+	if doJSON(t, mux, http.MethodGet, path+"/deliveries", "", "", &all); len(all) != 3 || all[2].ResponseCode != 200 {
+		t.Errorf("deliveries = %+v", all)
+	}
+
+	// A retry makes the delivery due again right away.
+	mu.Lock()
+	status = http.StatusOK
+	mu.Unlock()
+	retry := "/admin/webhooks/deliveries/" + strconv.FormatInt(pending[0].ID, 10) + "/retry"
+	if code := doJSON(t, mux, http.MethodPost, retry, "", "", nil); code != http.StatusAccepted {
+		t.Errorf("retry: status %d", code)
+	}
+	if n, _ := hooks.Flush(context.Background()); n != 1 {
+		t.Errorf("flushed %d after retry, want 1", n)
+	}
+	if code := doJSON(t, mux, http.MethodPost, "/admin/webhooks/deliveries/999/retry", "", "", nil); code != http.StatusNotFound {
+		t.Errorf("retry unknown delivery: status %d", code)
+	}
+
+	if code := doJSON(t, mux, http.MethodDelete, path, "", "", nil); code != http.StatusNoContent {
+		t.Errorf("delete: status %d", code)
+	}
+	if code := doJSON(t, mux, http.MethodDelete, path, "", "", nil); code != http.StatusNotFound {
+		t.Errorf("second delete: status %d", code)
+	}
+}
+
+func TestEvents_WebhookOnly(t *testing.T) {
+	var received []webhooks.Envelope
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var env webhooks.Envelope
+		_ = json.NewDecoder(r.Body).Decode(&env)
+		received = append(received, env)
+	}))
+	defer receiver.Close()
+
+	db := testDB(t)
+	hooks := webhooks.NewDispatcher(db, nil)
+	sender := sms.NewFakeSender("")
+	h := New(db, sms.NewRouter(), Options{Webhooks: hooks, Rules: rules.NewEngine(db, rules.Deps{Sender: sender})})
+	mux := rulesMux(h)
+	sub := &webhooks.Subscription{URL: receiver.URL, Secret: "whsec_test", Events: []string{webhooks.EventCalCreated}, Enabled: true, CreatedAt: time.Now()}
+	if err := db.CreateSubscription(sub); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+
+	// nexus-cal reports new events under the same name subscribers use;
+	// they queue webhooks but trigger no rules.
+	event := `{"type":"cal.event.created","data":{"event_id":"e1","summary":"Dentist"}}`
+	var fired struct{ Runs []rules.Run }
+	if code := doJSON(t, mux, http.MethodPost, "/events", "internal-secret", event, &fired); code != http.StatusAccepted || len(fired.Runs) != 0 {
+		t.Fatalf("ingest: status %d, runs %+v", code, fired.Runs)
+	}
+	if n, err := hooks.Flush(context.Background()); err != nil || n != 1 {
+		t.Fatalf("Flush = %d, %v", n, err)
+	}
+	if data, _ := received[0].Data.(map[string]interface{}); received[0].Type != webhooks.EventCalCreated || data["summary"] != "Dentist" {
+		t.Errorf("received = %+v", received)
+	}
+}
@@ -0,0 +1,48 @@
+package media
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Links makes signed, expiring URLs for stored blobs, so an attachment can
+// be texted back to its owner without serving the whole store to anyone
+// who learns a hash.
+type Links struct {
+	prefix string
+	key    []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewLinks creates Links under prefix (e.g.
+// https://nexus.example.com/media/), signed with secret and valid for ttl.
+func NewLinks(prefix, secret string, ttl time.Duration) *Links {
+	return &Links{prefix: prefix, key: []byte(secret), ttl: ttl, now: time.Now}
+}
+
+// URL returns a link to the blob with the given hash. Its exp and sig
+// query parameters are checked by Valid.
+func (l *Links) URL(sum string) string {
+	exp := strconv.FormatInt(l.now().Add(l.ttl).Unix(), 10)
+	return l.prefix + sum + "?exp=" + exp + "&sig=" + l.sign(sum, exp)
+}
+
+// Valid reports whether exp and sig, taken from a URL made by URL,
+// authorize sum and have not expired.
+func (l *Links) Valid(sum, exp, sig string) bool {
+	t, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || l.now().Unix() > t {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(l.sign(sum, exp)))
+}
+
+func (l *Links) sign(sum, exp string) string {
+	mac := hmac.New(sha256.New, l.key)
+	mac.Write([]byte(sum + "." + exp))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,170 @@
+// Package media downloads MMS attachments and keeps them in a
+// content-addressed blob directory.
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MaxSize caps how many bytes of a single attachment are stored. Twilio
+// limits inbound MMS to 5 MB in total.
+const MaxSize = 5 << 20
+
+// ErrTooLarge is returned when an attachment exceeds MaxSize.
+var ErrTooLarge = errors.New("media: attachment too large")
+
+// Fetcher downloads an attachment by URL.
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (io.ReadCloser, error)
+}
+
+// HTTPFetcher downloads attachments over HTTP, authenticating with the
+// Twilio account credentials when set (required if media URL auth is
+// enabled on the account).
+type HTTPFetcher struct {
+	accountSID, authToken string
+	http                  *http.Client
+}
+
+// NewHTTPFetcher creates an HTTPFetcher. accountSID and authToken may be
+// empty for public URLs.
+func NewHTTPFetcher(accountSID, authToken string) *HTTPFetcher {
+	return &HTTPFetcher{
+		accountSID: accountSID,
+		authToken:  authToken,
+		http:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Fetch implements Fetcher. Twilio media URLs redirect to a CDN; redirects
+// are followed.
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.accountSID != "" {
+		req.SetBasicAuth(f.accountSID, f.authToken)
+	}
+	resp, err := f.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetch %s: status %d", url, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// StubFetcher serves attachments from memory, keyed by URL. It is meant for
+// tests and local development.
+type StubFetcher struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewStubFetcher creates an empty StubFetcher.
+func NewStubFetcher() *StubFetcher {
+	return &StubFetcher{files: make(map[string][]byte)}
+}
+
+// Add makes data available at url.
+func (f *StubFetcher) Add(url string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[url] = data
+}
+
+// Fetch implements Fetcher.
+func (f *StubFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.files[url]
+	if !ok {
+		return nil, fmt.Errorf("fetch %s: not found", url)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Store keeps blobs on disk under their SHA-256, so identical attachments
+// are stored once.
+type Store struct {
+	dir string
+}
+
+// NewStore creates dir if needed and returns a Store rooted there.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create media dir: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Put copies r into the store and returns its hex SHA-256 and size.
+func (s *Store) Put(r io.Reader) (sum string, size int64, err error) {
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	h := sha256.New()
+	size, err = io.Copy(io.MultiWriter(tmp, h), io.LimitReader(r, MaxSize+1))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if size > MaxSize {
+		return "", 0, ErrTooLarge
+	}
+
+	sum = hex.EncodeToString(h.Sum(nil))
+	path := s.Path(sum)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, err
+	}
+	return sum, size, nil
+}
+
+// Path returns where the blob with the given hash is stored.
+func (s *Store) Path(sum string) string {
+	if len(sum) < 2 {
+		return filepath.Join(s.dir, sum)
+	}
+	return filepath.Join(s.dir, sum[:2], sum)
+}
+
+// Open opens a stored blob.
+func (s *Store) Open(sum string) (*os.File, error) {
+	if !validSum(sum) {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(s.Path(sum))
+}
+
+// validSum reports whether sum looks like a hex SHA-256, so user-supplied
+// hashes can't escape the store directory.
+func validSum(sum string) bool {
+	if len(sum) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(sum)
+	return err == nil
+}
@@ -0,0 +1,117 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStorePutDeduplicates(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+
+	sum1, size, err := s.Put(strings.NewReader("receipt"))
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if size != int64(len("receipt")) {
+		t.Errorf("size = %d", size)
+	}
+	// sha256("receipt")
+	if sum1 != "6f32860910ca0fb2a20c7fda143666b09dbf8db5238195c90a586fb542ff0cad" {
+		t.Errorf("unexpected sum %q", sum1)
+	}
+	sum2, _, err := s.Put(strings.NewReader("receipt"))
+	if err != nil || sum2 != sum1 {
+		t.Errorf("second put = %q, %v; want %q", sum2, err, sum1)
+	}
+
+	f, err := s.Open(sum1)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	data, _ := io.ReadAll(f)
+	if string(data) != "receipt" {
+		t.Errorf("stored data = %q", data)
+	}
+
+	entries, _ := os.ReadDir(s.dir)
+	if len(entries) != 1 {
+		t.Errorf("expected one shard dir and no temp files, got %d entries", len(entries))
+	}
+}
+
+func TestStoreRejectsLargeAndBadSums(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	if _, _, err := s.Put(bytes.NewReader(make([]byte, MaxSize+1))); err != ErrTooLarge {
+		t.Errorf("put oversized = %v, want ErrTooLarge", err)
+	}
+	if _, err := s.Open("../../etc/passwd"); !os.IsNotExist(err) {
+		t.Errorf("open traversal = %v, want not exist", err)
+	}
+}
+
+func TestHTTPFetcher(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "AC123" || pass != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("jpeg bytes"))
+	}))
+	defer srv.Close()
+
+	rc, err := NewHTTPFetcher("AC123", "token").Fetch(context.Background(), srv.URL+"/Media/ME1")
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	defer rc.Close()
+	if data, _ := io.ReadAll(rc); string(data) != "jpeg bytes" {
+		t.Errorf("fetched %q", data)
+	}
+
+	if _, err := NewHTTPFetcher("", "").Fetch(context.Background(), srv.URL); err == nil {
+		t.Error("expected error without credentials")
+	}
+}
+
+func TestLinks(t *testing.T) {
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	l := NewLinks("https://nexus.example.com/media/", "secret", time.Hour)
+	l.now = func() time.Time { return now }
+
+	u, err := url.Parse(l.URL("abc123"))
+	if err != nil || !strings.HasPrefix(u.String(), "https://nexus.example.com/media/abc123?") {
+		t.Fatalf("URL = %v, %v", u, err)
+	}
+	exp, sig := u.Query().Get("exp"), u.Query().Get("sig")
+	if !l.Valid("abc123", exp, sig) {
+		t.Error("fresh link rejected")
+	}
+	if l.Valid("def456", exp, sig) {
+		t.Error("link accepted for another blob")
+	}
+	if NewLinks("", "other", time.Hour).Valid("abc123", exp, sig) {
+		t.Error("link accepted with another secret")
+	}
+	if l.Valid("abc123", exp, "") || l.Valid("abc123", "", sig) {
+		t.Error("link accepted without its signature")
+	}
+	now = now.Add(2 * time.Hour)
+	if l.Valid("abc123", exp, sig) {
+		t.Error("expired link accepted")
+	}
+}
@@ -0,0 +1,207 @@
+// Package portalclient is a small HTTP client for the portal's internal
+// service-to-service API (services/portal, /internal routes).
+package portalclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client talks to a portal server using the shared internal API token.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// New creates a Client for the portal at baseURL. token must match the
+// portal's INTERNAL_API_TOKEN.
+func New(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// User is the account view the portal shares with other services.
+type User struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+}
+
+// APIError is a non-2xx response from the portal.
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("portal: status %d: %s", e.Status, e.Message)
+}
+
+// UserByPhoneHash returns the account whose phone hashes to hash
+// (identity.PhoneHash), or nil if there is none.
+func (c *Client) UserByPhoneHash(ctx context.Context, hash string) (*User, error) {
+	var u User
+	err := c.do(ctx, http.MethodGet, "/internal/users/by-phone-hash/"+url.PathEscape(hash), nil, &u)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("look up user by phone hash: %w", err)
+	}
+	return &u, nil
+}
+
+// CreateMagicLink creates a one-time /auth/magic login link for the account
+// whose phone hashes to hash. It returns "" if there is no such account.
+func (c *Client) CreateMagicLink(ctx context.Context, hash string) (string, error) {
+	var out struct {
+		Link string `json:"link"`
+	}
+	err := c.do(ctx, http.MethodPost, "/internal/magic-links", map[string]string{"phone_hash": hash}, &out)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("create magic link: %w", err)
+	}
+	return out.Link, nil
+}
+
+// AnswerRelay is a community Q&A answer waiting to be texted to the
+// person who asked.
+type AnswerRelay struct {
+	AnswerID string `json:"answer_id"`
+	Phone    string `json:"phone"`
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+	Username string `json:"username"`
+}
+
+// Ask posts a question to the circle of the account whose phone hashes to
+// hash and returns how many people are in that circle. found is false if
+// there is no such account.
+func (c *Client) Ask(ctx context.Context, hash, body string) (circleSize int, found bool, err error) {
+	var out struct {
+		CircleSize int `json:"circle_size"`
+	}
+	err = c.do(ctx, http.MethodPost, "/internal/questions", map[string]string{"phone_hash": hash, "body": body}, &out)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("ask question: %w", err)
+	}
+	return out.CircleSize, true, nil
+}
+
+// AnswerRelays returns answers not yet texted to their askers, oldest
+// first.
+func (c *Client) AnswerRelays(ctx context.Context) ([]AnswerRelay, error) {
+	var list []AnswerRelay
+	if err := c.do(ctx, http.MethodGet, "/internal/answer-relays", nil, &list); err != nil {
+		return nil, fmt.Errorf("list answer relays: %w", err)
+	}
+	return list, nil
+}
+
+// AnswerRelayed records that an answer has been texted to its asker.
+func (c *Client) AnswerRelayed(ctx context.Context, answerID string) error {
+	if err := c.do(ctx, http.MethodPost, "/internal/answer-relays/"+url.PathEscape(answerID)+"/done", nil, nil); err != nil {
+		return fmt.Errorf("mark answer relayed: %w", err)
+	}
+	return nil
+}
+
+// EscalationRelay is a person's answer to an escalated text, waiting to
+// be texted to the sender.
+type EscalationRelay struct {
+	EscalationID string `json:"escalation_id"`
+	Phone        string `json:"phone"`
+	Answer       string `json:"answer"`
+}
+
+// Escalate parks an inbound text in the portal's escalation queue for a
+// person to answer. phone is where the answer will be texted; hash
+// attributes the text to an account, if one matches. It returns the
+// escalation's ID.
+func (c *Client) Escalate(ctx context.Context, phone, hash, body, reason string) (string, error) {
+	var out struct {
+		ID string `json:"id"`
+	}
+	req := map[string]string{"phone": phone, "phone_hash": hash, "body": body, "reason": reason}
+	if err := c.do(ctx, http.MethodPost, "/internal/escalations", req, &out); err != nil {
+		return "", fmt.Errorf("create escalation: %w", err)
+	}
+	return out.ID, nil
+}
+
+// EscalationRelays returns answered escalations not yet texted to their
+// senders, oldest first.
+func (c *Client) EscalationRelays(ctx context.Context) ([]EscalationRelay, error) {
+	var list []EscalationRelay
+	if err := c.do(ctx, http.MethodGet, "/internal/escalation-relays", nil, &list); err != nil {
+		return nil, fmt.Errorf("list escalation relays: %w", err)
+	}
+	return list, nil
+}
+
+// EscalationRelayed records that an escalation's answer has been texted to
+// the sender.
+func (c *Client) EscalationRelayed(ctx context.Context, escalationID string) error {
+	if err := c.do(ctx, http.MethodPost, "/internal/escalation-relays/"+url.PathEscape(escalationID)+"/done", nil, nil); err != nil {
+		return fmt.Errorf("mark escalation relayed: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		return &APIError{Status: resp.StatusCode, Message: e.Error}
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,126 @@
+package portalclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUserByPhoneHash(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /internal/users/by-phone-hash/{hash}", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.PathValue("hash") != "abc123" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "User not found."})
+			return
+		}
+		json.NewEncoder(w).Encode(User{ID: "u-1", Username: "texter", Name: "Tex Ter", Role: "user"})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := New(srv.URL, "secret")
+
+	u, err := c.UserByPhoneHash(ctx, "abc123")
+	if err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+	if u == nil || u.ID != "u-1" || u.Name != "Tex Ter" {
+		t.Errorf("unexpected user: %+v", u)
+	}
+
+	u, err = c.UserByPhoneHash(ctx, "unknown")
+	if err != nil || u != nil {
+		t.Errorf("unknown hash: got %+v, %v; want nil, nil", u, err)
+	}
+
+	_, err = New(srv.URL, "wrong").UserByPhoneHash(ctx, "abc123")
+	if err == nil {
+		t.Error("expected error for bad token")
+	}
+}
+
+func TestCreateMagicLink(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /internal/magic-links", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["phone_hash"] != "abc123" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"link": "https://portal.example.com/auth/magic?token=t"})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := New(srv.URL, "secret")
+
+	link, err := c.CreateMagicLink(ctx, "abc123")
+	if err != nil || link != "https://portal.example.com/auth/magic?token=t" {
+		t.Errorf("CreateMagicLink = %q, %v", link, err)
+	}
+	link, err = c.CreateMagicLink(ctx, "unknown")
+	if err != nil || link != "" {
+		t.Errorf("unknown hash: got %q, %v; want empty, nil", link, err)
+	}
+}
+
+func TestAsk(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /internal/questions", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["phone_hash"] != "abc123" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req["body"] != "Anyone have a ladder?" {
+			t.Errorf("body = %q", req["body"])
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "q-1", "circle_size": 4})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := New(srv.URL, "secret")
+
+	n, found, err := c.Ask(ctx, "abc123", "Anyone have a ladder?")
+	if err != nil || !found || n != 4 {
+		t.Errorf("Ask = %d, %v, %v; want 4, true, nil", n, found, err)
+	}
+	if _, found, err := c.Ask(ctx, "unknown", "hi"); err != nil || found {
+		t.Errorf("unknown hash: got %v, %v; want false, nil", found, err)
+	}
+}
+
+func TestEscalate(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /internal/escalations", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["phone"] != "15555550100" || req["phone_hash"] != "abc123" || req["reason"] != `keyword "lawyer"` {
+			t.Errorf("request = %v", req)
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"id": "esc-1"})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	id, err := New(srv.URL, "secret").Escalate(context.Background(), "15555550100", "abc123", "need a lawyer", `keyword "lawyer"`)
+	if err != nil || id != "esc-1" {
+		t.Errorf("Escalate = %q, %v; want esc-1, nil", id, err)
+	}
+}
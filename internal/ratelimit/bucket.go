@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// BucketState is a key's token bucket as stored between events.
+type BucketState struct {
+	Tokens    float64
+	UpdatedAt time.Time
+	// NotifiedAt is when the key was last told it is being throttled.
+	NotifiedAt time.Time
+}
+
+// BucketStore persists bucket state so limits survive restarts.
+// *database.DB implements it; MemoryStore keeps state in memory.
+type BucketStore interface {
+	// LoadBucket returns the key's state, or nil if it has none.
+	LoadBucket(key string) (*BucketState, error)
+	SaveBucket(key string, s *BucketState) error
+}
+
+// Decision is the outcome of TokenBucket.Take.
+type Decision int
+
+const (
+	// Allow means the event is within the limit.
+	Allow Decision = iota
+	// Notify means the event is over the limit and the sender should be
+	// told so; at most once per throttling window.
+	Notify
+	// Drop means the event is over the limit and the sender has already
+	// been told.
+	Drop
+)
+
+// TokenBucket limits events per key with a token bucket: each key holds up
+// to burst tokens, an event spends one, and one token is refilled every
+// refill interval.
+type TokenBucket struct {
+	burst  float64
+	refill time.Duration
+	store  BucketStore
+
+	mu sync.Mutex
+}
+
+// NewTokenBucket creates a limiter that allows bursts of burst events per
+// key and one more event every refill.
+func NewTokenBucket(burst int, refill time.Duration, store BucketStore) *TokenBucket {
+	return &TokenBucket{burst: float64(burst), refill: refill, store: store}
+}
+
+// window is how long an empty bucket takes to refill completely. A
+// throttled key gets at most one notice per window.
+func (b *TokenBucket) window() time.Duration {
+	return time.Duration(b.burst) * b.refill
+}
+
+// Take spends a token for key at now and reports whether the event is
+// allowed, and if not, whether the sender should be notified.
+func (b *TokenBucket) Take(key string, now time.Time) (Decision, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s, err := b.store.LoadBucket(key)
+	if err != nil {
+		return Allow, err
+	}
+	if s == nil {
+		s = &BucketState{Tokens: b.burst, UpdatedAt: now}
+	}
+	if elapsed := now.Sub(s.UpdatedAt); elapsed > 0 {
+		s.Tokens = min(b.burst, s.Tokens+float64(elapsed)/float64(b.refill))
+		s.UpdatedAt = now
+	}
+
+	d := Allow
+	switch {
+	case s.Tokens >= 1:
+		s.Tokens--
+	case s.NotifiedAt.IsZero() || now.Sub(s.NotifiedAt) >= b.window():
+		d = Notify
+		s.NotifiedAt = now
+	default:
+		d = Drop
+	}
+	return d, b.store.SaveBucket(key, s)
+}
+
+// MemoryStore is a BucketStore that keeps state in memory.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]BucketState
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]BucketState)}
+}
+
+// LoadBucket implements BucketStore.
+func (m *MemoryStore) LoadBucket(key string) (*BucketState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.buckets[key]
+	if !ok {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+// SaveBucket implements BucketStore.
+func (m *MemoryStore) SaveBucket(key string, s *BucketState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.buckets[key] = *s
+	return nil
+}
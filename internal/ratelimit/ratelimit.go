@@ -0,0 +1,49 @@
+// Package ratelimit provides per-key rate limits, e.g. per phone number: an
+// in-memory sliding window and a token bucket whose state can be persisted.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Window allows at most limit events per key within any sliding window of
+// the given period.
+type Window struct {
+	limit  int
+	period time.Duration
+
+	mu     sync.Mutex
+	events map[string][]time.Time
+}
+
+// NewWindow creates a limiter allowing limit events per key per period.
+func NewWindow(limit int, period time.Duration) *Window {
+	return &Window{
+		limit:  limit,
+		period: period,
+		events: make(map[string][]time.Time),
+	}
+}
+
+// Allow reports whether an event for key at now is within the limit and, if
+// so, records it. Rejected events do not count against the key.
+func (w *Window) Allow(key string, now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	cutoff := now.Add(-w.period)
+	recent := w.events[key][:0]
+	for _, t := range w.events[key] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= w.limit {
+		w.events[key] = recent
+		return false
+	}
+	w.events[key] = append(recent, now)
+	return true
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	w := NewWindow(2, time.Hour)
+	start := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		key   string
+		after time.Duration
+		want  bool
+	}{
+		{"alice", 0, true},
+		{"alice", time.Minute, true},
+		{"alice", 2 * time.Minute, false}, // third within the hour
+		{"bob", 2 * time.Minute, true},    // keys are independent
+		{"alice", 59 * time.Minute, false},
+		{"alice", 60*time.Minute + 30*time.Second, true}, // first event has expired
+		{"alice", 60*time.Minute + 45*time.Second, false},
+	}
+	for _, tt := range tests {
+		if got := w.Allow(tt.key, start.Add(tt.after)); got != tt.want {
+			t.Errorf("Allow(%q, +%v) = %v, want %v", tt.key, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	b := NewTokenBucket(2, 10*time.Second, NewMemoryStore())
+	start := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		key   string
+		after time.Duration
+		want  Decision
+	}{
+		{"alice", 0, Allow},
+		{"alice", time.Second, Allow},
+		{"alice", 2 * time.Second, Notify}, // burst spent
+		{"alice", 3 * time.Second, Drop},   // already told
+		{"bob", 3 * time.Second, Allow},    // keys are independent
+		{"alice", 9 * time.Second, Drop},   // 0.9 tokens refilled
+		{"alice", 10 * time.Second, Allow}, // one token refilled
+		{"alice", 11 * time.Second, Drop},  // still within the notice window
+		{"alice", 40 * time.Second, Allow}, // refills cap at burst
+		{"alice", 41 * time.Second, Allow},
+		{"alice", 42 * time.Second, Notify}, // a new window
+		{"alice", 43 * time.Second, Drop},
+	}
+	for _, tt := range tests {
+		got, err := b.Take(tt.key, start.Add(tt.after))
+		if err != nil {
+			t.Fatalf("Take: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("Take(%q, +%v) = %v, want %v", tt.key, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestTokenBucketNoticeWindow(t *testing.T) {
+	// A key that never lets the bucket refill still hears about it once
+	// per window (burst * refill = 20s).
+	b := NewTokenBucket(2, 10*time.Second, NewMemoryStore())
+	start := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	var notices int
+	for i := 0; i < 60; i++ {
+		// One message a second spends every refilled token.
+		d, err := b.Take("alice", start.Add(time.Duration(i)*time.Second))
+		if err != nil {
+			t.Fatalf("Take: %v", err)
+		}
+		if d == Notify {
+			notices++
+		}
+	}
+	if notices != 3 {
+		t.Errorf("got %d notices in 60s, want at most one per window", notices)
+	}
+}
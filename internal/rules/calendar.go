@@ -0,0 +1,86 @@
+package rules
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/calclient"
+)
+
+// DefaultCalendarLead is how long before an event starts it triggers
+// cal.event.starting rules.
+const DefaultCalendarLead = 15 * time.Minute
+
+// FeedRef is a phone's nexus-cal reminder feed.
+type FeedRef struct {
+	Phone  string
+	FeedID string
+}
+
+// EventLister lists the events in a nexus-cal feed. *calclient.Client
+// implements it.
+type EventLister interface {
+	ListEvents(ctx context.Context, feedID string) ([]calclient.Event, error)
+}
+
+// CalendarWatcher polls reminder feeds and fires cal.event.starting once
+// for every timed event that starts within Lead.
+type CalendarWatcher struct {
+	engine   *Engine
+	calendar EventLister
+	feeds    func() ([]FeedRef, error)
+	lead     time.Duration
+}
+
+// NewCalendarWatcher creates a watcher over the feeds returned by feeds.
+func NewCalendarWatcher(engine *Engine, calendar EventLister, feeds func() ([]FeedRef, error), lead time.Duration) *CalendarWatcher {
+	if lead <= 0 {
+		lead = DefaultCalendarLead
+	}
+	return &CalendarWatcher{engine: engine, calendar: calendar, feeds: feeds, lead: lead}
+}
+
+// Check fires events starting between now and now+lead that haven't been
+// fired before. Feeds that fail to load are logged and skipped.
+func (w *CalendarWatcher) Check(ctx context.Context, now time.Time) error {
+	feeds, err := w.feeds()
+	if err != nil {
+		return err
+	}
+	for _, f := range feeds {
+		events, err := w.calendar.ListEvents(ctx, f.FeedID)
+		if err != nil {
+			log.Printf("error listing events of feed %s: %v", f.FeedID, err)
+			continue
+		}
+		for _, e := range events {
+			if e.AllDay || e.Start.Before(now) || e.Start.After(now.Add(w.lead)) {
+				continue
+			}
+			key := EventCalStarting + ":" + e.ID + ":" + e.Start.UTC().Format(time.RFC3339)
+			fresh, err := w.engine.store.MarkFired(key, now)
+			if err != nil {
+				return err
+			}
+			if !fresh {
+				continue
+			}
+			ev := &Event{
+				Type:  EventCalStarting,
+				Phone: f.Phone,
+				Data: map[string]string{
+					"event_id": e.ID,
+					"summary":  e.Summary,
+					"start":    e.Start.In(w.engine.deps.Location).Format("3:04 PM"),
+					"location": e.Location,
+				},
+				At: now,
+			}
+			if _, err := w.engine.Fire(ctx, ev); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
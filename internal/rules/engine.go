@@ -0,0 +1,289 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/calclient"
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/internal/webhooks"
+	"github.com/jredh-dev/nexus/pkg/when"
+)
+
+// Store persists rules and their run history. *database.DB implements it.
+type Store interface {
+	// EnabledRules returns the enabled rules triggered by event type t.
+	EnabledRules(t string) ([]*Rule, error)
+	RecordRun(run *Run) error
+	// MarkFired records that the event identified by key has been fired
+	// and reports whether it was new, so polled sources fire only once.
+	MarkFired(key string, at time.Time) (bool, error)
+}
+
+// Calendar creates nexus-cal events. *calclient.Client implements it.
+type Calendar interface {
+	CreateEvent(ctx context.Context, e *calclient.Event) (*calclient.Event, error)
+}
+
+// Deps are the services actions use. An action whose dependency is nil
+// fails with an error recorded in the run.
+type Deps struct {
+	Sender   sms.Sender
+	Calendar Calendar
+	// FeedForPhone returns the nexus-cal reminder feed of a phone, or ""
+	// if it has none. Used by create_cal_event actions without a FeedID.
+	FeedForPhone func(phone string) (string, error)
+	HTTP         *http.Client
+	Location     *time.Location
+}
+
+// Engine matches events against stored rules and runs their actions.
+type Engine struct {
+	store Store
+	deps  Deps
+	now   func() time.Time
+}
+
+// NewEngine creates an Engine.
+func NewEngine(store Store, deps Deps) *Engine {
+	if deps.HTTP == nil {
+		deps.HTTP = &http.Client{Timeout: 10 * time.Second}
+	}
+	if deps.Location == nil {
+		deps.Location = time.UTC
+	}
+	return &Engine{store: store, deps: deps, now: time.Now}
+}
+
+// Fire runs every enabled rule that matches ev and returns the runs. A
+// failing rule doesn't stop the others; its error is recorded in its run.
+func (e *Engine) Fire(ctx context.Context, ev *Event) ([]*Run, error) {
+	if ev.At.IsZero() {
+		ev.At = e.now().UTC()
+	}
+	rules, err := e.store.EnabledRules(ev.Type)
+	if err != nil {
+		return nil, fmt.Errorf("load rules: %w", err)
+	}
+
+	var runs []*Run
+	for _, r := range rules {
+		if !Matches(r, ev) {
+			continue
+		}
+		run := e.run(ctx, r, ev)
+		if err := e.store.RecordRun(run); err != nil {
+			log.Printf("error recording run of rule %d: %v", r.ID, err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// Matches reports whether ev satisfies r's trigger and conditions.
+func Matches(r *Rule, ev *Event) bool {
+	if r.Trigger.Event != ev.Type {
+		return false
+	}
+	if r.Trigger.Pattern != "" {
+		re, err := regexp.Compile("(?i)" + r.Trigger.Pattern)
+		if err != nil || !re.MatchString(ev.Field("body")) {
+			return false
+		}
+	}
+	for _, c := range r.Conditions {
+		if !c.holds(ev) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Condition) holds(ev *Event) bool {
+	v := ev.Field(c.Field)
+	switch c.Op {
+	case OpEquals:
+		return strings.EqualFold(v, c.Value)
+	case OpNotEquals:
+		return !strings.EqualFold(v, c.Value)
+	case OpContains:
+		return strings.Contains(strings.ToLower(v), strings.ToLower(c.Value))
+	case OpPrefix:
+		return strings.HasPrefix(strings.ToLower(v), strings.ToLower(c.Value))
+	case OpMatches:
+		re, err := regexp.Compile(c.Value)
+		return err == nil && re.MatchString(v)
+	}
+	return false
+}
+
+// run executes r's actions in order, stopping at the first failure.
+func (e *Engine) run(ctx context.Context, r *Rule, ev *Event) *Run {
+	run := &Run{RuleID: r.ID, Event: *ev, Status: RunOK, StartedAt: e.now().UTC()}
+	var results []string
+	for i, a := range r.Actions {
+		result, err := e.do(ctx, &a, ev)
+		if err != nil {
+			run.Status = RunError
+			results = append(results, fmt.Sprintf("%d %s: %v", i+1, a.Type, err))
+			break
+		}
+		results = append(results, fmt.Sprintf("%d %s: %s", i+1, a.Type, result))
+	}
+	run.Detail = strings.Join(results, "\n")
+	run.DurationMS = e.now().UTC().Sub(run.StartedAt).Milliseconds()
+	if run.Status == RunError {
+		log.Printf("rule %d (%s) failed on %s: %s", r.ID, r.Name, ev.Type, run.Detail)
+	}
+	return run
+}
+
+// do performs a single action and describes what it did.
+func (e *Engine) do(ctx context.Context, a *Action, ev *Event) (string, error) {
+	switch a.Type {
+	case ActionSendSMS:
+		return e.sendSMS(ctx, a, ev)
+	case ActionCalEvent:
+		return e.createCalEvent(ctx, a, ev)
+	case ActionWebhook:
+		return e.callWebhook(ctx, a, ev)
+	}
+	return "", fmt.Errorf("unknown action type %q", a.Type)
+}
+
+func (e *Engine) sendSMS(ctx context.Context, a *Action, ev *Event) (string, error) {
+	if e.deps.Sender == nil {
+		return "", fmt.Errorf("no SMS sender configured")
+	}
+	to, err := render(a.To, ev)
+	if err != nil {
+		return "", err
+	}
+	if to == "" {
+		to = ev.Phone
+	}
+	if to == "" {
+		return "", fmt.Errorf("no recipient")
+	}
+	if !strings.HasPrefix(to, "+") {
+		to = "+" + to
+	}
+	body, err := render(a.Body, ev)
+	if err != nil {
+		return "", err
+	}
+	rcpt, err := e.deps.Sender.Send(ctx, &sms.Outbound{To: to, Body: body})
+	if err != nil {
+		return "", err
+	}
+	return "sent " + rcpt.SID + " to " + to, nil
+}
+
+func (e *Engine) createCalEvent(ctx context.Context, a *Action, ev *Event) (string, error) {
+	if e.deps.Calendar == nil {
+		return "", fmt.Errorf("no calendar configured")
+	}
+	feedID := a.FeedID
+	if feedID == "" && e.deps.FeedForPhone != nil && ev.Phone != "" {
+		var err error
+		if feedID, err = e.deps.FeedForPhone(ev.Phone); err != nil {
+			return "", fmt.Errorf("lookup feed: %w", err)
+		}
+	}
+	if feedID == "" {
+		return "", fmt.Errorf("no feed_id and the event's phone has no calendar")
+	}
+
+	summary, err := render(a.Summary, ev)
+	if err != nil {
+		return "", err
+	}
+	phrase, err := render(a.When, ev)
+	if err != nil {
+		return "", err
+	}
+	parser := when.New(e.deps.Location)
+	parser.Now = e.now
+	at, err := parser.Parse(phrase)
+	if err != nil {
+		return "", fmt.Errorf("when %q: %w", phrase, err)
+	}
+
+	created, err := e.deps.Calendar.CreateEvent(ctx, &calclient.Event{
+		FeedID:      feedID,
+		Summary:     summary,
+		Description: "Created by a nexus rule.",
+		Start:       at.Time,
+		Categories:  "automation",
+	})
+	if err != nil {
+		return "", err
+	}
+	return "created event " + created.ID + " at " + at.Time.Format(time.RFC3339), nil
+}
+
+func (e *Engine) callWebhook(ctx context.Context, a *Action, ev *Event) (string, error) {
+	if a.Secret == "" {
+		return "", fmt.Errorf("webhook has no signing secret")
+	}
+	url, err := render(a.URL, ev)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "nexus-rules")
+	req.Header.Set(webhooks.HeaderEvent, ev.Type)
+	req.Header.Set(webhooks.HeaderSignature, webhooks.Sign(a.Secret, e.now(), payload))
+
+	resp, err := e.deps.HTTP.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return url + " returned " + resp.Status, nil
+}
+
+// parseTemplate parses an action's text field.
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("").Option("missingkey=zero").Parse(text)
+}
+
+// render evaluates an action template against the event's fields: .type,
+// .phone and every Data key.
+func render(text string, ev *Event) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	t, err := parseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	fields := map[string]string{"type": ev.Type, "phone": ev.Phone}
+	for k, v := range ev.Data {
+		fields[k] = v
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, fields); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
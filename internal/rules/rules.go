@@ -0,0 +1,205 @@
+// Package rules is nexus's IFTTT-style automation engine. A Rule listens
+// for one kind of Event (an inbound text, a calendar event about to start,
+// a portal signup, a giveaway claim), checks optional Conditions against
+// it and runs its Actions (send a text, create a calendar event, call a
+// webhook). Every execution is recorded as a Run.
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/events"
+)
+
+// Event types that can trigger rules (see package events for their data).
+const (
+	EventSMSReceived     = events.SMSReceived
+	EventCalStarting     = events.CalStarting
+	EventPortalSignup    = events.PortalSignup
+	EventGiveawayClaimed = events.GiveawayClaimed
+)
+
+// EventTypes lists every event type rules can trigger on.
+var EventTypes = []string{EventSMSReceived, EventCalStarting, EventPortalSignup, EventGiveawayClaimed}
+
+// Event is something that happened. Phone is the normalized number of the
+// person involved, if any; Data holds type-specific fields.
+type Event struct {
+	Type  string            `json:"type"`
+	Phone string            `json:"phone,omitempty"`
+	Data  map[string]string `json:"data,omitempty"`
+	At    time.Time         `json:"at"`
+}
+
+// Field returns an event field by name: "type", "phone" or a Data key.
+func (e *Event) Field(name string) string {
+	switch name {
+	case "type":
+		return e.Type
+	case "phone":
+		return e.Phone
+	}
+	return e.Data[name]
+}
+
+// Trigger selects the events a rule reacts to. Pattern is a regular
+// expression matched case-insensitively against the message body of
+// sms.received events; empty matches every message.
+type Trigger struct {
+	Event   string `json:"event"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// Condition operators.
+const (
+	OpEquals    = "eq"
+	OpNotEquals = "neq"
+	OpContains  = "contains"
+	OpPrefix    = "prefix"
+	OpMatches   = "matches" // regular expression
+)
+
+// Condition compares an event field (see Event.Field) with Value.
+type Condition struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// Action types.
+const (
+	ActionSendSMS  = "send_sms"
+	ActionCalEvent = "create_cal_event"
+	ActionWebhook  = "webhook"
+)
+
+// Action is one step of a rule. Text fields are Go templates
+// (text/template) evaluated against the event's fields, e.g.
+// "New signup: {{.username}}".
+//
+//   - send_sms: texts Body to To (default: the event's phone).
+//   - create_cal_event: adds Summary at When ("tomorrow 9am", "in 2
+//     hours") to FeedID, or to the event phone's reminder feed.
+//   - webhook: POSTs the event as JSON to URL, signed with Secret the way
+//     webhook deliveries are (X-Nexus-Signature, see webhooks.Sign).
+type Action struct {
+	Type    string `json:"type"`
+	To      string `json:"to,omitempty"`
+	Body    string `json:"body,omitempty"`
+	Summary string `json:"summary,omitempty"`
+	When    string `json:"when,omitempty"`
+	FeedID  string `json:"feed_id,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Secret  string `json:"secret,omitempty"`
+}
+
+// Rule is an automation: when Trigger fires and every Condition holds,
+// run Actions in order.
+type Rule struct {
+	ID         int64       `json:"id"`
+	Name       string      `json:"name"`
+	Enabled    bool        `json:"enabled"`
+	Trigger    Trigger     `json:"trigger"`
+	Conditions []Condition `json:"conditions,omitempty"`
+	Actions    []Action    `json:"actions"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// Validate checks that a rule is well-formed before it is stored, and
+// renames an aliased trigger event (see events.Canonical).
+func (r *Rule) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	r.Trigger.Event = events.Canonical(r.Trigger.Event)
+	if !KnownEvent(r.Trigger.Event) {
+		return fmt.Errorf("unknown trigger event %q", r.Trigger.Event)
+	}
+	if r.Trigger.Pattern != "" {
+		if r.Trigger.Event != EventSMSReceived {
+			return errors.New("trigger pattern only applies to sms.received")
+		}
+		if _, err := regexp.Compile(r.Trigger.Pattern); err != nil {
+			return fmt.Errorf("trigger pattern: %w", err)
+		}
+	}
+	for i, c := range r.Conditions {
+		if c.Field == "" {
+			return fmt.Errorf("condition %d: field is required", i+1)
+		}
+		switch c.Op {
+		case OpEquals, OpNotEquals, OpContains, OpPrefix:
+		case OpMatches:
+			if _, err := regexp.Compile(c.Value); err != nil {
+				return fmt.Errorf("condition %d: %w", i+1, err)
+			}
+		default:
+			return fmt.Errorf("condition %d: unknown op %q", i+1, c.Op)
+		}
+	}
+	if len(r.Actions) == 0 {
+		return errors.New("at least one action is required")
+	}
+	for i, a := range r.Actions {
+		if err := a.validate(); err != nil {
+			return fmt.Errorf("action %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (a *Action) validate() error {
+	switch a.Type {
+	case ActionSendSMS:
+		if a.Body == "" {
+			return errors.New("send_sms needs a body")
+		}
+	case ActionCalEvent:
+		if a.Summary == "" || a.When == "" {
+			return errors.New("create_cal_event needs a summary and when")
+		}
+	case ActionWebhook:
+		if a.URL == "" {
+			return errors.New("webhook needs a url")
+		}
+	default:
+		return fmt.Errorf("unknown action type %q", a.Type)
+	}
+	for _, text := range []string{a.To, a.Body, a.Summary, a.When, a.URL} {
+		if _, err := parseTemplate(text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// KnownEvent reports whether t is one of EventTypes.
+func KnownEvent(t string) bool {
+	for _, e := range EventTypes {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Run statuses.
+const (
+	RunOK    = "ok"
+	RunError = "error"
+)
+
+// Run records one execution of a rule.
+type Run struct {
+	ID         int64     `json:"id"`
+	RuleID     int64     `json:"rule_id"`
+	Event      Event     `json:"event"`
+	Status     string    `json:"status"`
+	Detail     string    `json:"detail,omitempty"` // per-action results or the error
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+}
@@ -0,0 +1,225 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/calclient"
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/internal/webhooks"
+)
+
+// memStore is an in-memory Store.
+type memStore struct {
+	mu    sync.Mutex
+	rules []*Rule
+	runs  []*Run
+	fired map[string]bool
+}
+
+func (m *memStore) EnabledRules(t string) ([]*Rule, error) {
+	var out []*Rule
+	for _, r := range m.rules {
+		if r.Enabled && r.Trigger.Event == t {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (m *memStore) RecordRun(run *Run) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs = append(m.runs, run)
+	return nil
+}
+
+func (m *memStore) MarkFired(key string, at time.Time) (bool, error) {
+	if m.fired == nil {
+		m.fired = map[string]bool{}
+	}
+	if m.fired[key] {
+		return false, nil
+	}
+	m.fired[key] = true
+	return true, nil
+}
+
+type fakeCal struct {
+	created []*calclient.Event
+	events  map[string][]calclient.Event
+}
+
+func (f *fakeCal) CreateEvent(ctx context.Context, e *calclient.Event) (*calclient.Event, error) {
+	f.created = append(f.created, e)
+	return &calclient.Event{ID: "evt-1"}, nil
+}
+
+func (f *fakeCal) ListEvents(ctx context.Context, feedID string) ([]calclient.Event, error) {
+	return f.events[feedID], nil
+}
+
+func TestValidate(t *testing.T) {
+	sendHi := []Action{{Type: ActionSendSMS, Body: "hi"}}
+	tests := []struct {
+		name string
+		rule Rule
+		err  string
+	}{
+		{"ok", Rule{Name: "r", Trigger: Trigger{Event: EventSMSReceived, Pattern: "^pizza"}, Actions: sendHi}, ""},
+		{"no name", Rule{Trigger: Trigger{Event: EventSMSReceived}, Actions: sendHi}, "name is required"},
+		{"bad event", Rule{Name: "r", Trigger: Trigger{Event: "nope"}, Actions: sendHi}, "unknown trigger event"},
+		{"pattern on signup", Rule{Name: "r", Trigger: Trigger{Event: EventPortalSignup, Pattern: "x"}, Actions: sendHi}, "only applies"},
+		{"bad pattern", Rule{Name: "r", Trigger: Trigger{Event: EventSMSReceived, Pattern: "("}, Actions: sendHi}, "trigger pattern"},
+		{"bad op", Rule{Name: "r", Trigger: Trigger{Event: EventSMSReceived}, Conditions: []Condition{{Field: "body", Op: "like"}}, Actions: sendHi}, "unknown op"},
+		{"no actions", Rule{Name: "r", Trigger: Trigger{Event: EventSMSReceived}}, "at least one action"},
+		{"webhook without url", Rule{Name: "r", Trigger: Trigger{Event: EventSMSReceived}, Actions: []Action{{Type: ActionWebhook}}}, "needs a url"},
+		{"bad template", Rule{Name: "r", Trigger: Trigger{Event: EventSMSReceived}, Actions: []Action{{Type: ActionSendSMS, Body: "{{.body"}}}, "action 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if tt.err == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	ev := &Event{Type: EventSMSReceived, Phone: "15555555555", Data: map[string]string{"body": "Pizza tonight?", "command": "PIZZA"}}
+	tests := []struct {
+		name string
+		rule Rule
+		want bool
+	}{
+		{"any message", Rule{Trigger: Trigger{Event: EventSMSReceived}}, true},
+		{"other event", Rule{Trigger: Trigger{Event: EventPortalSignup}}, false},
+		{"pattern ignores case", Rule{Trigger: Trigger{Event: EventSMSReceived, Pattern: "^pizza"}}, true},
+		{"pattern misses", Rule{Trigger: Trigger{Event: EventSMSReceived, Pattern: "^tacos"}}, false},
+		{"eq", Rule{Trigger: Trigger{Event: EventSMSReceived}, Conditions: []Condition{{Field: "phone", Op: OpEquals, Value: "15555555555"}}}, true},
+		{"neq", Rule{Trigger: Trigger{Event: EventSMSReceived}, Conditions: []Condition{{Field: "phone", Op: OpNotEquals, Value: "15555555555"}}}, false},
+		{"contains", Rule{Trigger: Trigger{Event: EventSMSReceived}, Conditions: []Condition{{Field: "body", Op: OpContains, Value: "TONIGHT"}}}, true},
+		{"prefix", Rule{Trigger: Trigger{Event: EventSMSReceived}, Conditions: []Condition{{Field: "command", Op: OpPrefix, Value: "piz"}}}, true},
+		{"matches", Rule{Trigger: Trigger{Event: EventSMSReceived}, Conditions: []Condition{{Field: "body", Op: OpMatches, Value: `\?$`}}}, true},
+		{"all conditions", Rule{Trigger: Trigger{Event: EventSMSReceived}, Conditions: []Condition{
+			{Field: "body", Op: OpContains, Value: "pizza"},
+			{Field: "missing", Op: OpEquals, Value: "x"},
+		}}, false},
+	}
+	for _, tt := range tests {
+		if got := Matches(&tt.rule, ev); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFire(t *testing.T) {
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	var hooked map[string]interface{}
+	var hookErr error
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		hookErr = webhooks.Verify("s3cret", r.Header.Get(webhooks.HeaderSignature), body, time.Minute, now)
+		json.Unmarshal(body, &hooked)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer hook.Close()
+
+	store := &memStore{rules: []*Rule{
+		{ID: 1, Name: "welcome", Enabled: true, Trigger: Trigger{Event: EventPortalSignup}, Actions: []Action{
+			{Type: ActionSendSMS, Body: "Welcome, {{.name}}!"},
+			{Type: ActionCalEvent, Summary: "Check in with {{.username}}", When: "tomorrow 9am", FeedID: "feed-admin"},
+			{Type: ActionWebhook, URL: hook.URL, Secret: "s3cret"},
+		}},
+		{ID: 2, Name: "disabled", Trigger: Trigger{Event: EventPortalSignup}, Actions: []Action{{Type: ActionSendSMS, Body: "nope"}}},
+		{ID: 3, Name: "broken", Enabled: true, Trigger: Trigger{Event: EventPortalSignup}, Actions: []Action{
+			{Type: ActionWebhook, URL: hook.URL + "/missing", Secret: "s3cret"},
+			{Type: ActionSendSMS, Body: "never sent"},
+		}},
+	}}
+
+	sender := sms.NewFakeSender("")
+	cal := &fakeCal{}
+	engine := NewEngine(store, Deps{Sender: sender, Calendar: cal})
+	engine.now = func() time.Time { return now }
+
+	runs, err := engine.Fire(context.Background(), &Event{
+		Type:  EventPortalSignup,
+		Phone: "15555555555",
+		Data:  map[string]string{"name": "Tex", "username": "tex"},
+	})
+	if err != nil {
+		t.Fatalf("fire: %v", err)
+	}
+	if len(runs) != 2 || len(store.runs) != 2 {
+		t.Fatalf("got %d runs (%d stored), want 2", len(runs), len(store.runs))
+	}
+
+	if runs[0].Status != RunOK || runs[0].RuleID != 1 {
+		t.Errorf("run 1 = %+v", runs[0])
+	}
+	sent := sender.Sent()
+	if len(sent) != 1 || sent[0].To != "+15555555555" || sent[0].Body != "Welcome, Tex!" {
+		t.Errorf("sent = %+v", sent)
+	}
+	if len(cal.created) != 1 || cal.created[0].Summary != "Check in with tex" || cal.created[0].FeedID != "feed-admin" ||
+		!cal.created[0].Start.Equal(time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("created = %+v", cal.created)
+	}
+	if hooked["type"] != EventPortalSignup || hooked["phone"] != "15555555555" {
+		t.Errorf("webhook payload = %v", hooked)
+	}
+	if hookErr != nil {
+		t.Errorf("webhook signature: %v", hookErr)
+	}
+
+	if runs[1].Status != RunError || !strings.Contains(runs[1].Detail, "404") {
+		t.Errorf("run 2 = %+v", runs[1])
+	}
+	if strings.Contains(runs[1].Detail, "send_sms") {
+		t.Errorf("actions after a failure must not run: %q", runs[1].Detail)
+	}
+}
+
+func TestCalendarWatcher(t *testing.T) {
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	store := &memStore{rules: []*Rule{{ID: 1, Name: "heads up", Enabled: true,
+		Trigger: Trigger{Event: EventCalStarting},
+		Actions: []Action{{Type: ActionSendSMS, Body: "{{.summary}} at {{.start}}"}},
+	}}}
+	cal := &fakeCal{events: map[string][]calclient.Event{"feed-1": {
+		{ID: "a", Summary: "Dentist", Start: now.Add(10 * time.Minute)},
+		{ID: "b", Summary: "Later", Start: now.Add(2 * time.Hour)},
+		{ID: "c", Summary: "Past", Start: now.Add(-time.Minute)},
+		{ID: "d", Summary: "Holiday", Start: now.Add(5 * time.Minute), AllDay: true},
+	}}}
+	sender := sms.NewFakeSender("")
+	engine := NewEngine(store, Deps{Sender: sender})
+	feeds := func() ([]FeedRef, error) { return []FeedRef{{Phone: "15555555555", FeedID: "feed-1"}}, nil }
+	w := NewCalendarWatcher(engine, cal, feeds, 15*time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if err := w.Check(context.Background(), now.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("check: %v", err)
+		}
+	}
+	sent := sender.Sent()
+	if len(sent) != 1 || sent[0].Body != "Dentist at 10:10 AM" {
+		t.Errorf("sent = %+v, want one heads-up", sent)
+	}
+}
@@ -0,0 +1,130 @@
+// Package sms models inbound text messages and routes them to command
+// handlers by keyword.
+package sms
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+// MaxMedia is the most attachments Twilio delivers on a single MMS.
+// NumMedia is client-supplied, so ParseMessage never looks past it.
+const MaxMedia = 10
+
+// Media is a single attachment on an inbound MMS.
+type Media struct {
+	URL         string
+	ContentType string
+}
+
+// Account is the portal account a sender's phone number is linked to.
+type Account struct {
+	ID       string
+	Username string
+	Name     string
+}
+
+// FirstName returns the first word of the account's display name, falling
+// back to the username.
+func (a *Account) FirstName() string {
+	if f := strings.Fields(a.Name); len(f) > 0 {
+		return f[0]
+	}
+	return a.Username
+}
+
+// Turn is one message of a conversation thread.
+type Turn struct {
+	Inbound bool // sent by the user rather than the assistant
+	Body    string
+	At      time.Time
+}
+
+// Message is a parsed inbound SMS/MMS webhook.
+type Message struct {
+	ID    int64  // memory store ID, set by the SMS handler once recorded
+	SID   string // Twilio MessageSid
+	From  string // sender, E.164 as sent by Twilio
+	Phone string // sender normalized with identity.NormalizePhone
+	To    string // our number
+	Body  string // raw message text
+	Media []Media
+
+	// Command is the upper-cased first word of Body and Args is the
+	// remainder with surrounding whitespace trimmed. Both are filled in by
+	// ParseMessage.
+	Command string
+	Args    string
+
+	// ThreadID is the conversation thread the message was recorded in and
+	// History its most recent turns, oldest first, ending with this
+	// message. Both are set by the SMS handler.
+	ThreadID int64
+	History  []Turn
+
+	// Account is the sender's linked portal account, set by the SMS
+	// handler when account linking is enabled. Nil for unknown numbers.
+	Account *Account
+}
+
+// AccountID returns the linked account's ID, or "" if there is none.
+func (m *Message) AccountID() string {
+	if m.Account == nil {
+		return ""
+	}
+	return m.Account.ID
+}
+
+// Redacted returns Body with secrets masked (the digits of PIN), for
+// anything that stores, logs or forwards the text.
+func (m *Message) Redacted() string {
+	if m.Command == "PIN" && m.Args != "" {
+		return "PIN ****"
+	}
+	return m.Body
+}
+
+// ParseMessage builds a Message from Twilio's webhook form parameters.
+func ParseMessage(form url.Values) *Message {
+	msg := &Message{
+		SID:  form.Get("MessageSid"),
+		From: form.Get("From"),
+		To:   form.Get("To"),
+		Body: form.Get("Body"),
+	}
+	msg.Phone = identity.NormalizePhone(msg.From)
+	msg.Command, msg.Args = splitCommand(msg.Body)
+
+	n, _ := strconv.Atoi(form.Get("NumMedia"))
+	n = min(n, MaxMedia)
+	for i := 0; i < n; i++ {
+		u := form.Get("MediaUrl" + strconv.Itoa(i))
+		if u == "" {
+			continue
+		}
+		msg.Media = append(msg.Media, Media{
+			URL:         u,
+			ContentType: form.Get("MediaContentType" + strconv.Itoa(i)),
+		})
+	}
+	return msg
+}
+
+// splitCommand splits text into an upper-cased keyword and its arguments.
+func splitCommand(text string) (command, args string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", ""
+	}
+	i := strings.IndexFunc(text, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	if i < 0 {
+		return strings.ToUpper(text), ""
+	}
+	return strings.ToUpper(text[:i]), strings.TrimSpace(text[i+1:])
+}
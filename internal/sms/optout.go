@@ -0,0 +1,91 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+// ErrOptedOut is returned when sending to a number that has replied STOP.
+var ErrOptedOut = errors.New("sms: recipient has opted out")
+
+// Carrier-mandated keywords. They only count when they are the whole
+// message ("stop" opts out, "stop by the store" does not).
+var (
+	stopKeywords  = map[string]bool{"STOP": true, "STOPALL": true, "UNSUBSCRIBE": true, "CANCEL": true, "END": true, "QUIT": true}
+	startKeywords = map[string]bool{"START": true, "UNSTOP": true, "YES": true}
+)
+
+// IsStop reports whether msg is an opt-out request.
+func IsStop(msg *Message) bool {
+	return msg.Args == "" && stopKeywords[msg.Command]
+}
+
+// IsStart reports whether msg is an opt-in (resubscribe) request.
+func IsStart(msg *Message) bool {
+	return msg.Args == "" && startKeywords[msg.Command]
+}
+
+// OptOutList reports whether a normalized phone number has opted out.
+type OptOutList interface {
+	IsOptedOut(phone string) (bool, error)
+}
+
+// SuppressingSender wraps a Sender and refuses to send to opted-out numbers.
+type SuppressingSender struct {
+	next    Sender
+	optOuts OptOutList
+}
+
+// NewSuppressingSender returns a Sender that checks optOuts before
+// delegating to next.
+func NewSuppressingSender(next Sender, optOuts OptOutList) *SuppressingSender {
+	return &SuppressingSender{next: next, optOuts: optOuts}
+}
+
+// Send delivers m unless the recipient has opted out, in which case it
+// returns ErrOptedOut.
+func (s *SuppressingSender) Send(ctx context.Context, m *Outbound) (*Receipt, error) {
+	out, err := s.optOuts.IsOptedOut(identity.NormalizePhone(m.To))
+	if err != nil {
+		return nil, fmt.Errorf("check opt-out: %w", err)
+	}
+	if out {
+		return nil, ErrOptedOut
+	}
+	return s.next.Send(ctx, m)
+}
+
+// BroadcastResult summarizes a Broadcast.
+type BroadcastResult struct {
+	Sent    int
+	Skipped int // opted-out recipients
+	Failed  int
+}
+
+// Broadcast sends body to every recipient through s, skipping numbers that
+// have opted out. It keeps going after individual failures and returns the
+// first error alongside the counts.
+func Broadcast(ctx context.Context, s Sender, recipients []string, body string) (BroadcastResult, error) {
+	var (
+		res      BroadcastResult
+		firstErr error
+	)
+	for _, to := range recipients {
+		_, err := s.Send(ctx, &Outbound{To: to, Body: body})
+		switch {
+		case err == nil:
+			res.Sent++
+		case errors.Is(err, ErrOptedOut):
+			res.Skipped++
+		default:
+			res.Failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("send to %s: %w", to, err)
+			}
+		}
+	}
+	return res, firstErr
+}
@@ -0,0 +1,65 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+// optOuts is a map-backed OptOutList.
+type optOuts map[string]bool
+
+func (o optOuts) IsOptedOut(phone string) (bool, error) {
+	return o[phone], nil
+}
+
+func TestKeywords(t *testing.T) {
+	tests := []struct {
+		body  string
+		stop  bool
+		start bool
+	}{
+		{"STOP", true, false},
+		{" unsubscribe ", true, false},
+		{"Cancel", true, false},
+		{"stop by the store", false, false},
+		{"start", false, true},
+		{"UNSTOP", false, true},
+		{"start the car", false, false},
+		{"hello", false, false},
+	}
+	for _, tt := range tests {
+		msg := ParseMessage(url.Values{"Body": {tt.body}})
+		if got := IsStop(msg); got != tt.stop {
+			t.Errorf("IsStop(%q) = %v, want %v", tt.body, got, tt.stop)
+		}
+		if got := IsStart(msg); got != tt.start {
+			t.Errorf("IsStart(%q) = %v, want %v", tt.body, got, tt.start)
+		}
+	}
+}
+
+func TestSuppressingSenderAndBroadcast(t *testing.T) {
+	fake := NewFakeSender("")
+	s := NewSuppressingSender(fake, optOuts{"15550000000": true})
+	ctx := context.Background()
+
+	if _, err := s.Send(ctx, &Outbound{To: "+1 (555) 000-0000", Body: "hi"}); !errors.Is(err, ErrOptedOut) {
+		t.Errorf("expected ErrOptedOut, got %v", err)
+	}
+
+	res, err := Broadcast(ctx, s, []string{"+15555555555", "+15550000000", "", "+15551112222"}, "big news")
+	if err == nil {
+		t.Error("expected error for the empty recipient")
+	}
+	if res.Sent != 2 || res.Skipped != 1 || res.Failed != 1 {
+		t.Errorf("unexpected broadcast result: %+v", res)
+	}
+
+	for _, m := range fake.Sent() {
+		if m.To == "+15550000000" {
+			t.Error("message was sent to an opted-out number")
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package sms
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// HandlerFunc handles a routed message and returns the reply text.
+// An empty reply means no message is sent back.
+type HandlerFunc func(ctx context.Context, msg *Message) (string, error)
+
+type command struct {
+	keyword string
+	usage   string
+	handler HandlerFunc
+	park    bool
+}
+
+// Router dispatches inbound messages to handlers by their first word.
+// Keywords are matched case-insensitively. Messages that match no keyword
+// go to the fallback handler.
+type Router struct {
+	commands map[string]command
+	fallback HandlerFunc
+}
+
+// NewRouter creates an empty Router. A HELP command listing all registered
+// keywords is built in and can be overridden with Handle.
+func NewRouter() *Router {
+	r := &Router{commands: make(map[string]command)}
+	r.Handle("HELP", "HELP - list commands", r.help)
+	return r
+}
+
+// Handle registers h for keyword. usage is a one-line description shown by
+// HELP (e.g. "NOTE <text> - save a note").
+func (r *Router) Handle(keyword, usage string, h HandlerFunc) {
+	keyword = strings.ToUpper(keyword)
+	r.commands[keyword] = command{keyword: keyword, usage: usage, handler: h}
+}
+
+// Park marks keywords whose messages are held for a person instead of
+// handled when an escalation classifier flags them (see Parks). Call it
+// after Handle; unknown keywords are ignored.
+func (r *Router) Park(keywords ...string) {
+	for _, k := range keywords {
+		k = strings.ToUpper(k)
+		if cmd, ok := r.commands[k]; ok {
+			cmd.park = true
+			r.commands[k] = cmd
+		}
+	}
+}
+
+// Parks reports whether a flagged msg should be held for a person rather
+// than dispatched: it goes to a parked command or to the fallback.
+// Messages for other commands still run.
+func (r *Router) Parks(msg *Message) bool {
+	if cmd, ok := r.commands[msg.Command]; ok {
+		return cmd.park
+	}
+	return true
+}
+
+// Fallback sets the handler for messages that match no keyword.
+func (r *Router) Fallback(h HandlerFunc) {
+	r.fallback = h
+}
+
+// Dispatch routes msg to the matching handler and returns its reply.
+func (r *Router) Dispatch(ctx context.Context, msg *Message) (string, error) {
+	if cmd, ok := r.commands[msg.Command]; ok {
+		return cmd.handler(ctx, msg)
+	}
+	if r.fallback != nil {
+		return r.fallback(ctx, msg)
+	}
+	return r.help(ctx, msg)
+}
+
+// Usage returns the usage lines of all registered commands, sorted by keyword.
+func (r *Router) Usage() []string {
+	keys := make([]string, 0, len(r.commands))
+	for k := range r.commands {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, r.commands[k].usage)
+	}
+	return lines
+}
+
+func (r *Router) help(ctx context.Context, msg *Message) (string, error) {
+	return "Commands:\n" + strings.Join(r.Usage(), "\n"), nil
+}
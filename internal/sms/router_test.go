@@ -0,0 +1,135 @@
+package sms
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseMessage(t *testing.T) {
+	form := url.Values{
+		"MessageSid":        {"SM123"},
+		"From":              {"+15555555555"},
+		"To":                {"+15550001111"},
+		"Body":              {"  note   buy milk and eggs "},
+		"NumMedia":          {"2"},
+		"MediaUrl0":         {"https://api.twilio.com/media/0"},
+		"MediaContentType0": {"image/jpeg"},
+		"MediaUrl1":         {"https://api.twilio.com/media/1"},
+		"MediaContentType1": {"image/png"},
+	}
+
+	msg := ParseMessage(form)
+	if msg.SID != "SM123" || msg.From != "+15555555555" || msg.To != "+15550001111" {
+		t.Errorf("unexpected header fields: %+v", msg)
+	}
+	if msg.Phone != "15555555555" {
+		t.Errorf("Phone = %q, want 15555555555", msg.Phone)
+	}
+	if msg.Command != "NOTE" {
+		t.Errorf("Command = %q, want NOTE", msg.Command)
+	}
+	if msg.Args != "buy milk and eggs" {
+		t.Errorf("Args = %q, want %q", msg.Args, "buy milk and eggs")
+	}
+	if len(msg.Media) != 2 {
+		t.Fatalf("expected 2 media, got %d", len(msg.Media))
+	}
+	if msg.Media[1].URL != "https://api.twilio.com/media/1" || msg.Media[1].ContentType != "image/png" {
+		t.Errorf("unexpected media[1]: %+v", msg.Media[1])
+	}
+}
+
+func TestParseMessage_ClampsNumMedia(t *testing.T) {
+	form := url.Values{"Body": {"hi"}, "NumMedia": {"2000000000"}}
+	for i := 0; i < MaxMedia+5; i++ {
+		form.Set("MediaUrl"+strconv.Itoa(i), "https://api.twilio.com/media/"+strconv.Itoa(i))
+	}
+
+	msg := ParseMessage(form)
+	if len(msg.Media) != MaxMedia {
+		t.Errorf("expected %d media, got %d", MaxMedia, len(msg.Media))
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		input       string
+		wantCommand string
+		wantArgs    string
+	}{
+		{"help", "HELP", ""},
+		{"NOTE hello world", "NOTE", "hello world"},
+		{"remind\ndentist friday", "REMIND", "dentist friday"},
+		{"   ", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		cmd, args := splitCommand(tt.input)
+		if cmd != tt.wantCommand || args != tt.wantArgs {
+			t.Errorf("splitCommand(%q) = (%q, %q), want (%q, %q)", tt.input, cmd, args, tt.wantCommand, tt.wantArgs)
+		}
+	}
+}
+
+func TestRouter_Dispatch(t *testing.T) {
+	r := NewRouter()
+	r.Handle("note", "NOTE <text> - save a note", func(ctx context.Context, msg *Message) (string, error) {
+		return "saved: " + msg.Args, nil
+	})
+	r.Fallback(func(ctx context.Context, msg *Message) (string, error) {
+		return "fallback: " + msg.Body, nil
+	})
+
+	ctx := context.Background()
+
+	reply, err := r.Dispatch(ctx, ParseMessage(url.Values{"Body": {"Note pick up keys"}}))
+	if err != nil || reply != "saved: pick up keys" {
+		t.Errorf("NOTE dispatch = (%q, %v)", reply, err)
+	}
+
+	reply, err = r.Dispatch(ctx, ParseMessage(url.Values{"Body": {"what's up"}}))
+	if err != nil || reply != "fallback: what's up" {
+		t.Errorf("fallback dispatch = (%q, %v)", reply, err)
+	}
+
+	reply, err = r.Dispatch(ctx, ParseMessage(url.Values{"Body": {"help"}}))
+	if err != nil {
+		t.Fatalf("HELP dispatch: %v", err)
+	}
+	if !strings.Contains(reply, "NOTE <text> - save a note") || !strings.Contains(reply, "HELP - list commands") {
+		t.Errorf("HELP reply missing usage lines: %q", reply)
+	}
+}
+
+func TestRouter_NoFallbackUsesHelp(t *testing.T) {
+	r := NewRouter()
+	reply, err := r.Dispatch(context.Background(), ParseMessage(url.Values{"Body": {"hello"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(reply, "Commands:") {
+		t.Errorf("expected help text, got %q", reply)
+	}
+}
+
+func TestRouter_Parks(t *testing.T) {
+	noop := func(ctx context.Context, msg *Message) (string, error) { return "", nil }
+	r := NewRouter()
+	r.Handle("NOTE", "NOTE <text> - save a note", noop)
+	r.Handle("ASK", "ASK <question> - ask your circle", noop)
+	r.Park("ask", "BOGUS")
+
+	for body, want := range map[string]bool{
+		"NOTE call the lawyer":   false,
+		"ask who knows a lawyer": true,
+		"I need a lawyer":        true,
+		"HELP":                   false,
+	} {
+		if got := r.Parks(ParseMessage(url.Values{"Body": {body}})); got != want {
+			t.Errorf("Parks(%q) = %v, want %v", body, got, want)
+		}
+	}
+}
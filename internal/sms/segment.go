@@ -0,0 +1,233 @@
+package sms
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// Encoding is the character set an SMS is sent in.
+type Encoding int
+
+const (
+	// GSM7 is the default 7-bit alphabet (GSM 03.38).
+	GSM7 Encoding = iota
+	// UCS2 is used when any character falls outside GSM-7; it fits far
+	// fewer characters per segment.
+	UCS2
+)
+
+func (e Encoding) String() string {
+	if e == UCS2 {
+		return "UCS-2"
+	}
+	return "GSM-7"
+}
+
+// Segment capacities: septets for GSM-7, UTF-16 code units for UCS-2. A
+// multi-segment message loses room in each segment to the concatenation
+// header.
+const (
+	gsm7Single = 160
+	gsm7Multi  = 153
+	ucs2Single = 70
+	ucs2Multi  = 67
+)
+
+// gsm7Basic is the GSM 03.38 default alphabet; each costs one septet.
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension characters are sent as an escape plus a code: two septets.
+const gsm7Extension = "\f^{}\\[~]|€"
+
+// runeCost is the space r takes in the given encoding.
+func runeCost(r rune, enc Encoding) int {
+	if enc == UCS2 {
+		if r >= 0x10000 {
+			return 2 // surrogate pair
+		}
+		return 1
+	}
+	if strings.ContainsRune(gsm7Extension, r) {
+		return 2
+	}
+	return 1
+}
+
+// DetectEncoding reports whether s can be sent as GSM-7 or needs UCS-2.
+func DetectEncoding(s string) Encoding {
+	for _, r := range s {
+		if !strings.ContainsRune(gsm7Basic, r) && !strings.ContainsRune(gsm7Extension, r) {
+			return UCS2
+		}
+	}
+	return GSM7
+}
+
+// Length is the size of s in its encoding: septets for GSM-7, UTF-16 code
+// units for UCS-2.
+func Length(s string) int {
+	enc := DetectEncoding(s)
+	if enc == UCS2 {
+		return len(utf16.Encode([]rune(s)))
+	}
+	n := 0
+	for _, r := range s {
+		n += runeCost(r, enc)
+	}
+	return n
+}
+
+// capacity returns the single- and multi-segment sizes for enc.
+func capacity(enc Encoding) (single, multi int) {
+	if enc == UCS2 {
+		return ucs2Single, ucs2Multi
+	}
+	return gsm7Single, gsm7Multi
+}
+
+// SegmentCount is how many SMS segments s is billed as.
+func SegmentCount(s string) int {
+	if s == "" {
+		return 0
+	}
+	single, multi := capacity(DetectEncoding(s))
+	n := Length(s)
+	if n <= single {
+		return 1
+	}
+	return (n + multi - 1) / multi
+}
+
+// SplitReply breaks s into at most maxParts messages that each fit in a
+// single segment, splitting on word boundaries and prefixing each part
+// with a "(1/3) " marker. Text beyond maxParts is dropped and the last part
+// ends with "…". maxParts < 1 means no limit.
+func SplitReply(s string, maxParts int) []string {
+	s = strings.TrimSpace(s)
+	enc := DetectEncoding(s)
+	single, _ := capacity(enc)
+	if Length(s) <= single {
+		return []string{s}
+	}
+
+	// The marker's width depends on the part count, which depends on the
+	// marker's width; widen the marker until the count fits in it.
+	for digits := 1; ; digits++ {
+		width := single - len("(/) ") - 2*digits
+		parts := wrap(s, width, enc)
+		if maxParts >= 1 && len(parts) > maxParts {
+			parts = parts[:maxParts]
+			parts[maxParts-1] = fitEllipsis(parts[maxParts-1], width, enc)
+		}
+		if len(strconv.Itoa(len(parts))) > digits {
+			continue
+		}
+		for i := range parts {
+			parts[i] = "(" + strconv.Itoa(i+1) + "/" + strconv.Itoa(len(parts)) + ") " + parts[i]
+		}
+		return parts
+	}
+}
+
+// CapReply shortens s to fit in maxSegments concatenated segments, ending
+// it with "…" if anything was cut. maxSegments < 1 means no limit.
+func CapReply(s string, maxSegments int) string {
+	s = strings.TrimSpace(s)
+	if maxSegments < 1 || SegmentCount(s) <= maxSegments {
+		return s
+	}
+	enc := DetectEncoding(s)
+	single, multi := capacity(enc)
+	limit := multi * maxSegments
+	if maxSegments == 1 {
+		limit = single
+	}
+	return fitEllipsis(s, limit, enc)
+}
+
+// wrap splits s into chunks of at most width, breaking between words where
+// possible and inside words that are longer than width. Line breaks are
+// kept unless they fall between chunks.
+func wrap(s string, width int, enc Encoding) []string {
+	var (
+		chunks []string
+		cur    []rune
+		curLen int
+	)
+	flush := func() {
+		if len(cur) > 0 {
+			chunks = append(chunks, strings.TrimSpace(string(cur)))
+			cur, curLen = nil, 0
+		}
+	}
+	for i, line := range strings.Split(s, "\n") {
+		sep := ' '
+		if i > 0 {
+			sep = '\n'
+		}
+		for _, word := range strings.Fields(line) {
+			wordLen := 0
+			for _, r := range word {
+				wordLen += runeCost(r, enc)
+			}
+			if curLen == 0 {
+				sep = 0
+			}
+			gap := 0
+			if sep != 0 {
+				gap = 1
+			}
+			if curLen+gap+wordLen <= width {
+				if sep != 0 {
+					cur = append(cur, sep)
+				}
+				cur = append(cur, []rune(word)...)
+				curLen += gap + wordLen
+				sep = ' '
+				continue
+			}
+			flush()
+			for _, r := range word {
+				c := runeCost(r, enc)
+				if curLen+c > width {
+					flush()
+				}
+				cur = append(cur, r)
+				curLen += c
+			}
+			sep = ' '
+		}
+	}
+	flush()
+	return chunks
+}
+
+// fitEllipsis trims s so that s plus an ellipsis fits in width, cutting at
+// a word boundary when one is reasonably close. GSM-7 text gets "..."
+// because "…" would force the whole message into UCS-2.
+func fitEllipsis(s string, width int, enc Encoding) string {
+	ellipsis := "…"
+	if enc == GSM7 {
+		ellipsis = "..."
+	}
+	room := width - len([]rune(ellipsis))
+	runes := []rune(s)
+	n, cut := 0, 0
+	for i, r := range runes {
+		n += runeCost(r, enc)
+		if n > room {
+			break
+		}
+		cut = i + 1
+	}
+	out := string(runes[:cut])
+	if cut < len(runes) && !unicode.IsSpace(runes[cut]) {
+		if i := strings.LastIndexAny(out, " \n"); i > len(out)/2 {
+			out = out[:i]
+		}
+	}
+	return strings.TrimRight(out, " \n.,;:") + ellipsis
+}
@@ -0,0 +1,176 @@
+package sms
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDetectEncodingAndLength(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		enc    Encoding
+		length int
+	}{
+		{"ascii", "hello world", GSM7, 11},
+		{"gsm accents", "café niño", GSM7, 9},
+		{"extension chars count twice", "€5 {x}", GSM7, 9},
+		{"lowercase c cedilla is not gsm", "façade", UCS2, 6},
+		{"hungarian accent", "Győr", UCS2, 4},
+		{"emoji is a surrogate pair", "hi 👋", UCS2, 5},
+		{"curly quote", "it’s", UCS2, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if enc := DetectEncoding(tt.in); enc != tt.enc {
+				t.Errorf("DetectEncoding(%q) = %v, want %v", tt.in, enc, tt.enc)
+			}
+			if n := Length(tt.in); n != tt.length {
+				t.Errorf("Length(%q) = %d, want %d", tt.in, n, tt.length)
+			}
+		})
+	}
+}
+
+func TestSegmentCount(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want int
+	}{
+		{"empty", "", 0},
+		{"gsm single", strings.Repeat("a", 160), 1},
+		{"gsm overflow", strings.Repeat("a", 161), 2},
+		{"gsm two full", strings.Repeat("a", 306), 2},
+		{"gsm three", strings.Repeat("a", 307), 3},
+		{"extension pushes over", strings.Repeat("a", 159) + "€", 2},
+		{"ucs2 single", strings.Repeat("ő", 70), 1},
+		{"ucs2 overflow", strings.Repeat("ő", 71), 2},
+		{"emoji single", strings.Repeat("😀", 35), 1},
+		{"emoji overflow", strings.Repeat("😀", 36), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SegmentCount(tt.in); got != tt.want {
+				t.Errorf("SegmentCount = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitReply(t *testing.T) {
+	words := strings.Repeat("lorem ipsum dolor sit amet ", 20)
+	tests := []struct {
+		name     string
+		in       string
+		maxParts int
+		parts    int
+		enc      Encoding
+	}{
+		{"short reply untouched", "Noted.", 3, 1, GSM7},
+		{"gsm", words, 0, 4, GSM7},
+		{"gsm accents stay gsm", strings.Repeat("café à la crème ", 20), 0, 3, GSM7},
+		{"emoji forces ucs2", "🎉 " + words, 0, 9, UCS2},
+		{"capped", words, 2, 2, GSM7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := SplitReply(tt.in, tt.maxParts)
+			if len(parts) != tt.parts {
+				t.Fatalf("got %d parts, want %d: %q", len(parts), tt.parts, parts)
+			}
+			for i, p := range parts {
+				if SegmentCount(p) != 1 {
+					t.Errorf("part %d is %d segments (%d long): %q", i, SegmentCount(p), Length(p), p)
+				}
+				if tt.parts > 1 {
+					marker := "(" + strconv.Itoa(i+1) + "/" + strconv.Itoa(tt.parts) + ") "
+					if !strings.HasPrefix(p, marker) {
+						t.Errorf("part %d = %q, want prefix %q", i, p, marker)
+					}
+				}
+			}
+			if enc := DetectEncoding(parts[0]); enc != tt.enc {
+				t.Errorf("first part encoding = %v, want %v", enc, tt.enc)
+			}
+		})
+	}
+}
+
+func TestSplitReplyWordBoundaries(t *testing.T) {
+	in := strings.TrimSpace(strings.Repeat("naïve ", 40))
+	parts := SplitReply(in, 0)
+	if len(parts) < 2 {
+		t.Fatalf("expected multiple parts, got %q", parts)
+	}
+	var rejoined []string
+	for _, p := range parts {
+		body := p[strings.Index(p, ") ")+2:]
+		for _, w := range strings.Fields(body) {
+			if w != "naïve" {
+				t.Errorf("word split across parts: %q in %q", w, p)
+			}
+		}
+		rejoined = append(rejoined, body)
+	}
+	if got := strings.Join(rejoined, " "); got != in {
+		t.Errorf("rejoined text differs:\n got %q\nwant %q", got, in)
+	}
+}
+
+func TestSplitReplyKeepsLineBreaks(t *testing.T) {
+	var lines []string
+	for i := 0; i < 8; i++ {
+		lines = append(lines, "COMMAND <arg> - does something useful")
+	}
+	parts := SplitReply(strings.Join(lines, "\n"), 0)
+	if len(parts) != 2 {
+		t.Fatalf("got %d parts, want 2: %q", len(parts), parts)
+	}
+	if !strings.HasPrefix(parts[0], "(1/2) COMMAND <arg> - does something useful\nCOMMAND") {
+		t.Errorf("part 1 = %q, want line breaks kept", parts[0])
+	}
+	if strings.HasPrefix(parts[1], "(2/2) \n") || strings.HasSuffix(parts[0], "\n") {
+		t.Errorf("line break left at a part boundary: %q", parts)
+	}
+}
+
+func TestSplitReplyTruncatesLastPart(t *testing.T) {
+	parts := SplitReply(strings.Repeat("lorem ipsum dolor sit amet ", 20), 2)
+	if !strings.HasSuffix(parts[1], "...") {
+		t.Errorf("last part = %q, want GSM-7 ellipsis", parts[1])
+	}
+
+	parts = SplitReply(strings.Repeat("héllo wörld ✓ ", 30), 2)
+	if !strings.HasSuffix(parts[1], "…") {
+		t.Errorf("last part = %q, want UCS-2 ellipsis", parts[1])
+	}
+}
+
+func TestCapReply(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		maxSegments int
+		segments    int
+		suffix      string
+	}{
+		{"fits", "see you at 3pm", 1, 1, "3pm"},
+		{"gsm one segment", strings.Repeat("word ", 50), 1, 1, "..."},
+		{"gsm two segments", strings.Repeat("word ", 100), 2, 2, "..."},
+		{"emoji one segment", strings.Repeat("yay 🎉 ", 30), 1, 1, "…"},
+		{"no limit", strings.Repeat("word ", 100), 0, 4, "word"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CapReply(tt.in, tt.maxSegments)
+			if n := SegmentCount(got); n != tt.segments {
+				t.Errorf("CapReply is %d segments, want %d: %q", n, tt.segments, got)
+			}
+			if !strings.HasSuffix(got, tt.suffix) {
+				t.Errorf("CapReply = %q, want suffix %q", got, tt.suffix)
+			}
+		})
+	}
+}
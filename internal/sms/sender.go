@@ -0,0 +1,135 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+// Outbound is a message the assistant initiates (reminders, broadcasts,
+// one-time codes), as opposed to an inline TwiML reply.
+type Outbound struct {
+	To        string   `json:"to"` // E.164, e.g. +15555555555
+	Body      string   `json:"body"`
+	MediaURLs []string `json:"media_urls,omitempty"`
+}
+
+// Receipt identifies a message accepted by the provider.
+type Receipt struct {
+	SID    string `json:"sid"`    // provider message ID (Twilio MessageSid)
+	Status string `json:"status"` // provider status, e.g. "queued"
+}
+
+// Sender sends outbound messages.
+type Sender interface {
+	Send(ctx context.Context, m *Outbound) (*Receipt, error)
+}
+
+// SentMessage is an outbound message recorded by FakeSender.
+type SentMessage struct {
+	Outbound
+	SID    string    `json:"sid"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// FakeSender is a Sender that never touches the network. It records every
+// message in memory and, if created with a path, appends it as a JSON line
+// to that file so local development can watch outbound traffic with
+// `tail -f`.
+type FakeSender struct {
+	mu   sync.Mutex
+	path string
+	sent []SentMessage
+}
+
+// NewFakeSender creates a FakeSender. If path is non-empty, sent messages
+// are also appended to that file.
+func NewFakeSender(path string) *FakeSender {
+	return &FakeSender{path: path}
+}
+
+// Send records m and returns a fake SID.
+func (f *FakeSender) Send(ctx context.Context, m *Outbound) (*Receipt, error) {
+	if m.To == "" {
+		return nil, fmt.Errorf("fake sender: missing recipient")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	msg := SentMessage{
+		Outbound: *m,
+		SID:      "SMfake" + strconv.Itoa(len(f.sent)+1),
+		SentAt:   time.Now().UTC(),
+	}
+	f.sent = append(f.sent, msg)
+
+	if f.path != "" {
+		if err := appendJSONLine(f.path, msg); err != nil {
+			return nil, fmt.Errorf("fake sender: %w", err)
+		}
+	}
+	return &Receipt{SID: msg.SID, Status: "sent"}, nil
+}
+
+// Sent returns a copy of every message sent so far, oldest first.
+func (f *FakeSender) Sent() []SentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]SentMessage(nil), f.sent...)
+}
+
+// Reset forgets all recorded messages (the file, if any, is left alone).
+func (f *FakeSender) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = nil
+}
+
+func appendJSONLine(path string, v interface{}) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(v)
+}
+
+// MessageLog stores outbound messages in the conversation history.
+// *database.DB implements it.
+type MessageLog interface {
+	RecordOutbound(phone, body, sid string, at time.Time) error
+}
+
+// RecordingSender wraps a Sender and logs every message it delivers, so
+// proactive messages (reminders, broadcasts) appear in the recipient's
+// thread alongside replies.
+type RecordingSender struct {
+	next Sender
+	log  MessageLog
+}
+
+// NewRecordingSender returns a Sender that records successful sends in log.
+func NewRecordingSender(next Sender, log MessageLog) *RecordingSender {
+	return &RecordingSender{next: next, log: log}
+}
+
+// Send delivers m through the wrapped Sender and records it. A failure to
+// record does not fail the send, since the message has already gone out.
+func (s *RecordingSender) Send(ctx context.Context, m *Outbound) (*Receipt, error) {
+	rcpt, err := s.next.Send(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.log.RecordOutbound(identity.NormalizePhone(m.To), m.Body, rcpt.SID, time.Now().UTC()); err != nil {
+		log.Printf("error recording outbound message to %s: %v", m.To, err)
+	}
+	return rcpt, nil
+}
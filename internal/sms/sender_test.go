@@ -0,0 +1,94 @@
+package sms
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFakeSender(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	f := NewFakeSender(path)
+	ctx := context.Background()
+
+	r1, err := f.Send(ctx, &Outbound{To: "+15555555555", Body: "first"})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	r2, err := f.Send(ctx, &Outbound{To: "+15550000000", Body: "second", MediaURLs: []string{"https://example.com/a.jpg"}})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if r1.SID == r2.SID {
+		t.Errorf("expected distinct SIDs, got %q twice", r1.SID)
+	}
+
+	sent := f.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 sent messages, got %d", len(sent))
+	}
+	if sent[0].To != "+15555555555" || sent[0].Body != "first" || sent[0].SID != r1.SID {
+		t.Errorf("unexpected first message: %+v", sent[0])
+	}
+
+	if _, err := f.Send(ctx, &Outbound{Body: "nobody"}); err == nil {
+		t.Error("expected error for missing recipient")
+	}
+
+	// The outbox file has one JSON line per message.
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open outbox: %v", err)
+	}
+	defer file.Close()
+
+	var lines []SentMessage
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var m SentMessage
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			t.Fatalf("decode outbox line: %v", err)
+		}
+		lines = append(lines, m)
+	}
+	if len(lines) != 2 || lines[1].Body != "second" || len(lines[1].MediaURLs) != 1 {
+		t.Errorf("unexpected outbox contents: %+v", lines)
+	}
+
+	f.Reset()
+	if len(f.Sent()) != 0 {
+		t.Error("expected Reset to clear recorded messages")
+	}
+}
+
+// memoryLog is an in-memory MessageLog.
+type memoryLog struct {
+	phones, bodies, sids []string
+}
+
+func (l *memoryLog) RecordOutbound(phone, body, sid string, at time.Time) error {
+	l.phones = append(l.phones, phone)
+	l.bodies = append(l.bodies, body)
+	l.sids = append(l.sids, sid)
+	return nil
+}
+
+func TestRecordingSender(t *testing.T) {
+	msgLog := &memoryLog{}
+	s := NewRecordingSender(NewSuppressingSender(NewFakeSender(""), optOuts{"15550000000": true}), msgLog)
+
+	if _, err := s.Send(context.Background(), &Outbound{To: "+1 555 555 5555", Body: "reminder: dentist"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if _, err := s.Send(context.Background(), &Outbound{To: "+15550000000", Body: "nope"}); err != ErrOptedOut {
+		t.Fatalf("send to opted-out number = %v, want ErrOptedOut", err)
+	}
+
+	if len(msgLog.phones) != 1 || msgLog.phones[0] != "15555555555" || msgLog.bodies[0] != "reminder: dentist" || msgLog.sids[0] != "SMfake1" {
+		t.Errorf("expected only the delivered message logged, got %+v", msgLog)
+	}
+}
@@ -0,0 +1,90 @@
+package twilio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+// DefaultAPIURL is the base URL of the Twilio REST API.
+const DefaultAPIURL = "https://api.twilio.com"
+
+// Client sends messages through the Twilio REST API. It implements
+// sms.Sender.
+type Client struct {
+	// APIURL is the REST API base URL; tests point it at an httptest server.
+	APIURL string
+	// StatusCallback, if set, is the URL Twilio posts delivery status
+	// updates to for every message sent (our /sms/status endpoint).
+	StatusCallback string
+
+	accountSID string
+	authToken  string
+	from       string
+	http       *http.Client
+}
+
+// NewClient creates a Client that sends from the given Twilio number.
+func NewClient(accountSID, authToken, from string) *Client {
+	return &Client{
+		APIURL:     DefaultAPIURL,
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+		http:       &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// apiError is the error body Twilio returns for failed requests.
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Send creates a Message resource.
+// POST /2010-04-01/Accounts/{AccountSid}/Messages.json
+func (c *Client) Send(ctx context.Context, m *sms.Outbound) (*sms.Receipt, error) {
+	form := url.Values{}
+	form.Set("To", m.To)
+	form.Set("From", c.from)
+	form.Set("Body", m.Body)
+	for _, u := range m.MediaURLs {
+		form.Add("MediaUrl", u)
+	}
+	if c.StatusCallback != "" {
+		form.Set("StatusCallback", c.StatusCallback)
+	}
+
+	endpoint := strings.TrimRight(c.APIURL, "/") + "/2010-04-01/Accounts/" + c.accountSID + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.accountSID, c.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("twilio send: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var e apiError
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		return nil, fmt.Errorf("twilio send: status %d: code %d: %s", resp.StatusCode, e.Code, e.Message)
+	}
+
+	var r sms.Receipt
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("twilio send: decode response: %w", err)
+	}
+	return &r, nil
+}
@@ -0,0 +1,71 @@
+package twilio
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+func TestClientSend(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2010-04-01/Accounts/AC123/Messages.json" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "AC123" || pass != "secret" {
+			t.Errorf("unexpected basic auth %q:%q", user, pass)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.PostForm.Get("To") != "+15555555555" || r.PostForm.Get("From") != "+15550001111" || r.PostForm.Get("Body") != "hi there" {
+			t.Errorf("unexpected form: %v", r.PostForm)
+		}
+		if got := r.PostForm["MediaUrl"]; len(got) != 1 || got[0] != "https://example.com/a.jpg" {
+			t.Errorf("unexpected MediaUrl: %v", got)
+		}
+		if got := r.PostForm.Get("StatusCallback"); got != "https://nexus.example.com/sms/status" {
+			t.Errorf("unexpected StatusCallback: %q", got)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid":"SM999","status":"queued","to":"+15555555555"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient("AC123", "secret", "+15550001111")
+	c.APIURL = srv.URL
+	c.StatusCallback = "https://nexus.example.com/sms/status"
+
+	var sender sms.Sender = c
+	r, err := sender.Send(context.Background(), &sms.Outbound{
+		To:        "+15555555555",
+		Body:      "hi there",
+		MediaURLs: []string{"https://example.com/a.jpg"},
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if r.SID != "SM999" || r.Status != "queued" {
+		t.Errorf("unexpected receipt: %+v", r)
+	}
+}
+
+func TestClientSend_APIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":21211,"message":"The 'To' number is not a valid phone number.","status":400}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient("AC123", "secret", "+15550001111")
+	c.APIURL = srv.URL
+
+	_, err := c.Send(context.Background(), &sms.Outbound{To: "nope", Body: "hi"})
+	if err == nil || !strings.Contains(err.Error(), "21211") {
+		t.Errorf("expected Twilio error code in error, got %v", err)
+	}
+}
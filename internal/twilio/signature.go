@@ -0,0 +1,56 @@
+// Package twilio contains helpers for talking to Twilio and verifying
+// requests that Twilio sends to our webhooks.
+package twilio
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SignatureHeader is the request header Twilio uses to sign webhooks.
+const SignatureHeader = "X-Twilio-Signature"
+
+// Signature computes the expected X-Twilio-Signature for a webhook request.
+//
+// Twilio signs the full request URL (scheme, host, path and query string)
+// followed by every POST parameter, sorted by name, with each name and value
+// appended without separators. The result is HMAC-SHA1 keyed with the
+// account auth token, base64 encoded.
+func Signature(authToken, fullURL string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(fullURL)
+	for _, k := range keys {
+		values := params[k]
+		// Repeated parameters are signed in value order.
+		sorted := append([]string(nil), values...)
+		sort.Strings(sorted)
+		for _, v := range sorted {
+			b.WriteString(k)
+			b.WriteString(v)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateSignature reports whether signature matches the expected signature
+// for the given URL and POST parameters. The comparison is constant-time.
+func ValidateSignature(authToken, fullURL string, params url.Values, signature string) bool {
+	if authToken == "" || signature == "" {
+		return false
+	}
+	expected := Signature(authToken, fullURL, params)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
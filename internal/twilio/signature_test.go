@@ -0,0 +1,87 @@
+package twilio
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSignature_DocumentedVector(t *testing.T) {
+	// Example from Twilio's webhook security documentation.
+	params := url.Values{
+		"CallSid": {"CA1234567890ABCDE"},
+		"Caller":  {"+12349013030"},
+		"Digits":  {"1234"},
+		"From":    {"+12349013030"},
+		"To":      {"+18005551212"},
+	}
+	got := Signature("12345", "https://mycompany.com/myapp.php?foo=1&bar=2", params)
+	want := "0/KCTR6DLpKmkAf8muzZqo1nDgQ="
+	if got != want {
+		t.Errorf("Signature = %q, want %q", got, want)
+	}
+}
+
+func TestSignature_SMSVectors(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		params url.Values
+		want   string
+	}{
+		{
+			name: "inbound sms",
+			url:  "https://abc123.ngrok.io/sms",
+			params: url.Values{
+				"AccountSid": {"ACXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX"},
+				"Body":       {"hello"},
+				"From":       {"+15555555555"},
+				"MessageSid": {"SM123"},
+				"To":         {"+15550001111"},
+			},
+			want: "lA/tLSbrYiiK+jhHfrPRmCABj+4=",
+		},
+		{
+			name:   "no params",
+			url:    "https://abc123.ngrok.io/sms",
+			params: url.Values{},
+			want:   "f5ZHJAHThkwJqd/9uVBBP7n+6iI=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Signature("test-auth-token", tt.url, tt.params)
+			if got != tt.want {
+				t.Errorf("Signature = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSignature(t *testing.T) {
+	const token = "test-auth-token"
+	const u = "https://abc123.ngrok.io/sms"
+	params := url.Values{"Body": {"hello"}, "From": {"+15555555555"}}
+	sig := Signature(token, u, params)
+
+	if !ValidateSignature(token, u, params, sig) {
+		t.Error("expected valid signature to pass")
+	}
+	if ValidateSignature("wrong-token", u, params, sig) {
+		t.Error("expected signature with wrong token to fail")
+	}
+	if ValidateSignature(token, "https://evil.example.com/sms", params, sig) {
+		t.Error("expected signature for different URL to fail")
+	}
+
+	tampered := url.Values{"Body": {"hello"}, "From": {"+15550000000"}}
+	if ValidateSignature(token, u, tampered, sig) {
+		t.Error("expected signature with tampered params to fail")
+	}
+	if ValidateSignature(token, u, params, "") {
+		t.Error("expected empty signature to fail")
+	}
+	if ValidateSignature("", u, params, sig) {
+		t.Error("expected empty auth token to fail")
+	}
+}
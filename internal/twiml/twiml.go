@@ -0,0 +1,139 @@
+// Package twiml builds TwiML, the XML Twilio expects in reply to SMS and
+// voice webhooks. Verbs are Go types marshaled with encoding/xml, so text
+// and attribute values are always escaped.
+package twiml
+
+import (
+	"encoding/xml"
+	"log"
+	"net/http"
+)
+
+// Verb is an element allowed directly inside a Response: Message, Say,
+// Gather, Record, Redirect, Hangup or Reject.
+type Verb interface {
+	verb()
+}
+
+// Response is the root of every TwiML document.
+type Response struct {
+	XMLName xml.Name `xml:"Response"`
+	Verbs   []Verb
+}
+
+// NewResponse returns a Response containing verbs.
+func NewResponse(verbs ...Verb) *Response {
+	return &Response{Verbs: verbs}
+}
+
+// Add appends verbs to the response.
+func (r *Response) Add(verbs ...Verb) *Response {
+	r.Verbs = append(r.Verbs, verbs...)
+	return r
+}
+
+// Marshal renders the document, including the XML declaration.
+func (r *Response) Marshal() ([]byte, error) {
+	out, err := xml.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// Write sends r as an HTTP 200 text/xml response.
+func Write(w http.ResponseWriter, r *Response) {
+	out, err := r.Marshal()
+	if err != nil {
+		log.Printf("error marshaling TwiML: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
+
+// Message replies with an SMS or MMS.
+type Message struct {
+	Body  string
+	Media []Media
+}
+
+// MarshalXML writes a plain <Message>text</Message>, or <Body> and <Media>
+// nouns when the message has attachments.
+func (m Message) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name.Local = "Message"
+	if len(m.Media) == 0 {
+		return e.EncodeElement(m.Body, start)
+	}
+	nouns := struct {
+		Body  string  `xml:"Body,omitempty"`
+		Media []Media `xml:"Media"`
+	}{m.Body, m.Media}
+	return e.EncodeElement(nouns, start)
+}
+
+// Media is an attachment on a Message.
+type Media struct {
+	URL string `xml:",chardata"`
+}
+
+// Say reads text aloud on a call.
+type Say struct {
+	XMLName  xml.Name `xml:"Say"`
+	Text     string   `xml:",chardata"`
+	Voice    string   `xml:"voice,attr,omitempty"`
+	Language string   `xml:"language,attr,omitempty"`
+}
+
+// Gather collects keypresses or speech and posts them to Action. Prompts
+// (Say verbs) are played while it listens.
+type Gather struct {
+	XMLName     xml.Name `xml:"Gather"`
+	Action      string   `xml:"action,attr,omitempty"`
+	Method      string   `xml:"method,attr,omitempty"`
+	Input       string   `xml:"input,attr,omitempty"` // "dtmf", "speech" or "dtmf speech"
+	NumDigits   int      `xml:"numDigits,attr,omitempty"`
+	Timeout     int      `xml:"timeout,attr,omitempty"` // seconds
+	FinishOnKey string   `xml:"finishOnKey,attr,omitempty"`
+	Hints       string   `xml:"hints,attr,omitempty"`
+	Prompts     []Say
+}
+
+// Record records the caller and posts the recording to Action.
+type Record struct {
+	XMLName     xml.Name `xml:"Record"`
+	Action      string   `xml:"action,attr,omitempty"`
+	Method      string   `xml:"method,attr,omitempty"`
+	MaxLength   int      `xml:"maxLength,attr,omitempty"` // seconds
+	Timeout     int      `xml:"timeout,attr,omitempty"`   // seconds of silence
+	FinishOnKey string   `xml:"finishOnKey,attr,omitempty"`
+	PlayBeep    bool     `xml:"playBeep,attr,omitempty"`
+}
+
+// Redirect hands control to the TwiML at URL.
+type Redirect struct {
+	XMLName xml.Name `xml:"Redirect"`
+	URL     string   `xml:",chardata"`
+	Method  string   `xml:"method,attr,omitempty"`
+}
+
+// Hangup ends the call.
+type Hangup struct {
+	XMLName xml.Name `xml:"Hangup"`
+}
+
+// Reject declines an incoming call without answering it.
+type Reject struct {
+	XMLName xml.Name `xml:"Reject"`
+	Reason  string   `xml:"reason,attr,omitempty"` // "rejected" or "busy"
+}
+
+func (Message) verb()  {}
+func (Say) verb()      {}
+func (Gather) verb()   {}
+func (Record) verb()   {}
+func (Redirect) verb() {}
+func (Hangup) verb()   {}
+func (Reject) verb()   {}
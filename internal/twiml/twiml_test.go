@@ -0,0 +1,86 @@
+package twiml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *Response
+	}{
+		{"empty", NewResponse()},
+		{"message", NewResponse(Message{Body: `Tom & "Jerry" <3 ünïcode 🎉`})},
+		{"messages", NewResponse(Message{Body: "(1/2) first"}, Message{Body: "(2/2) second"})},
+		{"mms", NewResponse(Message{
+			Body:  "Here's your receipt",
+			Media: []Media{{URL: "https://nexus.example.com/media/abc?x=1&y=2"}},
+		})},
+		{"media_only", NewResponse(Message{Media: []Media{{URL: "https://nexus.example.com/media/abc"}}})},
+		{"ivr", NewResponse(
+			Say{Text: "Hi, this is nexus."},
+			Gather{
+				Action:    "/voice/menu",
+				Input:     "dtmf speech",
+				NumDigits: 1,
+				Timeout:   5,
+				Hints:     "calendar, memo",
+				Prompts:   []Say{{Text: "Press 1 for today's events."}},
+			},
+			Redirect{URL: "/voice/menu"},
+		)},
+		{"record", NewResponse(
+			Say{Text: "Leave your memo after the beep.", Voice: "Polly.Joanna", Language: "en-US"},
+			Record{Action: "/voice/memo", MaxLength: 120, FinishOnKey: "#", PlayBeep: true},
+			Hangup{},
+		)},
+		{"reject", NewResponse(Reject{Reason: "busy"})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.resp.Marshal()
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			got = append(got, '\n')
+
+			path := filepath.Join("testdata", tt.name+".xml")
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("mismatch with %s\ngot:\n%s\nwant:\n%s", path, got, want)
+			}
+
+			// Whatever we build must parse back as XML.
+			if err := xml.Unmarshal(got, new(struct{})); err != nil {
+				t.Errorf("malformed XML: %v", err)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	w := httptest.NewRecorder()
+	Write(w, NewResponse(Say{Text: "hi"}))
+	if ct := w.Header().Get("Content-Type"); ct != "text/xml" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if want := xml.Header + "<Response><Say>hi</Say></Response>"; w.Body.String() != want {
+		t.Errorf("body = %q, want %q", w.Body.String(), want)
+	}
+}
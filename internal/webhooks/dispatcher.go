@@ -0,0 +1,224 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Store persists subscriptions and the delivery queue. *database.DB
+// implements it.
+type Store interface {
+	// SubscriptionsFor returns the enabled subscriptions that want event
+	// type t.
+	SubscriptionsFor(t string) ([]*Subscription, error)
+	// Subscription returns a subscription by ID, or nil if it doesn't exist.
+	Subscription(id int64) (*Subscription, error)
+	EnqueueDelivery(d *Delivery) error
+	// DueDeliveries returns up to limit pending deliveries whose next
+	// attempt is at or before now, oldest first.
+	DueDeliveries(now time.Time, limit int) ([]*Delivery, error)
+	UpdateDelivery(d *Delivery) error
+}
+
+// MaxAttempts is how many times a delivery is tried before it is marked
+// failed.
+const MaxAttempts = 8
+
+// Retry delays double from baseDelay up to maxDelay, so a delivery is
+// given up about an hour after the first failure.
+const (
+	baseDelay = 30 * time.Second
+	maxDelay  = 30 * time.Minute
+	batchSize = 50
+)
+
+// Backoff returns how long to wait after the given number of failed
+// attempts before trying again.
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+	d := baseDelay
+	for i := 1; i < attempts && d < maxDelay; i++ {
+		d *= 2
+	}
+	if d > maxDelay {
+		d = maxDelay
+	}
+	return d
+}
+
+// Dispatcher queues events for matching subscriptions and delivers them.
+type Dispatcher struct {
+	store Store
+	http  *http.Client
+	now   func() time.Time
+	wake  chan struct{}
+}
+
+// NewDispatcher creates a Dispatcher. A nil client uses one with a 10s
+// timeout.
+func NewDispatcher(store Store, client *http.Client) *Dispatcher {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Dispatcher{store: store, http: client, now: time.Now, wake: make(chan struct{}, 1)}
+}
+
+// Publish queues event t with data for every subscription that wants it.
+// Nothing is sent here; Run (or Flush) delivers the queue, so publishing
+// never blocks the caller on a slow receiver.
+func (d *Dispatcher) Publish(t string, data interface{}) error {
+	subs, err := d.store.SubscriptionsFor(t)
+	if err != nil {
+		return fmt.Errorf("load subscriptions: %w", err)
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	now := d.now().UTC()
+	id, err := newEventID()
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(Envelope{ID: id, Type: t, CreatedAt: now, Data: data})
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", t, err)
+	}
+	for _, s := range subs {
+		del := &Delivery{
+			SubscriptionID: s.ID,
+			Event:          t,
+			Payload:        payload,
+			Status:         StatusPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		if err := d.store.EnqueueDelivery(del); err != nil {
+			return fmt.Errorf("enqueue %s for subscription %d: %w", t, s.ID, err)
+		}
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Flush attempts every delivery that is due and returns how many it tried.
+func (d *Dispatcher) Flush(ctx context.Context) (int, error) {
+	tried := 0
+	for {
+		due, err := d.store.DueDeliveries(d.now().UTC(), batchSize)
+		if err != nil {
+			return tried, fmt.Errorf("load due deliveries: %w", err)
+		}
+		for _, del := range due {
+			if err := d.attempt(ctx, del); err != nil {
+				return tried, err
+			}
+			tried++
+		}
+		// Every attempted delivery is either done or rescheduled into the
+		// future, so a short batch means the queue is drained.
+		if len(due) < batchSize || ctx.Err() != nil {
+			return tried, ctx.Err()
+		}
+	}
+}
+
+// Run delivers the queue every interval, and right away after Publish,
+// until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.Flush(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("error delivering webhooks: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// attempt sends one delivery and records the outcome. Only store errors
+// are returned; receiver failures are recorded on the delivery.
+func (d *Dispatcher) attempt(ctx context.Context, del *Delivery) error {
+	sub, err := d.store.Subscription(del.SubscriptionID)
+	if err != nil {
+		return fmt.Errorf("load subscription %d: %w", del.SubscriptionID, err)
+	}
+
+	del.Attempts++
+	if sub == nil || !sub.Enabled {
+		del.Status = StatusFailed
+		del.LastError = "subscription disabled"
+	} else {
+		del.ResponseCode, err = d.send(ctx, sub, del)
+		switch {
+		case err == nil:
+			del.Status = StatusDelivered
+			del.LastError = ""
+		case del.Attempts >= MaxAttempts:
+			del.Status = StatusFailed
+			del.LastError = err.Error()
+		default:
+			del.LastError = err.Error()
+			del.NextAttemptAt = d.now().UTC().Add(Backoff(del.Attempts))
+		}
+	}
+	del.UpdatedAt = d.now().UTC()
+	if err := d.store.UpdateDelivery(del); err != nil {
+		return fmt.Errorf("update delivery %d: %w", del.ID, err)
+	}
+	return nil
+}
+
+// send POSTs the signed payload and returns the response status code. Any
+// non-2xx response is an error.
+func (d *Dispatcher) send(ctx context.Context, sub *Subscription, del *Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(del.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "nexus-webhooks/1")
+	req.Header.Set(HeaderEvent, del.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(del.ID, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, d.now(), del.Payload))
+
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func newEventID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "evt_" + hex.EncodeToString(b), nil
+}
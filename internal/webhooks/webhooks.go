@@ -0,0 +1,173 @@
+// Package webhooks delivers nexus events to URLs other tools register. A
+// Subscription names the event types it wants; each event is queued as a
+// Delivery per matching subscription, POSTed as a signed JSON Envelope and
+// retried with exponential backoff until the receiver answers 2xx.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/events"
+)
+
+// Event types subscriptions can receive (see package events for their
+// data; every payload also carries the phone, if any).
+const (
+	EventCalCreated      = events.CalCreated
+	EventPortalSignup    = events.PortalSignup
+	EventSMSReceived     = events.SMSReceived
+	EventGiveawayClaimed = events.GiveawayClaimed
+)
+
+// EventTypes lists every event type subscriptions can receive.
+var EventTypes = []string{EventCalCreated, EventPortalSignup, EventSMSReceived, EventGiveawayClaimed}
+
+// KnownEvent reports whether t is one of EventTypes.
+func KnownEvent(t string) bool {
+	for _, e := range EventTypes {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscription is a receiver URL and the events it wants. Secret signs
+// every delivery; it is generated when the subscription is created.
+type Subscription struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks that s has an http(s) URL and only known event types,
+// renaming aliased ones (see events.Canonical).
+func (s *Subscription) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http(s) URL")
+	}
+	if len(s.Events) == 0 {
+		return errors.New("at least one event is required")
+	}
+	for i, e := range s.Events {
+		s.Events[i] = events.Canonical(e)
+		if !KnownEvent(s.Events[i]) {
+			return fmt.Errorf("unknown event %q", e)
+		}
+	}
+	return nil
+}
+
+// Wants reports whether s receives events of type t.
+func (s *Subscription) Wants(t string) bool {
+	for _, e := range s.Events {
+		if events.Canonical(e) == t {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Delivery statuses.
+const (
+	StatusPending   = "pending"   // queued or waiting to be retried
+	StatusDelivered = "delivered" // the receiver answered 2xx
+	StatusFailed    = "failed"    // gave up after MaxAttempts
+)
+
+// Delivery is one event queued for one subscription. ResponseCode and
+// LastError describe the latest attempt (0 and "" before the first).
+type Delivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseCode   int             `json:"response_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// Envelope is the JSON body POSTed to receivers.
+type Envelope struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Request headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Nexus-Event"
+	HeaderDelivery  = "X-Nexus-Delivery"
+	HeaderSignature = "X-Nexus-Signature"
+)
+
+// Sign returns the X-Nexus-Signature value for body sent at t:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>" keyed by secret>".
+// Including the timestamp lets receivers reject replayed requests.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + mac(secret, ts, body)
+}
+
+// Verify checks a signature header produced by Sign and rejects it if its
+// timestamp is more than tolerance away from now. Receivers written in Go
+// can use it directly.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return errors.New("malformed signature header")
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > tolerance || d < -tolerance {
+		return errors.New("signature timestamp outside tolerance")
+	}
+	if !hmac.Equal([]byte(sig), []byte(mac(secret, ts, body))) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func mac(secret, ts string, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(ts))
+	m.Write([]byte("."))
+	m.Write(body)
+	return hex.EncodeToString(m.Sum(nil))
+}
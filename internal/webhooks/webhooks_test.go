@@ -0,0 +1,242 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memStore is an in-memory Store.
+type memStore struct {
+	mu         sync.Mutex
+	subs       []*Subscription
+	deliveries []*Delivery
+}
+
+func (m *memStore) SubscriptionsFor(t string) ([]*Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []*Subscription
+	for _, s := range m.subs {
+		if s.Enabled && s.Wants(t) {
+			list = append(list, s)
+		}
+	}
+	return list, nil
+}
+
+func (m *memStore) Subscription(id int64) (*Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.subs {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memStore) EnqueueDelivery(d *Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d.ID = int64(len(m.deliveries) + 1)
+	c := *d
+	m.deliveries = append(m.deliveries, &c)
+	return nil
+}
+
+func (m *memStore) DueDeliveries(now time.Time, limit int) ([]*Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []*Delivery
+	for _, d := range m.deliveries {
+		if d.Status == StatusPending && !d.NextAttemptAt.After(now) && len(list) < limit {
+			c := *d
+			list = append(list, &c)
+		}
+	}
+	return list, nil
+}
+
+func (m *memStore) UpdateDelivery(d *Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := *d
+	m.deliveries[d.ID-1] = &c
+	return nil
+}
+
+func (m *memStore) delivery(id int64) Delivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return *m.deliveries[id-1]
+}
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"type":"sms.received"}`)
+	header := Sign("whsec_test", now, body)
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   string
+		now    time.Time
+		ok     bool
+	}{
+		{"valid", "whsec_test", header, string(body), now, true},
+		{"within tolerance", "whsec_test", header, string(body), now.Add(4 * time.Minute), true},
+		{"wrong secret", "whsec_other", header, string(body), now, false},
+		{"tampered body", "whsec_test", header, `{"type":"portal.user.signup"}`, now, false},
+		{"replayed", "whsec_test", header, string(body), now.Add(10 * time.Minute), false},
+		{"malformed", "whsec_test", "v1=abc", string(body), now, false},
+	}
+	for _, tt := range tests {
+		err := Verify(tt.secret, tt.header, []byte(tt.body), 5*time.Minute, tt.now)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: Verify = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{7, 30 * time.Minute},
+		{20, 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestSubscriptionValidate(t *testing.T) {
+	tests := []struct {
+		sub Subscription
+		ok  bool
+	}{
+		{Subscription{URL: "https://example.com/hook", Events: []string{EventSMSReceived}}, true},
+		{Subscription{URL: "ftp://example.com", Events: []string{EventSMSReceived}}, false},
+		{Subscription{URL: "/relative", Events: []string{EventSMSReceived}}, false},
+		{Subscription{URL: "https://example.com/hook"}, false},
+		{Subscription{URL: "https://example.com/hook", Events: []string{"sms.sent"}}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.sub.Validate(); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) = %v, want ok=%v", tt.sub, err, tt.ok)
+		}
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		statuses = []int{http.StatusInternalServerError, http.StatusOK}
+		received []Envelope
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify("whsec_a", r.Header.Get(HeaderSignature), body, 5*time.Minute, time.Now()); err != nil {
+			t.Errorf("receiver: %v", err)
+		}
+		if r.Header.Get(HeaderEvent) != EventSMSReceived || r.Header.Get(HeaderDelivery) == "" {
+			t.Errorf("receiver: headers %v", r.Header)
+		}
+		var env Envelope
+		_ = json.Unmarshal(body, &env)
+
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, env)
+		status := statuses[0]
+		if len(statuses) > 1 {
+			statuses = statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+	dead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer dead.Close()
+
+	store := &memStore{subs: []*Subscription{
+		{ID: 1, URL: receiver.URL, Secret: "whsec_a", Events: []string{EventSMSReceived}, Enabled: true},
+		{ID: 2, URL: dead.URL, Secret: "whsec_b", Events: []string{EventSMSReceived, EventCalCreated}, Enabled: true},
+		{ID: 3, URL: receiver.URL, Secret: "whsec_c", Events: []string{EventPortalSignup}, Enabled: true},
+	}}
+	now := time.Now().UTC()
+	d := NewDispatcher(store, nil)
+	d.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if err := d.Publish(EventSMSReceived, map[string]string{"phone": "15555555555", "body": "hi"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if len(store.deliveries) != 2 {
+		t.Fatalf("queued %d deliveries, want 2 (subscriptions 1 and 2)", len(store.deliveries))
+	}
+
+	// First attempt: the receiver fails once, the dead URL always fails.
+	if n, err := d.Flush(ctx); err != nil || n != 2 {
+		t.Fatalf("Flush = %d, %v", n, err)
+	}
+	first := store.delivery(1)
+	if first.Status != StatusPending || first.Attempts != 1 || first.ResponseCode != 500 ||
+		!first.NextAttemptAt.Equal(now.Add(Backoff(1))) {
+		t.Errorf("after failure: %+v", first)
+	}
+	if n, _ := d.Flush(ctx); n != 0 {
+		t.Errorf("retried %d deliveries before their backoff elapsed", n)
+	}
+
+	// Retry after the backoff: the receiver now accepts.
+	now = now.Add(Backoff(1))
+	d.Flush(ctx)
+	if got := store.delivery(1); got.Status != StatusDelivered || got.Attempts != 2 || got.ResponseCode != 200 {
+		t.Errorf("after retry: %+v", got)
+	}
+	mu.Lock()
+	if len(received) != 2 || received[0].ID != received[1].ID || received[0].Type != EventSMSReceived {
+		t.Errorf("received = %+v", received)
+	}
+	mu.Unlock()
+
+	// The dead URL is given up after MaxAttempts.
+	for i := 0; i < MaxAttempts; i++ {
+		now = now.Add(maxDelay)
+		d.Flush(ctx)
+	}
+	if got := store.delivery(2); got.Status != StatusFailed || got.Attempts != MaxAttempts || got.ResponseCode != 410 {
+		t.Errorf("dead receiver: %+v", got)
+	}
+}
+
+func TestDispatcherDisabledSubscription(t *testing.T) {
+	store := &memStore{subs: []*Subscription{
+		{ID: 1, URL: "http://127.0.0.1:0", Events: []string{EventCalCreated}, Enabled: true},
+	}}
+	d := NewDispatcher(store, nil)
+	if err := d.Publish(EventCalCreated, nil); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	store.subs[0].Enabled = false
+	d.Flush(context.Background())
+	if got := store.delivery(1); got.Status != StatusFailed || got.LastError != "subscription disabled" {
+		t.Errorf("delivery = %+v", got)
+	}
+}
@@ -0,0 +1,229 @@
+// Package scheduler runs periodic background jobs inside a service:
+// session cleanup, reminder dispatch, queue flushing and the like.
+//
+// Jobs run on a Schedule (a fixed interval or a cron expression), with
+// optional jitter. A job never overlaps itself: if it is still running when
+// it is due again, that run is skipped and counted. Stop cancels running
+// jobs' contexts and waits for them to return, so services can stop the
+// scheduler from their shutdown signal handler.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Job is a named unit of periodic work.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	// Jitter delays each run by a random duration in [0, Jitter) so
+	// replicas and neighbouring jobs don't all fire at the same instant.
+	Jitter time.Duration
+	// Timeout bounds a single run; zero means runs are only cancelled by
+	// Stop.
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// Status is a job's run history, as reported by Scheduler.Status.
+type Status struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	Running  bool   `json:"running"`
+	Runs     int    `json:"runs"`
+	Failures int    `json:"failures"`
+	// Skipped counts runs that were due while the previous one was still
+	// going.
+	Skipped        int        `json:"skipped"`
+	LastStart      *time.Time `json:"last_start,omitempty"`
+	LastDurationMS int64      `json:"last_duration_ms"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextRun        *time.Time `json:"next_run,omitempty"`
+}
+
+// Scheduler runs jobs until stopped.
+type Scheduler struct {
+	mu      sync.Mutex
+	jobs    []*entry
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+	stopped bool
+}
+
+type entry struct {
+	job    Job
+	status Status
+}
+
+// New creates a Scheduler with no jobs.
+func New() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{ctx: ctx, cancel: cancel}
+}
+
+// Add registers a job. Jobs added after Start begin right away.
+func (s *Scheduler) Add(job Job) error {
+	if job.Name == "" || job.Schedule == nil || job.Run == nil {
+		return errors.New("scheduler: job needs a name, a schedule and a run function")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.jobs {
+		if e.job.Name == job.Name {
+			return fmt.Errorf("scheduler: duplicate job %q", job.Name)
+		}
+	}
+	e := &entry{job: job, status: Status{Name: job.Name, Schedule: job.Schedule.String()}}
+	s.jobs = append(s.jobs, e)
+	if s.started && !s.stopped {
+		s.wg.Add(1)
+		go s.loop(e)
+	}
+	return nil
+}
+
+// Start begins running the registered jobs. It returns immediately.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started || s.stopped {
+		return
+	}
+	s.started = true
+	for _, e := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(e)
+	}
+}
+
+// Stop stops scheduling new runs, cancels the context of running jobs and
+// waits for them to return or for ctx to be done, whichever is first.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Status returns every job's status, sorted by name.
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Status, len(s.jobs))
+	for i, e := range s.jobs {
+		list[i] = e.status
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// ServeHTTP writes Status as JSON, for mounting on an admin route.
+func (s *Scheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Status())
+}
+
+// loop waits for each of e's run times and starts a run unless the
+// previous one is still going.
+func (s *Scheduler) loop(e *entry) {
+	defer s.wg.Done()
+	for {
+		next := e.job.Schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("scheduler: job %s has no next run time; stopping it", e.job.Name)
+			return
+		}
+		if e.job.Jitter > 0 {
+			next = next.Add(rand.N(e.job.Jitter))
+		}
+		s.mu.Lock()
+		e.status.NextRun = &next
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.mu.Lock()
+		if e.status.Running {
+			e.status.Skipped++
+			s.mu.Unlock()
+			continue
+		}
+		e.status.Running = true
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.run(e)
+	}
+}
+
+// run executes one run of e and records the outcome.
+func (s *Scheduler) run(e *entry) {
+	defer s.wg.Done()
+
+	ctx := s.ctx
+	if e.job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.job.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	s.mu.Lock()
+	e.status.LastStart = &start
+	s.mu.Unlock()
+
+	err := call(ctx, e.job.Run)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.status.Running = false
+	e.status.Runs++
+	e.status.LastDurationMS = time.Since(start).Milliseconds()
+	e.status.LastError = ""
+	if err != nil {
+		e.status.Failures++
+		e.status.LastError = err.Error()
+		log.Printf("scheduler: job %s failed: %v", e.job.Name, err)
+	}
+}
+
+// call runs fn, turning a panic into an error so one bad job can't take
+// down the service.
+func call(ctx context.Context, fn func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
@@ -0,0 +1,214 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseNext(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("no zone data: %v", err)
+	}
+	// Friday 2026-03-06 10:07:30 UTC.
+	from := time.Date(2026, 3, 6, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		loc  *time.Location
+		want time.Time
+	}{
+		{"* * * * *", nil, time.Date(2026, 3, 6, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", nil, time.Date(2026, 3, 6, 10, 15, 0, 0, time.UTC)},
+		{"5/20 * * * *", nil, time.Date(2026, 3, 6, 10, 25, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", nil, time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)},
+		{"30 2,14 * * *", nil, time.Date(2026, 3, 6, 14, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan *", nil, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", nil, time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: the 15th or any Monday.
+		{"0 0 15 * mon", nil, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", nil, time.Date(2026, 3, 6, 11, 0, 0, 0, time.UTC)},
+		{"@daily", nil, time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", nil, time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", nil, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", nil, from.Add(90 * time.Second)},
+		// 10:07 UTC is 2:07am in Los Angeles (PST, UTC-8).
+		{"0 3 * * *", la, time.Date(2026, 3, 6, 3, 0, 0, 0, la)},
+		// 2:30am doesn't exist on 2026-03-08 (DST starts), so the next
+		// match is a year later.
+		{"30 2 8 3 *", la, time.Date(2027, 3, 8, 2, 30, 0, 0, la)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr, tt.loc)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Parse(%q).Next(%v) = %v, want %v", tt.expr, from, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"@sometimes",
+		"@every soon",
+		"@every -1m",
+	} {
+		if _, err := Parse(expr, nil); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+	if s, _ := Parse("0 0 30 2 *", nil); !s.Next(time.Now()).IsZero() {
+		t.Errorf("Feb 30 should never run")
+	}
+}
+
+// waitFor polls cond until it holds or a second passes.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func status(s *Scheduler, name string) Status {
+	for _, st := range s.Status() {
+		if st.Name == name {
+			return st
+		}
+	}
+	return Status{}
+}
+
+func TestScheduler(t *testing.T) {
+	s := New()
+	var ticks atomic.Int32
+	if err := s.Add(Job{Name: "tick", Schedule: Every(10 * time.Millisecond), Run: func(ctx context.Context) error {
+		ticks.Add(1)
+		return nil
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(Job{Name: "tick", Schedule: Every(time.Second), Run: func(ctx context.Context) error { return nil }}); err == nil {
+		t.Error("duplicate job name accepted")
+	}
+	if err := s.Add(Job{Name: "broken", Schedule: Every(10 * time.Millisecond), Run: func(ctx context.Context) error {
+		return errors.New("disk full")
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(Job{Name: "panics", Schedule: Every(10 * time.Millisecond), Run: func(ctx context.Context) error {
+		panic("boom")
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Start()
+	waitFor(t, "three ticks", func() bool { return ticks.Load() >= 3 })
+	waitFor(t, "failures", func() bool { return status(s, "broken").Failures >= 1 && status(s, "panics").Failures >= 1 })
+
+	if st := status(s, "broken"); st.LastError != "disk full" || st.Schedule != "@every 10ms" || st.LastStart == nil {
+		t.Errorf("broken status = %+v", st)
+	}
+	if st := status(s, "panics"); st.LastError != "panic: boom" {
+		t.Errorf("panics status = %+v", st)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/admin/jobs", nil))
+	var list []Status
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil || len(list) != 3 || list[0].Name != "broken" {
+		t.Errorf("ServeHTTP = %s (%v)", rec.Body.String(), err)
+	}
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	n := ticks.Load()
+	time.Sleep(30 * time.Millisecond)
+	if ticks.Load() != n {
+		t.Error("job ran after Stop")
+	}
+}
+
+func TestSchedulerSingleFlight(t *testing.T) {
+	s := New()
+	var running, maxRunning atomic.Int32
+	s.Add(Job{Name: "slow", Schedule: Every(5 * time.Millisecond), Run: func(ctx context.Context) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		if n > maxRunning.Load() {
+			maxRunning.Store(n)
+		}
+		select {
+		case <-time.After(50 * time.Millisecond):
+		case <-ctx.Done():
+		}
+		return nil
+	}})
+	s.Start()
+	waitFor(t, "skipped runs", func() bool { return status(s, "slow").Skipped >= 3 })
+	if maxRunning.Load() != 1 {
+		t.Errorf("job overlapped itself: %d concurrent runs", maxRunning.Load())
+	}
+	s.Stop(context.Background())
+}
+
+func TestSchedulerStop(t *testing.T) {
+	s := New()
+	started := make(chan struct{})
+	var cancelled atomic.Bool
+	s.Add(Job{Name: "long", Schedule: Every(time.Millisecond), Run: func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		cancelled.Store(true)
+		return ctx.Err()
+	}})
+	s.Start()
+	<-started
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if !cancelled.Load() {
+		t.Error("Stop returned before the running job saw cancellation")
+	}
+
+	// A job that ignores cancellation is abandoned when ctx expires.
+	s = New()
+	release := make(chan struct{})
+	defer close(release)
+	started = make(chan struct{})
+	s.Add(Job{Name: "stubborn", Schedule: Every(time.Millisecond), Run: func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}})
+	s.Start()
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop = %v, want deadline exceeded", err)
+	}
+}
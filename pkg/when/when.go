@@ -0,0 +1,398 @@
+// Package when parses natural-language date and time phrases such as
+// "tomorrow at 9", "next tue 3:30pm", "in 2 hours" or "every monday" into
+// concrete times in a user's time zone.
+//
+// Parsing is deterministic: the current time comes from Parser.Now, which
+// tests can replace with a fixed clock.
+package when
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoTime is returned when the input contains no date or time.
+	ErrNoTime = errors.New("when: no date or time found")
+
+	// ErrUnrecognized is returned when the input contains words that are not
+	// part of a date or time phrase.
+	ErrUnrecognized = errors.New("when: unrecognized phrase")
+)
+
+// DefaultHour is the hour used when a phrase names a day but no time
+// ("friday" means friday at 9am).
+const DefaultHour = 9
+
+// Frequency is how often a recurring event repeats. Values match the
+// RFC 5545 FREQ names.
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// Recurrence describes a repeating schedule parsed from phrases like
+// "every monday" or "every 2 weeks".
+type Recurrence struct {
+	Frequency Frequency
+	Interval  int            // repeat every Interval periods (>= 1)
+	ByDay     []time.Weekday // restrict to these weekdays (weekly only)
+}
+
+// RRule renders the recurrence as an RFC 5545 RRULE value,
+// e.g. "FREQ=WEEKLY;BYDAY=MO".
+func (r *Recurrence) RRule() string {
+	rule := "FREQ=" + string(r.Frequency)
+	if r.Interval > 1 {
+		rule += ";INTERVAL=" + strconv.Itoa(r.Interval)
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = strings.ToUpper(d.String()[:2])
+		}
+		rule += ";BYDAY=" + strings.Join(days, ",")
+	}
+	return rule
+}
+
+// Result is a parsed phrase.
+type Result struct {
+	// Time is the (first) moment the phrase refers to.
+	Time time.Time
+	// HasClock reports whether the phrase named a time of day. If false,
+	// Time is at DefaultHour.
+	HasClock bool
+	// Recurrence is non-nil for repeating phrases ("every monday").
+	Recurrence *Recurrence
+}
+
+// Parser parses phrases relative to the current time in a time zone.
+type Parser struct {
+	Location *time.Location
+	Now      func() time.Time
+}
+
+// New creates a Parser for loc using the wall clock. A nil loc means UTC.
+func New(loc *time.Location) *Parser {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &Parser{Location: loc, Now: time.Now}
+}
+
+// Parse parses a phrase that consists only of a date/time expression.
+func (p *Parser) Parse(s string) (Result, error) {
+	words := tokenize(s)
+	if len(words) == 0 {
+		return Result{}, ErrNoTime
+	}
+	return p.parseWords(words)
+}
+
+// Extract splits text that ends in a date/time expression, such as
+// "dentist friday 3pm", into the leading text ("dentist") and the parsed
+// result. The longest trailing expression wins. Connector words between the
+// two ("dentist on friday") are dropped.
+func (p *Parser) Extract(s string) (string, Result, error) {
+	words := strings.Fields(s)
+	for n := 0; n < len(words); n++ {
+		r, err := p.parseWords(tokenize(strings.Join(words[n:], " ")))
+		if err != nil {
+			continue
+		}
+		return strings.Join(trimConnectors(words[:n]), " "), r, nil
+	}
+	return s, Result{}, ErrNoTime
+}
+
+func (p *Parser) now() time.Time {
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return now().In(loc)
+}
+
+// phrase accumulates the pieces of a parsed expression.
+type phrase struct {
+	// Calendar date, if given explicitly ("march 5", "3/5").
+	month, day, year int
+	// Days from today ("tomorrow" = 1), or -1 if not given.
+	dayOffset int
+	// Weekday, if given. strict means today doesn't count ("friday" on a
+	// Friday means next week); "this friday" allows today.
+	weekday    *time.Weekday
+	weekStrict bool
+	// Time of day, or hour = -1 if not given.
+	hour, minute int
+	// Relative offset ("in 2 hours").
+	relative time.Duration
+	months   int // relative months ("next month")
+	// Recurrence, if any.
+	recur *Recurrence
+}
+
+func (p *Parser) parseWords(words []string) (Result, error) {
+	ph := phrase{dayOffset: -1, hour: -1}
+	seen := false
+	expectHour := false
+
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		next := ""
+		if i+1 < len(words) {
+			next = words[i+1]
+		}
+
+		switch {
+		case w == "at" || w == "@":
+			expectHour = true
+			continue
+		case w == "on" || w == "by" || w == "the":
+			continue
+
+		case w == "now" && !seen:
+			ph.dayOffset = 0
+			n := p.now()
+			ph.hour, ph.minute = n.Hour(), n.Minute()
+
+		case w == "today" && ph.dayOffset < 0:
+			ph.dayOffset = 0
+		case w == "tonight" && ph.dayOffset < 0:
+			ph.dayOffset = 0
+			if ph.hour < 0 {
+				ph.hour = 20
+			}
+		case (w == "tomorrow" || w == "tmrw" || w == "tmr") && ph.dayOffset < 0:
+			ph.dayOffset = 1
+
+		case w == "in" && next != "":
+			d, months, used, ok := parseRelative(words[i+1:])
+			if !ok {
+				return Result{}, unrecognized(w)
+			}
+			ph.relative += d
+			ph.months += months
+			i += used
+
+		case (w == "next" || w == "this") && next != "":
+			if wd, ok := weekdays[next]; ok {
+				ph.weekday = &wd
+				ph.weekStrict = w == "next"
+				i++
+				break
+			}
+			if w == "next" && next == "week" {
+				ph.relative += 7 * 24 * time.Hour
+				ph.dayOffset = 0
+				i++
+				break
+			}
+			if w == "next" && next == "month" {
+				ph.months++
+				ph.dayOffset = 0
+				i++
+				break
+			}
+			return Result{}, unrecognized(w + " " + next)
+
+		case w == "every" && next != "":
+			r, used, ok := parseEvery(words[i+1:])
+			if !ok {
+				return Result{}, unrecognized(w + " " + next)
+			}
+			ph.recur = r
+			i += used
+		case w == "daily":
+			ph.recur = &Recurrence{Frequency: Daily, Interval: 1}
+		case w == "weekly":
+			ph.recur = &Recurrence{Frequency: Weekly, Interval: 1}
+		case w == "monthly":
+			ph.recur = &Recurrence{Frequency: Monthly, Interval: 1}
+
+		case isWeekday(w) && ph.weekday == nil:
+			wd := weekdays[w]
+			ph.weekday = &wd
+			ph.weekStrict = true
+
+		case isMonth(w) && next != "":
+			d, ok := parseDayOfMonth(next)
+			if !ok {
+				return Result{}, unrecognized(w + " " + next)
+			}
+			ph.month, ph.day = months[w], d
+			i++
+			if y, ok := parseYear(words, i+1); ok {
+				ph.year = y
+				i++
+			}
+		case isDayOfMonth(w) && isMonth(next):
+			ph.day, _ = parseDayOfMonth(w)
+			ph.month = months[next]
+			i++
+			if y, ok := parseYear(words, i+1); ok {
+				ph.year = y
+				i++
+			}
+		case strings.ContainsAny(w, "/-") && ph.month == 0:
+			y, m, d, ok := parseNumericDate(w)
+			if !ok {
+				return Result{}, unrecognized(w)
+			}
+			ph.year, ph.month, ph.day = y, m, d
+
+		case ph.hour < 0 && isPartOfDay(w):
+			ph.hour, ph.minute = partsOfDay[w], 0
+		case ph.hour < 0:
+			// Allow "3 pm" as two words.
+			if next == "am" || next == "pm" {
+				w += next
+				i++
+			}
+			h, m, ok := parseClock(w, expectHour)
+			if !ok {
+				return Result{}, unrecognized(w)
+			}
+			ph.hour, ph.minute = h, m
+
+		default:
+			return Result{}, unrecognized(w)
+		}
+		seen = true
+		expectHour = false
+	}
+
+	if !seen {
+		return Result{}, ErrNoTime
+	}
+	return p.resolve(ph)
+}
+
+// resolve turns a phrase into a concrete time. It fails for calendar
+// dates that don't exist ("feb 30").
+func (p *Parser) resolve(ph phrase) (Result, error) {
+	now := p.now()
+	loc := now.Location()
+	res := Result{HasClock: ph.hour >= 0, Recurrence: ph.recur}
+
+	// Relative offsets ("in 2 hours") count from now unless a clock time
+	// was also given ("in 2 days at 5pm").
+	if ph.relative > 0 || ph.months > 0 {
+		t := now.AddDate(0, ph.months, 0).Add(ph.relative)
+		if ph.hour >= 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), ph.hour, ph.minute, 0, 0, loc)
+		} else if ph.relative%(24*time.Hour) == 0 {
+			// Whole days: "next week" means that day at the default hour.
+			t = time.Date(t.Year(), t.Month(), t.Day(), DefaultHour, 0, 0, 0, loc)
+		} else {
+			res.HasClock = true
+		}
+		res.Time = t
+		return res, nil
+	}
+
+	hour, minute := ph.hour, ph.minute
+	if hour < 0 {
+		hour, minute = DefaultHour, 0
+	}
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, hour, minute, 0, 0, loc)
+
+	// A recurring weekday schedule with no explicit day starts on the next
+	// matching day (today counts if the time is still ahead).
+	if ph.recur != nil && len(ph.recur.ByDay) > 0 && ph.weekday == nil && ph.dayOffset < 0 {
+		for _, wd := range ph.recur.ByDay {
+			offset := (int(wd) - int(now.Weekday()) + 7) % 7
+			if offset == 0 && !today.After(now) {
+				offset = 7
+			}
+			if ph.dayOffset < 0 || offset < ph.dayOffset {
+				ph.dayOffset = offset
+			}
+		}
+	}
+
+	switch {
+	case ph.month != 0:
+		month := time.Month(ph.month)
+		year := ph.year
+		if year == 0 {
+			// A month and day without a year that has already passed
+			// means next year ("jan 5" said in December).
+			year = y
+			if time.Date(year, month, ph.day, 0, 0, 0, 0, loc).Before(startOfDay(now)) {
+				year++
+			}
+		}
+		if ph.day > daysIn(month, year) {
+			return Result{}, unrecognized(fmt.Sprintf("%s %d", month, ph.day))
+		}
+		res.Time = time.Date(year, month, ph.day, hour, minute, 0, 0, loc)
+	case ph.weekday != nil:
+		offset := (int(*ph.weekday) - int(now.Weekday()) + 7) % 7
+		if offset == 0 && (ph.weekStrict || !today.After(now)) {
+			offset = 7
+		}
+		res.Time = today.AddDate(0, 0, offset)
+	case ph.dayOffset >= 0:
+		res.Time = today.AddDate(0, 0, ph.dayOffset)
+	default:
+		// Time only (or "every day"): the next time the clock reads it.
+		t := today
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		res.Time = t
+	}
+	return res, nil
+}
+
+// daysIn returns the number of days in month m of year.
+func daysIn(m time.Month, year int) int {
+	return time.Date(year, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func unrecognized(s string) error {
+	return fmt.Errorf("%w: %q", ErrUnrecognized, s)
+}
+
+// tokenize lower-cases s and splits it into words, dropping commas and
+// trailing periods.
+func tokenize(s string) []string {
+	s = strings.ToLower(s)
+	s = strings.ReplaceAll(s, ",", " ")
+	words := strings.Fields(s)
+	for i, w := range words {
+		words[i] = strings.TrimRight(w, ".!?")
+	}
+	return words
+}
+
+// trimConnectors drops trailing filler words ("dentist on" -> "dentist").
+func trimConnectors(words []string) []string {
+	for len(words) > 0 {
+		switch strings.ToLower(words[len(words)-1]) {
+		case "at", "on", "by", "for", "in", "every":
+			words = words[:len(words)-1]
+		default:
+			return words
+		}
+	}
+	return words
+}
@@ -0,0 +1,174 @@
+package when
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var seattle = mustLoad("America/Los_Angeles")
+
+func mustLoad(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// testParser is fixed at Wednesday, March 4 2026, 10:00 in Seattle.
+func testParser() *Parser {
+	p := New(seattle)
+	p.Now = func() time.Time { return time.Date(2026, 3, 4, 10, 0, 0, 0, seattle) }
+	return p
+}
+
+func at(month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2026, month, day, hour, minute, 0, 0, seattle)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		want     time.Time
+		hasClock bool
+	}{
+		{"now", at(3, 4, 10, 0), true},
+		{"today", at(3, 4, 9, 0), false},
+		{"tonight", at(3, 4, 20, 0), true},
+		{"tomorrow", at(3, 5, 9, 0), false},
+		{"tomorrow at 9", at(3, 5, 9, 0), true},
+		{"tomorrow at 3", at(3, 5, 15, 0), true},
+		{"Tomorrow 7:45am", at(3, 5, 7, 45), true},
+		{"tmrw noon", at(3, 5, 12, 0), true},
+		{"3pm", at(3, 4, 15, 0), true},
+		{"3 pm", at(3, 4, 15, 0), true},
+		{"9am", at(3, 5, 9, 0), true}, // already past today
+		{"at 17:30", at(3, 4, 17, 30), true},
+		{"930p", at(3, 4, 21, 30), true},
+		{"friday", at(3, 6, 9, 0), false},
+		{"friday 3pm", at(3, 6, 15, 0), true},
+		{"wednesday", at(3, 11, 9, 0), false}, // today is Wednesday
+		{"this wednesday 5pm", at(3, 4, 17, 0), true},
+		{"next tue 3:30pm", at(3, 10, 15, 30), true},
+		{"on friday at 8:15am", at(3, 6, 8, 15), true},
+		{"friday morning", at(3, 6, 9, 0), true},
+		{"saturday evening", at(3, 7, 18, 0), true},
+		{"in 2 hours", at(3, 4, 12, 0), true},
+		{"in 45 minutes", at(3, 4, 10, 45), true},
+		{"in an hour", at(3, 4, 11, 0), true},
+		{"in 3 days", at(3, 7, 9, 0), false},
+		{"in 2 days at 5pm", at(3, 6, 17, 0), true},
+		{"next week", at(3, 11, 9, 0), false},
+		{"next month", at(4, 4, 9, 0), false},
+		{"march 20", at(3, 20, 9, 0), false},
+		{"Mar 20th at 2pm", at(3, 20, 14, 0), true},
+		{"20 march", at(3, 20, 9, 0), false},
+		{"3/20", at(3, 20, 9, 0), false},
+		{"2026-03-20 10:15", at(3, 20, 10, 15), true},
+		{"jan 5", time.Date(2027, 1, 5, 9, 0, 0, 0, seattle), false}, // already passed this year
+		{"jan 5 2026", time.Date(2026, 1, 5, 9, 0, 0, 0, seattle), false},
+		{"feb 29 2028", time.Date(2028, 2, 29, 9, 0, 0, 0, seattle), false}, // leap year
+	}
+
+	p := testParser()
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := p.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			if !got.Time.Equal(tt.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tt.input, got.Time, tt.want)
+			}
+			if got.HasClock != tt.hasClock {
+				t.Errorf("Parse(%q).HasClock = %v, want %v", tt.input, got.HasClock, tt.hasClock)
+			}
+			if got.Recurrence != nil {
+				t.Errorf("Parse(%q) unexpectedly recurring: %+v", tt.input, got.Recurrence)
+			}
+		})
+	}
+}
+
+func TestParse_Recurrence(t *testing.T) {
+	tests := []struct {
+		input string
+		first time.Time
+		rrule string
+	}{
+		{"every monday", at(3, 9, 9, 0), "FREQ=WEEKLY;BYDAY=MO"},
+		{"every wednesday at 5pm", at(3, 4, 17, 0), "FREQ=WEEKLY;BYDAY=WE"},
+		{"every wednesday at 8am", at(3, 11, 8, 0), "FREQ=WEEKLY;BYDAY=WE"},
+		{"every day at 8pm", at(3, 4, 20, 0), "FREQ=DAILY"},
+		{"daily 7am", at(3, 5, 7, 0), "FREQ=DAILY"},
+		{"every weekday at 9:30am", at(3, 5, 9, 30), "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		{"every 2 weeks", at(3, 5, 9, 0), "FREQ=WEEKLY;INTERVAL=2"},
+		{"every other friday", at(3, 6, 9, 0), "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR"},
+		{"monthly", at(3, 5, 9, 0), "FREQ=MONTHLY"},
+	}
+
+	p := testParser()
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := p.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			if got.Recurrence == nil {
+				t.Fatalf("Parse(%q): expected recurrence", tt.input)
+			}
+			if rr := got.Recurrence.RRule(); rr != tt.rrule {
+				t.Errorf("Parse(%q).RRule = %q, want %q", tt.input, rr, tt.rrule)
+			}
+			if !got.Time.Equal(tt.first) {
+				t.Errorf("Parse(%q) first = %v, want %v", tt.input, got.Time, tt.first)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	p := testParser()
+
+	if _, err := p.Parse(""); !errors.Is(err, ErrNoTime) {
+		t.Errorf("empty input: expected ErrNoTime, got %v", err)
+	}
+	for _, input := range []string{"dentist", "call mom 2", "13pm", "in two weeks ago", "every blue moon", "32/1",
+		"feb 30", "2/30", "april 31 2026", "feb 29", "in 0 hours", "in 0 days"} {
+		if got, err := p.Parse(input); !errors.Is(err, ErrUnrecognized) {
+			t.Errorf("Parse(%q) = (%v, %v), expected ErrUnrecognized", input, got.Time, err)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantText string
+		want     time.Time
+	}{
+		{"dentist friday 3pm", "dentist", at(3, 6, 15, 0)},
+		{"call mom tomorrow at 9:30am", "call mom", at(3, 5, 9, 30)},
+		{"standup on wed", "standup", at(3, 11, 9, 0)},
+		{"take meds at 8 pm", "take meds", at(3, 4, 20, 0)},
+		{"Check the oven in 20 minutes", "Check the oven", at(3, 4, 10, 20)},
+		{"tomorrow", "", at(3, 5, 9, 0)},
+	}
+
+	p := testParser()
+	for _, tt := range tests {
+		text, got, err := p.Extract(tt.input)
+		if err != nil {
+			t.Errorf("Extract(%q): %v", tt.input, err)
+			continue
+		}
+		if text != tt.wantText || !got.Time.Equal(tt.want) {
+			t.Errorf("Extract(%q) = (%q, %v), want (%q, %v)", tt.input, text, got.Time, tt.wantText, tt.want)
+		}
+	}
+
+	if _, _, err := p.Extract("call mom 2"); !errors.Is(err, ErrNoTime) {
+		t.Errorf("Extract without time: expected ErrNoTime, got %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+package when
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "weds": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// Plural weekday names, used after "every" ("every mondays" is tolerated).
+func weekdayName(s string) (time.Weekday, bool) {
+	if wd, ok := weekdays[s]; ok {
+		return wd, true
+	}
+	wd, ok := weekdays[strings.TrimSuffix(s, "s")]
+	return wd, ok
+}
+
+func isWeekday(s string) bool {
+	_, ok := weekdays[s]
+	return ok
+}
+
+var months = map[string]int{
+	"jan": 1, "january": 1,
+	"feb": 2, "february": 2,
+	"mar": 3, "march": 3,
+	"apr": 4, "april": 4,
+	"may": 5,
+	"jun": 6, "june": 6,
+	"jul": 7, "july": 7,
+	"aug": 8, "august": 8,
+	"sep": 9, "sept": 9, "september": 9,
+	"oct": 10, "october": 10,
+	"nov": 11, "november": 11,
+	"dec": 12, "december": 12,
+}
+
+func isMonth(s string) bool {
+	_, ok := months[s]
+	return ok
+}
+
+// partsOfDay maps vague times of day to an hour.
+var partsOfDay = map[string]int{
+	"morning":   9,
+	"noon":      12,
+	"midday":    12,
+	"afternoon": 15,
+	"evening":   18,
+	"night":     20,
+	"midnight":  0,
+}
+
+func isPartOfDay(s string) bool {
+	_, ok := partsOfDay[s]
+	return ok
+}
+
+// units maps relative-time unit words to durations. Months are handled
+// separately because they vary in length.
+var units = map[string]time.Duration{
+	"min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"day": 24 * time.Hour, "days": 24 * time.Hour,
+	"week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour, "wk": 7 * 24 * time.Hour, "wks": 7 * 24 * time.Hour,
+}
+
+// parseCount parses "2", "a", "an" or a small number word.
+func parseCount(s string) (int, bool) {
+	switch s {
+	case "a", "an", "one":
+		return 1, true
+	case "two":
+		return 2, true
+	case "three":
+		return 3, true
+	case "few":
+		return 3, true
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// parseRelative parses the words after "in": "2 hours", "an hour",
+// "3 days", "1 month". It returns the duration or months and how many words
+// it consumed.
+func parseRelative(words []string) (d time.Duration, monthsAhead, used int, ok bool) {
+	if len(words) < 2 {
+		return 0, 0, 0, false
+	}
+	n, ok := parseCount(words[0])
+	if !ok || n <= 0 {
+		return 0, 0, 0, false
+	}
+	unit := words[1]
+	if unit == "month" || unit == "months" {
+		return 0, n, 2, true
+	}
+	u, ok := units[unit]
+	if !ok {
+		return 0, 0, 0, false
+	}
+	return time.Duration(n) * u, 0, 2, true
+}
+
+// parseEvery parses the words after "every": "monday", "day", "week",
+// "month", "weekday", "2 weeks", "other day".
+func parseEvery(words []string) (*Recurrence, int, bool) {
+	w := words[0]
+	switch w {
+	case "day":
+		return &Recurrence{Frequency: Daily, Interval: 1}, 1, true
+	case "week":
+		return &Recurrence{Frequency: Weekly, Interval: 1}, 1, true
+	case "month":
+		return &Recurrence{Frequency: Monthly, Interval: 1}, 1, true
+	case "weekday", "weekdays":
+		return &Recurrence{
+			Frequency: Weekly,
+			Interval:  1,
+			ByDay:     []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		}, 1, true
+	case "other":
+		if len(words) > 1 {
+			if r, _, ok := parseEvery(words[1:]); ok && len(r.ByDay) <= 1 {
+				r.Interval = 2
+				return r, 2, true
+			}
+		}
+		return nil, 0, false
+	}
+
+	if wd, ok := weekdayName(w); ok {
+		return &Recurrence{Frequency: Weekly, Interval: 1, ByDay: []time.Weekday{wd}}, 1, true
+	}
+
+	if n, ok := parseCount(w); ok && n > 0 && len(words) > 1 {
+		switch words[1] {
+		case "day", "days":
+			return &Recurrence{Frequency: Daily, Interval: n}, 2, true
+		case "week", "weeks":
+			return &Recurrence{Frequency: Weekly, Interval: n}, 2, true
+		case "month", "months":
+			return &Recurrence{Frequency: Monthly, Interval: n}, 2, true
+		}
+	}
+	return nil, 0, false
+}
+
+// parseDayOfMonth parses "5", "5th", "21st", "22nd", "3rd".
+func parseDayOfMonth(s string) (int, bool) {
+	for _, suffix := range []string{"st", "nd", "rd", "th"} {
+		s = strings.TrimSuffix(s, suffix)
+	}
+	d, err := strconv.Atoi(s)
+	if err != nil || d < 1 || d > 31 {
+		return 0, false
+	}
+	return d, true
+}
+
+func isDayOfMonth(s string) bool {
+	_, ok := parseDayOfMonth(s)
+	return ok
+}
+
+// parseYear reports whether words[i] is a four-digit year.
+func parseYear(words []string, i int) (int, bool) {
+	if i >= len(words) || len(words[i]) != 4 {
+		return 0, false
+	}
+	y, err := strconv.Atoi(words[i])
+	if err != nil || y < 1970 {
+		return 0, false
+	}
+	return y, true
+}
+
+// parseNumericDate parses ISO "2026-03-05" and US "3/5" or "3/5/2026".
+// A zero year means "not given".
+func parseNumericDate(s string) (year, month, day int, ok bool) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t.Year(), int(t.Month()), t.Day(), true
+	}
+
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, 0, 0, false
+	}
+	m, err1 := strconv.Atoi(parts[0])
+	d, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || m < 1 || m > 12 || d < 1 || d > 31 {
+		return 0, 0, 0, false
+	}
+	if len(parts) == 3 {
+		y, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		if y < 100 {
+			y += 2000
+		}
+		year = y
+	}
+	return year, m, d, true
+}
+
+// parseClock parses "3pm", "3:30pm", "15:00" and, when expectHour is set
+// (the word followed "at"), a bare hour such as "9". Bare hours from 1 to 6
+// are assumed to be afternoon ("at 3" means 3pm).
+func parseClock(s string, expectHour bool) (hour, minute int, ok bool) {
+	suffix := ""
+	if strings.HasSuffix(s, "am") || strings.HasSuffix(s, "pm") {
+		suffix = s[len(s)-2:]
+		s = s[:len(s)-2]
+	} else if strings.HasSuffix(s, "a") || strings.HasSuffix(s, "p") {
+		// "3p", "930a"
+		suffix = s[len(s)-1:] + "m"
+		s = s[:len(s)-1]
+	}
+
+	hs, ms, hasMinute := strings.Cut(s, ":")
+	if !hasMinute && suffix != "" && len(s) >= 3 {
+		// "930pm" -> 9:30pm
+		hs, ms, hasMinute = s[:len(s)-2], s[len(s)-2:], true
+	}
+	h, err := strconv.Atoi(hs)
+	if err != nil || h < 0 {
+		return 0, 0, false
+	}
+	if hasMinute {
+		if minute, err = strconv.Atoi(ms); err != nil || len(ms) != 2 || minute > 59 {
+			return 0, 0, false
+		}
+	} else if suffix == "" && !expectHour {
+		// A bare number is too ambiguous ("call mom 2") to be a time.
+		return 0, 0, false
+	}
+
+	switch suffix {
+	case "am", "pm":
+		if h < 1 || h > 12 {
+			return 0, 0, false
+		}
+		h %= 12
+		if suffix == "pm" {
+			h += 12
+		}
+	default:
+		if h > 23 {
+			return 0, 0, false
+		}
+		if !hasMinute && h >= 1 && h <= 6 {
+			h += 12
+		}
+	}
+	return h, minute, true
+}
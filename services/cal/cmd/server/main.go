@@ -18,10 +18,14 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // embed zone data for "timezone" in event requests
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	"github.com/jredh-dev/nexus/internal/events"
+	smshandlers "github.com/jredh-dev/nexus/internal/handlers"
+	"github.com/jredh-dev/nexus/pkg/scheduler"
 	"github.com/jredh-dev/nexus/services/cal/config"
 	"github.com/jredh-dev/nexus/services/cal/internal/database"
 	"github.com/jredh-dev/nexus/services/cal/internal/handlers"
@@ -53,6 +57,22 @@ func main() {
 	defer db.Close()
 
 	h := handlers.New(db)
+	if cfg.NexusURL != "" && cfg.InternalToken != "" {
+		h.SetEvents(events.NewPublisher(cfg.NexusURL, cfg.InternalToken))
+	} else {
+		log.Println("NEXUS_URL or INTERNAL_API_TOKEN is empty — new events won't be published for webhooks")
+	}
+
+	// Nightly database maintenance, off-peak for US time zones.
+	jobs := scheduler.New()
+	if err := jobs.Add(scheduler.Job{
+		Name:     "optimize-db",
+		Schedule: scheduler.MustParse("30 10 * * *", time.UTC),
+		Timeout:  5 * time.Minute,
+		Run:      db.Optimize,
+	}); err != nil {
+		log.Fatalf("Failed to schedule optimize-db: %v", err)
+	}
 
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
@@ -80,6 +100,9 @@ func main() {
 
 		r.Post("/events", h.CreateEvent)
 		r.Delete("/events/{id}", h.DeleteEvent)
+
+		// Job status is for operators only.
+		r.With(smshandlers.InternalAPIMiddleware(cfg.InternalToken)).Get("/jobs", jobs.ServeHTTP)
 	})
 
 	addr := ":" + cfg.Port
@@ -100,11 +123,16 @@ func main() {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
+		if err := jobs.Stop(ctx); err != nil {
+			log.Printf("Scheduler shutdown error: %v", err)
+		}
 		if err := srv.Shutdown(ctx); err != nil {
 			log.Printf("Server shutdown error: %v", err)
 		}
 	}()
 
+	jobs.Start()
+
 	log.Printf("nexus-cal starting on %s", addr)
 	log.Printf("  Subscribe: webcal://localhost%s/{token}.ics", addr)
 	log.Printf("  API:       http://localhost%s/api/", addr)
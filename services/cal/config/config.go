@@ -8,6 +8,11 @@ import (
 type Config struct {
 	Port   string
 	DBPath string
+
+	// NexusURL is the SMS server's base URL. When set (with InternalToken),
+	// every new event is reported to its /events API for webhooks.
+	NexusURL      string
+	InternalToken string // shared INTERNAL_API_TOKEN
 }
 
 func envOr(key, fallback string) string {
@@ -22,5 +27,8 @@ func Load() *Config {
 	return &Config{
 		Port:   envOr("CAL_PORT", "8085"),
 		DBPath: envOr("CAL_DB_PATH", "cal.db"),
+
+		NexusURL:      envOr("NEXUS_URL", ""),
+		InternalToken: envOr("INTERNAL_API_TOKEN", ""),
 	}
 }
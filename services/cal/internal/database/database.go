@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -93,6 +94,19 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
+// Optimize refreshes query planner statistics and truncates the
+// write-ahead log, which otherwise only shrinks when the last connection
+// closes. It is run periodically by the job scheduler.
+func (db *DB) Optimize(ctx context.Context) error {
+	if _, err := db.conn.ExecContext(ctx, `PRAGMA optimize`); err != nil {
+		return fmt.Errorf("optimize: %w", err)
+	}
+	if _, err := db.conn.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	return nil
+}
+
 // --- Feed operations ---
 
 // CreateFeed inserts a new feed.
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
@@ -193,3 +194,16 @@ func TestCascadeDelete(t *testing.T) {
 		t.Error("expected event to be deleted via cascade")
 	}
 }
+
+func TestOptimize(t *testing.T) {
+	db := testDB(t)
+	if err := db.CreateFeed(&Feed{ID: "feed-1", Name: "Test", Token: "tok", CreatedAt: time.Now().UTC()}); err != nil {
+		t.Fatalf("create feed: %v", err)
+	}
+	if err := db.Optimize(context.Background()); err != nil {
+		t.Fatalf("optimize: %v", err)
+	}
+	if f, err := db.FeedByID("feed-1"); err != nil || f == nil {
+		t.Errorf("feed lost after optimize: %v, %v", f, err)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/jredh-dev/nexus/internal/events"
+	"github.com/jredh-dev/nexus/pkg/when"
 	"github.com/jredh-dev/nexus/services/cal/internal/database"
 	"github.com/jredh-dev/nexus/services/cal/internal/ical"
 )
@@ -20,12 +22,20 @@ var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}[a-z0-9]$`)
 
 // Handler holds dependencies for HTTP handlers.
 type Handler struct {
-	db *database.DB
+	db     *database.DB
+	now    func() time.Time // clock for parsing "when" phrases
+	events *events.Publisher
 }
 
 // New creates a new Handler.
 func New(db *database.DB) *Handler {
-	return &Handler{db: db}
+	return &Handler{db: db, now: time.Now}
+}
+
+// SetEvents reports every event created through the API to the nexus SMS
+// server as a cal.event.created event. Without it nothing is published.
+func (h *Handler) SetEvents(p *events.Publisher) {
+	h.events = p
 }
 
 // --- Subscription endpoint (served to calendar clients) ---
@@ -182,8 +192,10 @@ type createEventReq struct {
 	Description string  `json:"description"`
 	Location    string  `json:"location"`
 	URL         string  `json:"url"`
-	Start       string  `json:"start"` // RFC 3339
-	End         *string `json:"end"`   // RFC 3339, optional
+	Start       string  `json:"start"`    // RFC 3339
+	When        string  `json:"when"`     // natural language, e.g. "next tue 3:30pm" (alternative to start)
+	Timezone    string  `json:"timezone"` // IANA zone for "when", default UTC
+	End         *string `json:"end"`      // RFC 3339, optional
 	AllDay      bool    `json:"all_day"`
 	Deadline    *string `json:"deadline"` // RFC 3339, optional
 	Status      string  `json:"status"`
@@ -198,15 +210,46 @@ func (h *Handler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 		jsonError(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
-	if req.FeedID == "" || req.Summary == "" || req.Start == "" {
-		jsonError(w, "feed_id, summary, and start are required", http.StatusBadRequest)
+	if req.FeedID == "" || req.Summary == "" || (req.Start == "" && req.When == "") {
+		jsonError(w, "feed_id, summary, and start (or when) are required", http.StatusBadRequest)
 		return
 	}
 
-	start, err := time.Parse(time.RFC3339, req.Start)
-	if err != nil {
-		jsonError(w, "start must be RFC 3339 format", http.StatusBadRequest)
-		return
+	var start time.Time
+	if req.Start != "" {
+		t, err := time.Parse(time.RFC3339, req.Start)
+		if err != nil {
+			jsonError(w, "start must be RFC 3339 format", http.StatusBadRequest)
+			return
+		}
+		start = t
+	} else {
+		loc := time.UTC
+		if req.Timezone != "" {
+			l, err := time.LoadLocation(req.Timezone)
+			if err != nil {
+				jsonError(w, "timezone must be an IANA time zone name", http.StatusBadRequest)
+				return
+			}
+			loc = l
+		}
+
+		parsed, err := (&when.Parser{Location: loc, Now: h.now}).Parse(req.When)
+		if err != nil {
+			jsonError(w, "could not understand when: "+req.When, http.StatusBadRequest)
+			return
+		}
+		if parsed.Recurrence != nil {
+			jsonError(w, "recurring events are not supported", http.StatusBadRequest)
+			return
+		}
+		start = parsed.Time
+		// A day without a time of day ("march 20") is an all-day event.
+		if !parsed.HasClock {
+			y, m, d := start.Date()
+			start = time.Date(y, m, d, 0, 0, 0, 0, loc)
+			req.AllDay = true
+		}
 	}
 
 	var end *time.Time
@@ -257,10 +300,29 @@ func (h *Handler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 		jsonError(w, "failed to create event", http.StatusInternalServerError)
 		return
 	}
+	h.publishCreated(event)
 
 	jsonOK(w, http.StatusCreated, event)
 }
 
+// publishCreated reports a new event, if SetEvents was called.
+func (h *Handler) publishCreated(e *database.Event) {
+	if h.events == nil {
+		return
+	}
+	data := map[string]string{
+		"event_id": e.ID,
+		"feed_id":  e.FeedID,
+		"summary":  e.Summary,
+		"start":    e.Start.Format(time.RFC3339),
+		"location": e.Location,
+	}
+	if e.End != nil {
+		data["end"] = e.End.Format(time.RFC3339)
+	}
+	h.events.Go(&events.Event{Type: events.CalCreated, Data: data})
+}
+
 // ListEvents returns all events for a feed.
 // GET /api/feeds/{id}/events
 func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
@@ -8,9 +8,11 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/jredh-dev/nexus/internal/events"
 	"github.com/jredh-dev/nexus/services/cal/internal/database"
 )
 
@@ -376,3 +378,110 @@ func TestCreateFeed_WithoutSlug_GetsUUID(t *testing.T) {
 		t.Errorf("expected UUID token (36 chars), got %q (%d chars)", created.Token, len(created.Token))
 	}
 }
+
+func TestCreateEvent_NaturalLanguageWhen(t *testing.T) {
+	h := testHandler(t)
+	// Wednesday, March 4 2026, 10:00 UTC.
+	h.now = func() time.Time { return time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC) }
+	r := testRouter(h)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/feeds", strings.NewReader(`{"name":"NL"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var feed createFeedResp
+	if err := json.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("unmarshal feed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		when       string
+		timezone   string
+		wantStart  time.Time
+		wantAllDay bool
+		wantStatus int
+	}{
+		{"weekday and time", "next tue 3:30pm", "", time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC), false, http.StatusCreated},
+		{"with timezone", "tomorrow at 9am", "America/New_York", time.Date(2026, 3, 5, 14, 0, 0, 0, time.UTC), false, http.StatusCreated},
+		{"date only is all day", "march 20", "", time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC), true, http.StatusCreated},
+		{"recurring rejected", "every monday", "", time.Time{}, false, http.StatusBadRequest},
+		{"gibberish rejected", "whenever", "", time.Time{}, false, http.StatusBadRequest},
+		{"bad timezone", "tomorrow", "Mars/Olympus_Mons", time.Time{}, false, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]interface{}{
+				"feed_id":  feed.ID,
+				"summary":  tt.name,
+				"when":     tt.when,
+				"timezone": tt.timezone,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/events", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+
+			var e database.Event
+			if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil {
+				t.Fatalf("unmarshal event: %v", err)
+			}
+			if !e.Start.Equal(tt.wantStart) {
+				t.Errorf("start = %v, want %v", e.Start, tt.wantStart)
+			}
+			if e.AllDay != tt.wantAllDay {
+				t.Errorf("all_day = %v, want %v", e.AllDay, tt.wantAllDay)
+			}
+		})
+	}
+}
+
+func TestCreateEvent_Publishes(t *testing.T) {
+	published := make(chan events.Event, 1)
+	nexus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/events" || r.Header.Get("Authorization") != "Bearer internal-secret" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var ev events.Event
+		_ = json.NewDecoder(r.Body).Decode(&ev)
+		published <- ev
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer nexus.Close()
+
+	h := testHandler(t)
+	h.SetEvents(events.NewPublisher(nexus.URL, "internal-secret"))
+	r := testRouter(h)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/feeds", strings.NewReader(`{"name":"Test"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var feed createFeedResp
+	if err := json.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("unmarshal feed: %v", err)
+	}
+
+	body := `{"feed_id":"` + feed.ID + `","summary":"Dentist","start":"2026-03-02T15:00:00Z"}`
+	req = httptest.NewRequest(http.MethodPost, "/api/events", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create event: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	select {
+	case ev := <-published:
+		if ev.Type != events.CalCreated || ev.Data["feed_id"] != feed.ID || ev.Data["summary"] != "Dentist" ||
+			ev.Data["start"] != "2026-03-02T15:00:00Z" || ev.Data["event_id"] == "" {
+			t.Errorf("published = %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not published")
+	}
+}
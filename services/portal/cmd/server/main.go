@@ -16,6 +16,9 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/jredh-dev/nexus/gen/portal/v1/portalv1connect"
+	"github.com/jredh-dev/nexus/internal/assistant"
+	"github.com/jredh-dev/nexus/internal/events"
+	"github.com/jredh-dev/nexus/pkg/scheduler"
 	"github.com/jredh-dev/nexus/services/portal/config"
 	"github.com/jredh-dev/nexus/services/portal/internal/actions"
 	"github.com/jredh-dev/nexus/services/portal/internal/auth"
@@ -48,6 +51,10 @@ func main() {
 		cfg.Session.Secret = "insecure-dev-secret-change-me"
 	}
 
+	if cfg.Internal.Token == "" {
+		log.Println("WARNING: INTERNAL_API_TOKEN is empty — /internal routes are disabled")
+	}
+
 	// Initialize SQLite database.
 	db, err := database.New(cfg.DB.Path)
 	if err != nil {
@@ -57,6 +64,19 @@ func main() {
 
 	// Initialize auth service.
 	authService := auth.New(db, cfg)
+	if err := configureChallenges(authService, cfg); err != nil {
+		log.Fatalf("Failed to configure security challenges: %v", err)
+	}
+
+	// Report signups and giveaway claims to the SMS server's rules engine
+	// and webhooks.
+	var publisher *events.Publisher
+	if cfg.Internal.NexusURL != "" && cfg.Internal.Token != "" {
+		publisher = events.NewPublisher(cfg.Internal.NexusURL, cfg.Internal.Token)
+		authService.SetEvents(publisher)
+	} else {
+		log.Println("NEXUS_URL or INTERNAL_API_TOKEN is empty — signups and giveaway claims won't be published as events")
+	}
 
 	// Initialize actions registry (shared between HTTP handlers and RPC).
 	actionsRegistry := actions.New()
@@ -67,6 +87,9 @@ func main() {
 	// Seed admin user (dev@jredh.com) in all environments.
 	seedAdminUser(db, authService)
 
+	// Background jobs.
+	jobs := newJobs(db, authService)
+
 	// Initialize router.
 	r := chi.NewRouter()
 
@@ -84,6 +107,9 @@ func main() {
 
 	// Initialize handlers.
 	h := handlers.New(db, cfg, authService, actionsRegistry)
+	if publisher != nil {
+		h.SetEvents(publisher)
+	}
 
 	// Connect RPC handlers (Astro frontend talks to these).
 	authPath, authHandler := portalv1connect.NewAuthServiceHandler(
@@ -104,6 +130,37 @@ func main() {
 	// Public JSON API.
 	r.Route("/api", func(r chi.Router) {
 		r.Get("/actions", h.SearchActions)
+
+		// Community Q&A (login required).
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.AuthMiddleware(authService))
+			r.Get("/qa/circle", h.GetCircle)
+			r.Post("/qa/circle", h.AddCircleMember)
+			r.Delete("/qa/circle/{username}", h.RemoveCircleMember)
+			r.Get("/qa/questions", h.ListQuestions)
+			r.Post("/qa/questions", h.CreateQuestion)
+			r.Get("/qa/questions/{id}", h.GetQuestion)
+			r.Post("/qa/questions/{id}/answers", h.CreateAnswer)
+			r.Put("/qa/answers/{id}/vote", h.Upvote)
+			r.Delete("/qa/answers/{id}/vote", h.RemoveUpvote)
+
+			// Security challenges (step-up verification).
+			r.Post("/challenge", h.StartChallenge)
+			r.Post("/challenge/{id}", h.AnswerChallenge)
+		})
+	})
+
+	// Internal service-to-service API (shared bearer token required).
+	r.Route("/internal", func(r chi.Router) {
+		r.Use(handlers.InternalAPIMiddleware(cfg.Internal.Token))
+		r.Get("/users/by-phone-hash/{hash}", h.InternalUserByPhoneHash)
+		r.Post("/magic-links", h.InternalCreateMagicLink)
+		r.Post("/questions", h.InternalCreateQuestion)
+		r.Get("/answer-relays", h.InternalAnswerRelays)
+		r.Post("/answer-relays/{id}/done", h.InternalAnswerRelayed)
+		r.Post("/escalations", h.InternalCreateEscalation)
+		r.Get("/escalation-relays", h.InternalEscalationRelays)
+		r.Post("/escalation-relays/{id}/done", h.InternalEscalationRelayed)
 	})
 
 	// Admin routes (login + admin role required).
@@ -113,6 +170,20 @@ func main() {
 
 		// Admin utilities.
 		r.Post("/admin/magic-link", h.AdminGenerateMagicLink)
+		r.Get("/admin/jobs", jobs.ServeHTTP)
+		r.Put("/admin/users/{id}/role", h.AdminSetUserRole)
+
+		// Community Q&A moderation.
+		r.Get("/admin/qa/questions", h.AdminListQuestions)
+		r.Delete("/admin/qa/questions/{id}", h.AdminHideQuestion)
+		r.Delete("/admin/qa/answers/{id}", h.AdminHideAnswer)
+
+		// Human-in-the-loop escalation queue.
+		r.Get("/admin/escalations", h.AdminListEscalations)
+		r.Get("/admin/escalations/{id}", h.AdminGetEscalation)
+		r.Post("/admin/escalations/{id}/claim", h.AdminClaimEscalation)
+		r.Post("/admin/escalations/{id}/answer", h.AdminAnswerEscalation)
+		r.Post("/admin/escalations/{id}/dismiss", h.AdminDismissEscalation)
 	})
 
 	// Start server.
@@ -134,11 +205,16 @@ func main() {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
+		if err := jobs.Stop(ctx); err != nil {
+			log.Printf("Scheduler shutdown error: %v", err)
+		}
 		if err := srv.Shutdown(ctx); err != nil {
 			log.Printf("Server shutdown error: %v", err)
 		}
 	}()
 
+	jobs.Start()
+
 	log.Printf("Portal server starting on %s (env: %s)", addr, cfg.Server.Env)
 	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
 		log.Fatalf("Server error: %v", err)
@@ -146,6 +222,65 @@ func main() {
 	log.Println("Server stopped")
 }
 
+// newJobs registers the portal's periodic maintenance jobs.
+func newJobs(db *database.DB, authService *auth.Service) *scheduler.Scheduler {
+	jobs := scheduler.New()
+	for _, job := range []scheduler.Job{
+		{
+			Name:     "clean-sessions",
+			Schedule: scheduler.Every(time.Hour),
+			Jitter:   5 * time.Minute,
+			Run: func(ctx context.Context) error {
+				return authService.CleanExpiredSessions()
+			},
+		},
+		{
+			Name:     "clean-magic-tokens",
+			Schedule: scheduler.Every(time.Hour),
+			Jitter:   5 * time.Minute,
+			Run: func(ctx context.Context) error {
+				return db.DeleteExpiredMagicTokens()
+			},
+		},
+	} {
+		if err := jobs.Add(job); err != nil {
+			log.Fatalf("Failed to schedule %s: %v", job.Name, err)
+		}
+	}
+	return jobs
+}
+
+// configureChallenges selects the security question generator
+// (CHALLENGE_PROVIDER) and the data questions draw on: the notes and
+// reminders the user texted to the SMS server at NEXUS_URL. Without it
+// challenges are refused, and so are the role changes that need them.
+func configureChallenges(authService *auth.Service, cfg *config.Config) error {
+	if cfg.Internal.NexusURL == "" || cfg.Internal.Token == "" {
+		log.Println("WARNING: NEXUS_URL or INTERNAL_API_TOKEN is empty — security challenges and role changes are disabled")
+		return nil
+	}
+	sources := []auth.FactSource{
+		auth.NewNotesFacts(cfg.Internal.NexusURL, cfg.Internal.Token),
+		auth.NewReminderFacts(cfg.Internal.NexusURL, cfg.Internal.Token),
+	}
+
+	switch cfg.Challenge.Provider {
+	case "openai":
+		if cfg.Challenge.URL == "" || cfg.Challenge.Model == "" {
+			return fmt.Errorf("CHALLENGE_PROVIDER=openai requires CHALLENGE_URL and CHALLENGE_MODEL")
+		}
+		model := assistant.NewOpenAI(cfg.Challenge.URL, cfg.Challenge.APIKey, cfg.Challenge.Model)
+		model.SystemPrompt = auth.ModelSystemPrompt
+		authService.SetChallenges(auth.ModelGenerator{Model: model}, sources...)
+		log.Printf("Security questions via %s (model %s)", cfg.Challenge.URL, cfg.Challenge.Model)
+	case "stub":
+		authService.SetChallenges(auth.StubGenerator{}, sources...)
+	default:
+		return fmt.Errorf("unknown CHALLENGE_PROVIDER %q (want stub or openai)", cfg.Challenge.Provider)
+	}
+	return nil
+}
+
 // seedDemoUser ensures the demo account exists in all environments.
 func seedDemoUser(authService *auth.Service) {
 	_, err := authService.Login("demo@demo.com", "demo", "seed", "seed")
@@ -7,15 +7,21 @@ import (
 
 // Config holds all application configuration.
 type Config struct {
-	Server  ServerConfig
-	DB      DBConfig
-	Session SessionConfig
+	Server    ServerConfig
+	DB        DBConfig
+	Session   SessionConfig
+	Internal  InternalConfig
+	Challenge ChallengeConfig
 }
 
 // ServerConfig holds HTTP server settings.
 type ServerConfig struct {
 	Port string
 	Env  string
+
+	// PublicURL is the browser-facing base URL (e.g. https://portal.example.com)
+	// used in links sent out of band. If empty, links use the request host.
+	PublicURL string
 }
 
 // DBConfig holds database settings.
@@ -29,12 +35,36 @@ type SessionConfig struct {
 	MaxAge int    // session duration in seconds (default: 7 days)
 }
 
+// InternalConfig holds settings for the service-to-service API used by
+// other nexus services (e.g. the SMS server).
+type InternalConfig struct {
+	Token string // shared bearer token; empty disables /internal routes
+
+	// NexusURL is the SMS server's base URL. When set (with Token),
+	// signups and giveaway claims are reported to its /events API and
+	// security questions draw on the user's texted notes and reminders;
+	// without it security challenges are refused.
+	NexusURL string
+}
+
+// ChallengeConfig selects how security questions are generated.
+type ChallengeConfig struct {
+	// Provider is "stub" for deterministic fill-in-the-blank questions or
+	// "openai" for a model behind an OpenAI-compatible chat completions API.
+	Provider string
+	URL      string // API base URL including version, e.g. https://api.openai.com/v1
+	APIKey   string
+	Model    string
+}
+
 // Load returns application configuration from environment variables.
 func Load() *Config {
 	return &Config{
 		Server: ServerConfig{
 			Port: getEnv("PORT", "8080"),
 			Env:  getEnv("ENV", "development"),
+
+			PublicURL: getEnv("PUBLIC_URL", ""),
 		},
 		DB: DBConfig{
 			Path: getEnv("DB_PATH", "portal.db"),
@@ -43,6 +73,16 @@ func Load() *Config {
 			Secret: getEnv("SESSION_SECRET", ""),
 			MaxAge: getEnvInt("SESSION_MAX_AGE", 604800), // 7 days
 		},
+		Internal: InternalConfig{
+			Token:    getEnv("INTERNAL_API_TOKEN", ""),
+			NexusURL: getEnv("NEXUS_URL", ""),
+		},
+		Challenge: ChallengeConfig{
+			Provider: getEnv("CHALLENGE_PROVIDER", "stub"),
+			URL:      getEnv("CHALLENGE_URL", "https://api.openai.com/v1"),
+			APIKey:   getEnv("CHALLENGE_API_KEY", ""),
+			Model:    getEnv("CHALLENGE_MODEL", "gpt-4o-mini"),
+		},
 	}
 }
 
@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/jredh-dev/nexus/services/portal/pkg/models"
+)
+
+const (
+	challengeExpiry      = 5 * time.Minute  // time to answer a challenge
+	stepUpWindow         = 10 * time.Minute // how long a passed challenge authorizes sensitive actions
+	maxChallengeFailures = 3                // wrong answers allowed per lockout period
+	challengeLockout     = 15 * time.Minute // lockout period
+	maxChallengeFacts    = 20               // facts fetched from each source
+	minChallengeFactAge  = 24 * time.Hour   // newer facts could have been planted for the challenge
+)
+
+// Fact is a piece of a user's own data, such as a note they texted in,
+// that a security question can be drawn from.
+type Fact struct {
+	Kind string // what the fact is, e.g. "note" or "reminder"
+	Text string
+	At   time.Time
+}
+
+// FactSource supplies facts about a user, newest first.
+type FactSource interface {
+	Facts(ctx context.Context, user *models.User, limit int) ([]Fact, error)
+}
+
+// FactSourceFunc adapts a function to FactSource.
+type FactSourceFunc func(ctx context.Context, user *models.User, limit int) ([]Fact, error)
+
+// Facts calls f.
+func (f FactSourceFunc) Facts(ctx context.Context, user *models.User, limit int) ([]Fact, error) {
+	return f(ctx, user, limit)
+}
+
+// Generator writes a security question and its expected answer from a
+// user's facts. Only someone who knows the facts should be able to answer.
+type Generator interface {
+	Generate(ctx context.Context, facts []Fact) (question, answer string, err error)
+}
+
+// SetChallenges replaces the security question generator and the sources
+// it draws on. Sources must only hold data a session hijacker can't add
+// (see NexusFacts). New configures StubGenerator and no sources, so
+// challenges, and the sensitive actions behind them, are refused until
+// SetChallenges is called.
+func (s *Service) SetChallenges(gen Generator, sources ...FactSource) {
+	s.challengeGen = gen
+	s.factSources = sources
+}
+
+// StartChallenge generates a security question for user from their own
+// data, ignoring anything from the last minChallengeFactAge. It returns
+// ErrChallengesDisabled if no fact sources are configured,
+// ErrChallengeLocked after too many wrong answers and ErrNoChallengeFacts
+// if there is nothing to ask about. Sources that fail are logged and
+// skipped.
+func (s *Service) StartChallenge(ctx context.Context, user *models.User) (*models.Challenge, error) {
+	if len(s.factSources) == 0 {
+		return nil, ErrChallengesDisabled
+	}
+	if _, locked, err := s.ChallengeLockedUntil(user.ID); err != nil {
+		return nil, err
+	} else if locked {
+		return nil, ErrChallengeLocked
+	}
+
+	var facts []Fact
+	cutoff := time.Now().Add(-minChallengeFactAge)
+	for _, src := range s.factSources {
+		f, err := src.Facts(ctx, user, maxChallengeFacts)
+		if err != nil {
+			log.Printf("Failed to load challenge facts for %s: %v", user.ID, err)
+			continue
+		}
+		for _, fact := range f {
+			if !fact.At.IsZero() && fact.At.Before(cutoff) {
+				facts = append(facts, fact)
+			}
+		}
+	}
+	if len(facts) == 0 {
+		return nil, ErrNoChallengeFacts
+	}
+
+	question, answer, err := s.challengeGen.Generate(ctx, facts)
+	if err != nil {
+		return nil, fmt.Errorf("generate challenge: %w", err)
+	}
+	if question == "" || normalizeAnswer(answer) == "" {
+		return nil, ErrNoChallengeFacts
+	}
+
+	now := time.Now()
+	c := &models.Challenge{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		Question:  question,
+		Answer:    answer,
+		ExpiresAt: now.Add(challengeExpiry),
+		CreatedAt: now,
+	}
+	if err := s.db.CreateChallenge(c); err != nil {
+		return nil, fmt.Errorf("store challenge: %w", err)
+	}
+	return c, nil
+}
+
+// AnswerChallenge checks userID's answer to one of their open challenges.
+// It returns ErrWrongAnswer if the answer doesn't match, and
+// ErrChallengeLocked, without checking, once too many answers in a row
+// have been wrong.
+func (s *Service) AnswerChallenge(userID, challengeID, answer string) error {
+	if _, locked, err := s.ChallengeLockedUntil(userID); err != nil {
+		return err
+	} else if locked {
+		return ErrChallengeLocked
+	}
+
+	c, err := s.db.GetChallenge(challengeID)
+	if err != nil {
+		return fmt.Errorf("get challenge: %w", err)
+	}
+	now := time.Now()
+	if c == nil || c.UserID != userID || c.PassedAt != nil || now.After(c.ExpiresAt) {
+		return ErrChallengeNotFound
+	}
+
+	ok := AnswerMatches(answer, c.Answer)
+	if err := s.db.RecordChallengeAttempt(c.ID, userID, ok, now); err != nil {
+		return fmt.Errorf("record challenge attempt: %w", err)
+	}
+	if !ok {
+		return ErrWrongAnswer
+	}
+	return nil
+}
+
+// ChallengeLockedUntil reports whether userID is locked out of challenges
+// and, if so, until when.
+func (s *Service) ChallengeLockedUntil(userID string) (time.Time, bool, error) {
+	now := time.Now()
+	failures, err := s.db.GetChallengeFailures(userID, now.Add(-challengeLockout))
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("count challenge failures: %w", err)
+	}
+	if len(failures) < maxChallengeFailures {
+		return time.Time{}, false, nil
+	}
+	// Locked until the oldest failure that still counts ages out.
+	return failures[maxChallengeFailures-1].Add(challengeLockout), true, nil
+}
+
+// SteppedUp reports whether userID has passed a challenge recently enough
+// to perform a sensitive action.
+func (s *Service) SteppedUp(userID string) (bool, error) {
+	passed, err := s.db.GetLastChallengePass(userID)
+	if err != nil {
+		return false, fmt.Errorf("get last challenge pass: %w", err)
+	}
+	return !passed.IsZero() && time.Since(passed) < stepUpWindow, nil
+}
+
+// AnswerMatches reports whether answer is close enough to expected.
+// Case, punctuation and the articles "a", "an" and "the" are ignored, and
+// longer answers may contain a typo or two (one edit per five letters).
+func AnswerMatches(answer, expected string) bool {
+	a, e := normalizeAnswer(answer), normalizeAnswer(expected)
+	if a == "" || e == "" {
+		return false
+	}
+	return editDistance(a, e) <= len([]rune(e))/5
+}
+
+// normalizeAnswer lowercases s, turns punctuation into spaces and drops
+// articles.
+func normalizeAnswer(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, s)
+	var words []string
+	for _, w := range strings.Fields(s) {
+		if w != "a" && w != "an" && w != "the" {
+			words = append(words, w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
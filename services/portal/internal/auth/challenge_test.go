@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/assistant"
+)
+
+func TestAnswerMatches(t *testing.T) {
+	for _, tt := range []struct {
+		answer, expected string
+		want             bool
+	}{
+		{"kumquats", "kumquats", true},
+		{"  Kumquats! ", "kumquats", true},
+		{"the kumquats", "kumquats", true},
+		{"kumquat", "kumquats", true},   // one edit in eight letters
+		{"kumkwats", "kumquats", false}, // two edits
+		{"El Farolito", "el farolito", true},
+		{"dog", "dig", false}, // short answers must be exact
+		{"", "kumquats", false},
+		{"the", "the", false},
+	} {
+		if got := AnswerMatches(tt.answer, tt.expected); got != tt.want {
+			t.Errorf("AnswerMatches(%q, %q) = %v, want %v", tt.answer, tt.expected, got, tt.want)
+		}
+	}
+}
+
+func TestStubGenerator(t *testing.T) {
+	at := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	facts := []Fact{
+		{Kind: "note", Text: "ok bye", At: at},
+		{Kind: "note", Text: "pick up kumquats, then the dentist", At: at},
+	}
+	q, a, err := StubGenerator{}.Generate(context.Background(), facts)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if a != "kumquats" || q != `Fill in the blank from your note of Mar 4: "pick up ___, then the dentist"` {
+		t.Errorf("Generate = %q, %q", q, a)
+	}
+	if q, a, _ := (StubGenerator{}).Generate(context.Background(), facts[:1]); q != "" || a != "" {
+		t.Errorf("no usable facts: got %q, %q", q, a)
+	}
+}
+
+type cannedModel struct {
+	reply string
+	req   *assistant.Request
+}
+
+func (m *cannedModel) Reply(ctx context.Context, req *assistant.Request) (string, error) {
+	m.req = req
+	return m.reply, nil
+}
+
+func TestModelGenerator(t *testing.T) {
+	model := &cannedModel{reply: "Sure!\n```json\n{\"question\": \"What fruit did you plan to buy on March 4?\", \"answer\": \"kumquats\"}\n```"}
+	facts := []Fact{{Kind: "note", Text: "pick up kumquats", At: time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)}}
+	q, a, err := ModelGenerator{Model: model}.Generate(context.Background(), facts)
+	if err != nil || q != "What fruit did you plan to buy on March 4?" || a != "kumquats" {
+		t.Errorf("Generate = %q, %q, %v", q, a, err)
+	}
+	if got := model.req.History[0].Content; got != "Facts:\n- 2026-03-04, note: pick up kumquats\n" {
+		t.Errorf("prompt = %q", got)
+	}
+
+	model.reply = "I can't help with that."
+	if _, _, err := (ModelGenerator{Model: model}).Generate(context.Background(), facts); err == nil {
+		t.Error("non-JSON reply: want error")
+	}
+}
@@ -12,4 +12,10 @@ var (
 	ErrUsernameTaken      = errors.New("this username is already taken")
 	ErrInvalidMagicToken  = errors.New("invalid or expired magic login token")
 	ErrForbidden          = errors.New("forbidden: admin access required")
+	ErrChallengeNotFound  = errors.New("challenge not found or expired")
+	ErrWrongAnswer        = errors.New("incorrect answer")
+	ErrChallengeLocked    = errors.New("too many incorrect answers; try again later")
+	ErrNoChallengeFacts   = errors.New("not enough of your own data to ask a security question")
+	ErrChallengesDisabled = errors.New("security challenges are not configured")
+	ErrStepUpRequired     = errors.New("answer a security challenge first")
 )
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+	"github.com/jredh-dev/nexus/services/portal/pkg/models"
+)
+
+// NexusFacts draws on private data a user keeps on the nexus SMS server,
+// which only reaches it from their phone: texted notes or nexus-cal
+// reminders. Nothing the portal itself stores (such as Q&A posts) is used,
+// since anyone holding a session could plant it.
+type NexusFacts struct {
+	baseURL string
+	token   string
+	path    string // internal API listing the user's items
+	kind    string // Fact.Kind of the items
+	http    *http.Client
+}
+
+// NewNotesFacts creates a source of the notes a user has texted in,
+// fetched from the SMS server's /internal/notes API at baseURL with the
+// shared internal API token.
+func NewNotesFacts(baseURL, token string) *NexusFacts {
+	return newNexusFacts(baseURL, token, "/internal/notes", "note")
+}
+
+// NewReminderFacts creates a source of the events in a user's nexus-cal
+// reminder feed, fetched from the SMS server's /internal/reminders API.
+func NewReminderFacts(baseURL, token string) *NexusFacts {
+	return newNexusFacts(baseURL, token, "/internal/reminders", "reminder")
+}
+
+func newNexusFacts(baseURL, token, path, kind string) *NexusFacts {
+	return &NexusFacts{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		path:    path,
+		kind:    kind,
+		http:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Facts implements FactSource. Users without a phone number have none.
+// Each fact is dated when the item was created, not when it is about.
+func (n *NexusFacts) Facts(ctx context.Context, user *models.User, limit int) ([]Fact, error) {
+	if user.PhoneNumber == "" {
+		return nil, nil
+	}
+	q := url.Values{"phone": {identity.NormalizePhone(user.PhoneNumber)}, "limit": {strconv.Itoa(limit)}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.baseURL+n.path+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+n.token)
+
+	resp, err := n.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("list %ss: %w", n.kind, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list %ss: status %d", n.kind, resp.StatusCode)
+	}
+
+	var items []struct {
+		Body      string    `json:"body"`    // notes
+		Summary   string    `json:"summary"` // reminders
+		CreatedAt time.Time `json:"created_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		return nil, fmt.Errorf("decode %ss: %w", n.kind, err)
+	}
+	facts := make([]Fact, 0, len(items))
+	for _, it := range items {
+		text := it.Body
+		if text == "" {
+			text = it.Summary
+		}
+		facts = append(facts, Fact{Kind: n.kind, Text: text, At: it.CreatedAt})
+	}
+	return facts, nil
+}
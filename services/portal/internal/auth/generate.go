@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jredh-dev/nexus/internal/assistant"
+)
+
+// StubGenerator is a deterministic Generator for tests and deployments
+// without a model. It blanks out the longest word of the first fact that
+// has one of at least minStubWord letters and asks for it back.
+type StubGenerator struct{}
+
+const minStubWord = 5
+
+// Generate implements Generator.
+func (StubGenerator) Generate(ctx context.Context, facts []Fact) (string, string, error) {
+	for _, f := range facts {
+		tokens := strings.Fields(f.Text)
+		best, word := -1, ""
+		for i, tok := range tokens {
+			w := strings.TrimFunc(tok, func(r rune) bool { return !unicode.IsLetter(r) })
+			if n := len([]rune(w)); n >= minStubWord && n > len([]rune(word)) {
+				best, word = i, w
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		tokens[best] = strings.Replace(tokens[best], word, "___", 1)
+		question := fmt.Sprintf("Fill in the blank from your %s of %s: %q",
+			f.Kind, f.At.Format("Jan 2"), strings.Join(tokens, " "))
+		return question, word, nil
+	}
+	return "", "", nil
+}
+
+// ModelSystemPrompt is the system prompt for a chat model behind a
+// ModelGenerator (see assistant.OpenAI.SystemPrompt). It is formatted with
+// the reply budget in characters.
+const ModelSystemPrompt = "You write security questions that only one person can answer, " +
+	"using facts from their own notes and reminders. Ask about one specific detail, " +
+	"never quote the answer in the question, and keep the answer to one to three words. " +
+	`Reply with only a JSON object {"question": "...", "answer": "..."} in at most %d characters.`
+
+// modelReplyChars bounds a ModelGenerator reply.
+const modelReplyChars = 400
+
+// ModelGenerator asks a chat model to write the question, so questions
+// vary and read naturally. The model should be configured with
+// ModelSystemPrompt.
+type ModelGenerator struct {
+	Model assistant.Assistant
+}
+
+// Generate implements Generator.
+func (g ModelGenerator) Generate(ctx context.Context, facts []Fact) (string, string, error) {
+	var b strings.Builder
+	b.WriteString("Facts:\n")
+	for _, f := range facts {
+		fmt.Fprintf(&b, "- %s, %s: %s\n", f.At.Format("2006-01-02"), f.Kind, f.Text)
+	}
+	reply, err := g.Model.Reply(ctx, &assistant.Request{
+		History:  []assistant.Turn{{Role: assistant.RoleUser, Content: b.String()}},
+		MaxChars: modelReplyChars,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	// Tolerate prose or code fences around the object.
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return "", "", fmt.Errorf("model reply is not JSON: %q", reply)
+	}
+	var out struct {
+		Question string `json:"question"`
+		Answer   string `json:"answer"`
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &out); err != nil {
+		return "", "", fmt.Errorf("decode model reply: %w", err)
+	}
+	return strings.TrimSpace(out.Question), strings.TrimSpace(out.Answer), nil
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jredh-dev/nexus/internal/events"
 	"github.com/jredh-dev/nexus/services/portal/config"
 	"github.com/jredh-dev/nexus/services/portal/internal/database"
 	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
@@ -20,11 +21,25 @@ const bcryptCost = 12
 type Service struct {
 	db  *database.DB
 	cfg *config.Config
+
+	challengeGen Generator
+	factSources  []FactSource
+	events       *events.Publisher
 }
 
 // New creates a new auth service.
 func New(db *database.DB, cfg *config.Config) *Service {
-	return &Service{db: db, cfg: cfg}
+	return &Service{
+		db:           db,
+		cfg:          cfg,
+		challengeGen: StubGenerator{},
+	}
+}
+
+// SetEvents reports every new signup to the nexus SMS server as a
+// portal.user.signup event. Without it signups are not published.
+func (s *Service) SetEvents(p *events.Publisher) {
+	s.events = p
 }
 
 // HashPassword hashes a plaintext password with bcrypt.
@@ -44,6 +59,7 @@ func CheckPassword(password, hash string) error {
 // Signup registers a new user after checking for identity duplicates.
 // It normalizes and hashes the email and phone number, then checks
 // that no existing user shares the same username, email hash, or phone hash.
+// The signup is published as an event if SetEvents was called.
 func (s *Service) Signup(username, email, phone, password, name string) (*models.User, error) {
 	// Check username uniqueness.
 	existing, err := s.db.GetUserByUsername(username)
@@ -101,6 +117,13 @@ func (s *Service) Signup(username, email, phone, password, name string) (*models
 	if err := s.db.CreateUser(user); err != nil {
 		return nil, fmt.Errorf("create user: %w", err)
 	}
+	if s.events != nil {
+		s.events.Go(&events.Event{
+			Type:  events.PortalSignup,
+			Phone: identity.NormalizePhone(phone),
+			Data:  map[string]string{"user_id": user.ID, "username": user.Username, "name": user.Name},
+		})
+	}
 	return user, nil
 }
 
@@ -217,6 +240,20 @@ func (s *Service) UpdateUserRole(userID, role string) error {
 	return s.db.UpdateUserRole(userID, role)
 }
 
+// ChangeUserRole changes a user's role on behalf of actor, who must have
+// passed a security challenge within the step-up window. It returns
+// ErrStepUpRequired otherwise.
+func (s *Service) ChangeUserRole(actor *models.User, userID, role string) error {
+	ok, err := s.SteppedUp(actor.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrStepUpRequired
+	}
+	return s.UpdateUserRole(userID, role)
+}
+
 // --- Magic link operations ---
 
 const (
@@ -234,7 +271,24 @@ func (s *Service) CreateMagicToken(email string) (string, error) {
 	if user == nil {
 		return "", ErrUserNotFound
 	}
+	return s.createMagicToken(user)
+}
+
+// CreateMagicTokenByPhoneHash generates a one-time login token for the user
+// whose normalized phone hashes to hash (see identity.PhoneHash). Used to
+// deliver login links by SMS.
+func (s *Service) CreateMagicTokenByPhoneHash(hash string) (string, error) {
+	user, err := s.db.GetUserByPhoneHash(hash)
+	if err != nil {
+		return "", fmt.Errorf("lookup user: %w", err)
+	}
+	if user == nil {
+		return "", ErrUserNotFound
+	}
+	return s.createMagicToken(user)
+}
 
+func (s *Service) createMagicToken(user *models.User) (string, error) {
 	// Generate a cryptographically secure random token.
 	tokenBytes := make([]byte, magicTokenBytes)
 	if _, err := rand.Read(tokenBytes); err != nil {
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jredh-dev/nexus/services/portal/pkg/models"
+)
+
+// CreateChallenge inserts a new security challenge.
+func (db *DB) CreateChallenge(c *models.Challenge) error {
+	const q = `INSERT INTO challenges (id, user_id, question, answer, expires_at, created_at)
+	           VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := db.conn.Exec(q, c.ID, c.UserID, c.Question, c.Answer, c.ExpiresAt, c.CreatedAt)
+	return err
+}
+
+// GetChallenge returns a challenge by ID, or nil if there is none.
+func (db *DB) GetChallenge(id string) (*models.Challenge, error) {
+	const q = `SELECT id, user_id, question, answer, expires_at, passed_at, created_at
+	           FROM challenges WHERE id = ?`
+	c := &models.Challenge{}
+	var passed sql.NullTime
+	err := db.conn.QueryRow(q, id).Scan(&c.ID, &c.UserID, &c.Question, &c.Answer, &c.ExpiresAt, &passed, &c.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if passed.Valid {
+		c.PassedAt = &passed.Time
+	}
+	return c, err
+}
+
+// RecordChallengeAttempt logs an answer to a challenge and, if it was
+// right, marks the challenge passed.
+func (db *DB) RecordChallengeAttempt(challengeID, userID string, ok bool, at time.Time) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const insert = `INSERT INTO challenge_attempts (challenge_id, user_id, ok, created_at) VALUES (?, ?, ?, ?)`
+	if _, err := tx.Exec(insert, challengeID, userID, ok, at); err != nil {
+		return err
+	}
+	if ok {
+		if _, err := tx.Exec(`UPDATE challenges SET passed_at = ? WHERE id = ?`, at, challengeID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetChallengeFailures returns when userID answered a challenge wrong
+// since the given time, newest first.
+func (db *DB) GetChallengeFailures(userID string, since time.Time) ([]time.Time, error) {
+	const q = `SELECT created_at FROM challenge_attempts
+	           WHERE user_id = ? AND ok = 0 AND created_at > ? ORDER BY created_at DESC`
+	rows, err := db.conn.Query(q, userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var times []time.Time
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, rows.Err()
+}
+
+// GetLastChallengePass returns when userID last passed a challenge, or
+// the zero time if they never have.
+func (db *DB) GetLastChallengePass(userID string) (time.Time, error) {
+	const q = `SELECT passed_at FROM challenges
+	           WHERE user_id = ? AND passed_at IS NOT NULL ORDER BY passed_at DESC LIMIT 1`
+	var t time.Time
+	err := db.conn.QueryRow(q, userID).Scan(&t)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return t, err
+}
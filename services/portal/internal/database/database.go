@@ -81,6 +81,94 @@ func migrate(conn *sql.DB) error {
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_magic_tokens_user_id ON magic_tokens(user_id);
+
+	CREATE TABLE IF NOT EXISTS challenges (
+		id         TEXT PRIMARY KEY,
+		user_id    TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		question   TEXT NOT NULL,
+		answer     TEXT NOT NULL,
+		expires_at DATETIME NOT NULL,
+		passed_at  DATETIME,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_challenges_user_id ON challenges(user_id, passed_at);
+
+	CREATE TABLE IF NOT EXISTS challenge_attempts (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		challenge_id TEXT NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+		user_id      TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		ok           INTEGER NOT NULL,
+		created_at   DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_challenge_attempts_user_id ON challenge_attempts(user_id, created_at);
+
+	CREATE TABLE IF NOT EXISTS circle_members (
+		owner_id   TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		member_id  TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (owner_id, member_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_circle_members_member_id ON circle_members(member_id);
+
+	CREATE TABLE IF NOT EXISTS questions (
+		id         TEXT PRIMARY KEY,
+		user_id    TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		body       TEXT NOT NULL,
+		source     TEXT NOT NULL DEFAULT 'web',
+		hidden_at  DATETIME,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_questions_user_id ON questions(user_id);
+
+	CREATE TABLE IF NOT EXISTS answers (
+		id          TEXT PRIMARY KEY,
+		question_id TEXT NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+		user_id     TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		body        TEXT NOT NULL,
+		hidden_at   DATETIME,
+		relayed_at  DATETIME,
+		created_at  DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_answers_question_id ON answers(question_id);
+
+	CREATE TABLE IF NOT EXISTS answer_votes (
+		answer_id  TEXT NOT NULL REFERENCES answers(id) ON DELETE CASCADE,
+		user_id    TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (answer_id, user_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS escalations (
+		id          TEXT PRIMARY KEY,
+		phone       TEXT NOT NULL,
+		user_id     TEXT NOT NULL DEFAULT '',
+		body        TEXT NOT NULL,
+		reason      TEXT NOT NULL DEFAULT '',
+		status      TEXT NOT NULL DEFAULT 'open',
+		assignee_id TEXT NOT NULL DEFAULT '',
+		answer      TEXT NOT NULL DEFAULT '',
+		created_at  DATETIME NOT NULL,
+		updated_at  DATETIME NOT NULL,
+		relayed_at  DATETIME
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_escalations_status ON escalations(status, created_at);
+
+	CREATE TABLE IF NOT EXISTS escalation_events (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		escalation_id TEXT NOT NULL REFERENCES escalations(id) ON DELETE CASCADE,
+		actor_id      TEXT NOT NULL DEFAULT '',
+		action        TEXT NOT NULL,
+		note          TEXT NOT NULL DEFAULT '',
+		created_at    DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_escalation_events_escalation_id ON escalation_events(escalation_id);
 	`
 	if _, err := conn.Exec(ddl); err != nil {
 		return err
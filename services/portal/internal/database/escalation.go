@@ -0,0 +1,202 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jredh-dev/nexus/services/portal/pkg/models"
+)
+
+// escalationColumns is the SELECT column list for escalation queries over
+// escalations e joined with the sender (s) and assignee (a) users.
+const escalationColumns = `e.id, e.phone, COALESCE(s.username, ''), e.body, e.reason, e.status,
+	e.assignee_id, COALESCE(a.username, ''), e.answer, e.created_at, e.updated_at, e.relayed_at`
+
+const escalationFrom = ` FROM escalations e
+	LEFT JOIN users s ON s.id = e.user_id
+	LEFT JOIN users a ON a.id = e.assignee_id`
+
+func scanEscalation(row interface{ Scan(...interface{}) error }) (*models.Escalation, error) {
+	e := &models.Escalation{}
+	var relayed sql.NullTime
+	err := row.Scan(&e.ID, &e.Phone, &e.Username, &e.Body, &e.Reason, &e.Status,
+		&e.AssigneeID, &e.Assignee, &e.Answer, &e.CreatedAt, &e.UpdatedAt, &relayed)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if relayed.Valid {
+		e.RelayedAt = &relayed.Time
+	}
+	return e, err
+}
+
+// CreateEscalation inserts a new open escalation and starts its audit
+// trail. userID is the sender's account, or "" if they have none.
+func (db *DB) CreateEscalation(e *models.Escalation, userID string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const q = `INSERT INTO escalations (id, phone, user_id, body, reason, status, created_at, updated_at)
+	           VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	if _, err := tx.Exec(q, e.ID, e.Phone, userID, e.Body, e.Reason, e.Status, e.CreatedAt, e.UpdatedAt); err != nil {
+		return err
+	}
+	if err := addEscalationEvent(tx, e.ID, "", models.ActionCreated, e.Reason, e.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetEscalation looks up an escalation by ID.
+func (db *DB) GetEscalation(id string) (*models.Escalation, error) {
+	return scanEscalation(db.conn.QueryRow(`SELECT `+escalationColumns+escalationFrom+` WHERE e.id = ?`, id))
+}
+
+// GetEscalations returns escalations oldest first, so the queue is worked
+// in order. An empty status returns those still waiting for a person
+// (open and claimed).
+func (db *DB) GetEscalations(status string, limit int) ([]models.Escalation, error) {
+	q := `SELECT ` + escalationColumns + escalationFrom + `
+	      WHERE (? = '' AND e.status IN ('open', 'claimed')) OR e.status = ?
+	      ORDER BY e.created_at ASC LIMIT ?`
+	rows, err := db.conn.Query(q, status, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.Escalation
+	for rows.Next() {
+		e, err := scanEscalation(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *e)
+	}
+	return list, rows.Err()
+}
+
+// GetEscalationEvents returns an escalation's audit trail, oldest first.
+func (db *DB) GetEscalationEvents(id string) ([]models.EscalationEvent, error) {
+	const q = `SELECT ev.id, ev.actor_id, COALESCE(u.username, ''), ev.action, ev.note, ev.created_at
+	           FROM escalation_events ev LEFT JOIN users u ON u.id = ev.actor_id
+	           WHERE ev.escalation_id = ? ORDER BY ev.id`
+	rows, err := db.conn.Query(q, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.EscalationEvent
+	for rows.Next() {
+		var ev models.EscalationEvent
+		if err := rows.Scan(&ev.ID, &ev.ActorID, &ev.Actor, &ev.Action, &ev.Note, &ev.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, ev)
+	}
+	return list, rows.Err()
+}
+
+// ClaimEscalation assigns an open escalation to userID. It reports false
+// if the escalation is closed or claimed by someone else; claiming one's
+// own escalation again is a no-op that succeeds.
+func (db *DB) ClaimEscalation(id, userID string) (bool, error) {
+	return db.updateEscalation(id, userID, models.ActionClaimed, "",
+		`status = 'claimed', assignee_id = ?`, userID)
+}
+
+// AnswerEscalation records userID's answer to an open escalation, or one
+// they have claimed, for relay to the sender. It reports false if the
+// escalation is closed or claimed by someone else.
+func (db *DB) AnswerEscalation(id, userID, answer string) (bool, error) {
+	return db.updateEscalation(id, userID, models.ActionAnswered, answer,
+		`status = 'answered', assignee_id = ?, answer = ?`, userID, answer)
+}
+
+// DismissEscalation closes an open escalation, or one userID has claimed,
+// without answering. note explains why, for the audit trail.
+func (db *DB) DismissEscalation(id, userID, note string) (bool, error) {
+	return db.updateEscalation(id, userID, models.ActionDismissed, note,
+		`status = 'dismissed', assignee_id = ?`, userID)
+}
+
+// updateEscalation applies set to an escalation that is open or claimed
+// by userID, and records action in its audit trail.
+func (db *DB) updateEscalation(id, userID, action, note, set string, args ...interface{}) (bool, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	q := `UPDATE escalations SET ` + set + `, updated_at = ?
+	      WHERE id = ? AND (status = 'open' OR (status = 'claimed' AND assignee_id = ?))`
+	res, err := tx.Exec(q, append(args, now, id, userID)...)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	if err := addEscalationEvent(tx, id, userID, action, note, now); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func addEscalationEvent(tx *sql.Tx, id, actorID, action, note string, at time.Time) error {
+	const q = `INSERT INTO escalation_events (escalation_id, actor_id, action, note, created_at) VALUES (?, ?, ?, ?, ?)`
+	_, err := tx.Exec(q, id, actorID, action, note, at)
+	return err
+}
+
+// GetPendingEscalationRelays returns up to limit answered escalations,
+// oldest first, whose answer hasn't been texted to the sender yet.
+func (db *DB) GetPendingEscalationRelays(limit int) ([]models.EscalationRelay, error) {
+	const q = `SELECT id, phone, answer FROM escalations
+	           WHERE status = 'answered' AND relayed_at IS NULL
+	           ORDER BY updated_at ASC LIMIT ?`
+	rows, err := db.conn.Query(q, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.EscalationRelay
+	for rows.Next() {
+		var r models.EscalationRelay
+		if err := rows.Scan(&r.EscalationID, &r.Phone, &r.Answer); err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+// MarkEscalationRelayed records that an escalation's answer has been
+// texted to the sender and reports whether it was waiting to be.
+func (db *DB) MarkEscalationRelayed(id string) (bool, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	res, err := tx.Exec(`UPDATE escalations SET relayed_at = ? WHERE id = ? AND status = 'answered' AND relayed_at IS NULL`, now, id)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	if err := addEscalationEvent(tx, id, "", models.ActionRelayed, "", now); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
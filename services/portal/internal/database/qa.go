@@ -0,0 +1,282 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jredh-dev/nexus/services/portal/pkg/models"
+)
+
+// --- Circle operations ---
+
+// AddCircleMember adds memberID to ownerID's circle. Adding someone twice
+// is a no-op.
+func (db *DB) AddCircleMember(ownerID, memberID string) error {
+	const q = `INSERT INTO circle_members (owner_id, member_id, created_at) VALUES (?, ?, ?)
+	           ON CONFLICT(owner_id, member_id) DO NOTHING`
+	_, err := db.conn.Exec(q, ownerID, memberID, time.Now())
+	return err
+}
+
+// RemoveCircleMember removes memberID from ownerID's circle and reports
+// whether they were in it.
+func (db *DB) RemoveCircleMember(ownerID, memberID string) (bool, error) {
+	res, err := db.conn.Exec(`DELETE FROM circle_members WHERE owner_id = ? AND member_id = ?`, ownerID, memberID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetCircle returns the members of ownerID's circle, most recently added
+// first.
+func (db *DB) GetCircle(ownerID string) ([]models.CircleMember, error) {
+	const q = `SELECT u.id, u.username, u.name, c.created_at
+	           FROM circle_members c JOIN users u ON u.id = c.member_id
+	           WHERE c.owner_id = ? ORDER BY c.created_at DESC`
+	rows, err := db.conn.Query(q, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []models.CircleMember
+	for rows.Next() {
+		var m models.CircleMember
+		if err := rows.Scan(&m.UserID, &m.Username, &m.Name, &m.AddedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// CanSeeQuestionsOf reports whether viewerID may see ownerID's questions:
+// their own, or those of anyone whose circle they are in.
+func (db *DB) CanSeeQuestionsOf(viewerID, ownerID string) (bool, error) {
+	if viewerID == ownerID {
+		return true, nil
+	}
+	var n int
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM circle_members WHERE owner_id = ? AND member_id = ?`,
+		ownerID, viewerID).Scan(&n)
+	return n > 0, err
+}
+
+// --- Question operations ---
+
+// questionColumns is the SELECT column list for question queries over
+// questions q joined with users u.
+const questionColumns = `q.id, q.user_id, u.username, q.body, q.source, q.hidden_at, q.created_at,
+	(SELECT COUNT(*) FROM answers a WHERE a.question_id = q.id AND a.hidden_at IS NULL)`
+
+func scanQuestion(row interface{ Scan(...interface{}) error }) (*models.Question, error) {
+	q := &models.Question{}
+	var hidden sql.NullTime
+	err := row.Scan(&q.ID, &q.UserID, &q.Username, &q.Body, &q.Source, &hidden, &q.CreatedAt, &q.AnswerCount)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if hidden.Valid {
+		q.HiddenAt = &hidden.Time
+	}
+	return q, err
+}
+
+func scanQuestions(rows *sql.Rows, err error) ([]models.Question, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.Question
+	for rows.Next() {
+		q, err := scanQuestion(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *q)
+	}
+	return list, rows.Err()
+}
+
+// CreateQuestion inserts a new question.
+func (db *DB) CreateQuestion(q *models.Question) error {
+	const stmt = `INSERT INTO questions (id, user_id, body, source, created_at) VALUES (?, ?, ?, ?, ?)`
+	_, err := db.conn.Exec(stmt, q.ID, q.UserID, q.Body, q.Source, q.CreatedAt)
+	return err
+}
+
+// GetQuestion looks up a question by ID, including removed ones.
+func (db *DB) GetQuestion(id string) (*models.Question, error) {
+	q := `SELECT ` + questionColumns + ` FROM questions q JOIN users u ON u.id = q.user_id WHERE q.id = ?`
+	return scanQuestion(db.conn.QueryRow(q, id))
+}
+
+// GetVisibleQuestions returns the newest questions viewerID may see: their
+// own and those of everyone whose circle they are in. Removed questions
+// are left out.
+func (db *DB) GetVisibleQuestions(viewerID string, limit int) ([]models.Question, error) {
+	q := `SELECT ` + questionColumns + ` FROM questions q JOIN users u ON u.id = q.user_id
+	      WHERE q.hidden_at IS NULL AND (q.user_id = ? OR q.user_id IN
+	        (SELECT owner_id FROM circle_members WHERE member_id = ?))
+	      ORDER BY q.created_at DESC LIMIT ?`
+	return scanQuestions(db.conn.Query(q, viewerID, viewerID, limit))
+}
+
+// GetQuestionsByUser returns the newest questions userID has asked,
+// leaving out removed ones.
+func (db *DB) GetQuestionsByUser(userID string, limit int) ([]models.Question, error) {
+	q := `SELECT ` + questionColumns + ` FROM questions q JOIN users u ON u.id = q.user_id
+	      WHERE q.user_id = ? AND q.hidden_at IS NULL
+	      ORDER BY q.created_at DESC LIMIT ?`
+	return scanQuestions(db.conn.Query(q, userID, limit))
+}
+
+// GetAllQuestions returns the newest questions of every user, including
+// removed ones, for moderation.
+func (db *DB) GetAllQuestions(limit int) ([]models.Question, error) {
+	q := `SELECT ` + questionColumns + ` FROM questions q JOIN users u ON u.id = q.user_id
+	      ORDER BY q.created_at DESC LIMIT ?`
+	return scanQuestions(db.conn.Query(q, limit))
+}
+
+// HideQuestion removes a question from every listing and reports whether
+// it exists.
+func (db *DB) HideQuestion(id string) (bool, error) {
+	return db.hide("questions", id)
+}
+
+// --- Answer operations ---
+
+// CreateAnswer inserts a new answer.
+func (db *DB) CreateAnswer(a *models.Answer) error {
+	const q = `INSERT INTO answers (id, question_id, user_id, body, created_at) VALUES (?, ?, ?, ?, ?)`
+	_, err := db.conn.Exec(q, a.ID, a.QuestionID, a.UserID, a.Body, a.CreatedAt)
+	return err
+}
+
+// GetAnswer looks up an answer by ID, including removed ones. Voted is
+// always false.
+func (db *DB) GetAnswer(id string) (*models.Answer, error) {
+	const q = `SELECT a.id, a.question_id, a.user_id, u.username, a.body, a.hidden_at, a.created_at,
+	             (SELECT COUNT(*) FROM answer_votes v WHERE v.answer_id = a.id), 0
+	           FROM answers a JOIN users u ON u.id = a.user_id WHERE a.id = ?`
+	return scanAnswer(db.conn.QueryRow(q, id))
+}
+
+// GetAnswers returns a question's answers, most upvoted first, with Voted
+// set for viewerID. Removed answers are included only if includeHidden.
+func (db *DB) GetAnswers(questionID, viewerID string, includeHidden bool) ([]models.Answer, error) {
+	const q = `SELECT a.id, a.question_id, a.user_id, u.username, a.body, a.hidden_at, a.created_at,
+	             (SELECT COUNT(*) FROM answer_votes v WHERE v.answer_id = a.id) AS votes,
+	             EXISTS (SELECT 1 FROM answer_votes v WHERE v.answer_id = a.id AND v.user_id = ?)
+	           FROM answers a JOIN users u ON u.id = a.user_id
+	           WHERE a.question_id = ? AND (a.hidden_at IS NULL OR ?)
+	           ORDER BY votes DESC, a.created_at ASC`
+	rows, err := db.conn.Query(q, viewerID, questionID, includeHidden)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.Answer
+	for rows.Next() {
+		a, err := scanAnswer(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *a)
+	}
+	return list, rows.Err()
+}
+
+func scanAnswer(row interface{ Scan(...interface{}) error }) (*models.Answer, error) {
+	a := &models.Answer{}
+	var hidden sql.NullTime
+	err := row.Scan(&a.ID, &a.QuestionID, &a.UserID, &a.Username, &a.Body, &hidden, &a.CreatedAt, &a.Votes, &a.Voted)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if hidden.Valid {
+		a.HiddenAt = &hidden.Time
+	}
+	return a, err
+}
+
+// HideAnswer removes an answer from its question and reports whether it
+// exists. Removed answers are never relayed.
+func (db *DB) HideAnswer(id string) (bool, error) {
+	return db.hide("answers", id)
+}
+
+// hide sets hidden_at on the row of table with the given id, keeping the
+// first removal time.
+func (db *DB) hide(table, id string) (bool, error) {
+	res, err := db.conn.Exec(`UPDATE `+table+` SET hidden_at = COALESCE(hidden_at, ?) WHERE id = ?`, time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// --- Vote operations ---
+
+// Upvote records userID's vote for an answer. Voting twice is a no-op.
+func (db *DB) Upvote(answerID, userID string) error {
+	const q = `INSERT INTO answer_votes (answer_id, user_id, created_at) VALUES (?, ?, ?)
+	           ON CONFLICT(answer_id, user_id) DO NOTHING`
+	_, err := db.conn.Exec(q, answerID, userID, time.Now())
+	return err
+}
+
+// RemoveUpvote withdraws userID's vote for an answer.
+func (db *DB) RemoveUpvote(answerID, userID string) error {
+	_, err := db.conn.Exec(`DELETE FROM answer_votes WHERE answer_id = ? AND user_id = ?`, answerID, userID)
+	return err
+}
+
+// --- SMS relay operations ---
+
+// GetPendingRelays returns up to limit answers, oldest first, that haven't
+// been texted to the asker yet. Answers to removed questions, removed
+// answers, answers to one's own question and askers without a phone
+// number are skipped.
+func (db *DB) GetPendingRelays(limit int) ([]models.AnswerRelay, error) {
+	const q = `SELECT a.id, asker.phone_number, q.body, a.body, author.username
+	           FROM answers a
+	           JOIN questions q ON q.id = a.question_id
+	           JOIN users asker ON asker.id = q.user_id
+	           JOIN users author ON author.id = a.user_id
+	           WHERE a.relayed_at IS NULL AND a.hidden_at IS NULL AND q.hidden_at IS NULL
+	             AND a.user_id != q.user_id AND asker.phone_number != ''
+	           ORDER BY a.created_at ASC LIMIT ?`
+	rows, err := db.conn.Query(q, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.AnswerRelay
+	for rows.Next() {
+		var r models.AnswerRelay
+		if err := rows.Scan(&r.AnswerID, &r.Phone, &r.Question, &r.Answer, &r.Username); err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+// MarkRelayed records that an answer has been texted to the asker and
+// reports whether it exists.
+func (db *DB) MarkRelayed(answerID string) (bool, error) {
+	res, err := db.conn.Exec(`UPDATE answers SET relayed_at = COALESCE(relayed_at, ?) WHERE id = ?`, time.Now(), answerID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jredh-dev/nexus/services/portal/internal/auth"
+	"github.com/jredh-dev/nexus/services/portal/pkg/models"
+)
+
+// StartChallenge handles POST /api/challenge — asks the current user a
+// security question generated from the notes and reminders they texted in. Answering
+// it (AnswerChallenge) unlocks sensitive actions for a few minutes.
+func (h *Handler) StartChallenge(w http.ResponseWriter, r *http.Request) {
+	user, _ := GetUserFromContext(r.Context())
+	c, err := h.auth.StartChallenge(r.Context(), user)
+	switch {
+	case errors.Is(err, auth.ErrChallengesDisabled):
+		h.jsonError(w, "Security challenges aren't available.", http.StatusServiceUnavailable)
+	case errors.Is(err, auth.ErrChallengeLocked):
+		h.jsonError(w, "Too many incorrect answers. Try again later.", http.StatusLocked)
+	case errors.Is(err, auth.ErrNoChallengeFacts):
+		h.jsonError(w, "There isn't enough of your own data to ask a security question yet.", http.StatusUnprocessableEntity)
+	case err != nil:
+		log.Printf("Failed to start challenge for %s: %v", user.ID, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+	default:
+		h.writeJSON(w, http.StatusCreated, c)
+	}
+}
+
+// AnswerChallenge handles POST /api/challenge/{id} — checks the answer to
+// a security question. Body: {"answer": "..."}. Repeated wrong answers
+// lock the user out of challenges for a while.
+func (h *Handler) AnswerChallenge(w http.ResponseWriter, r *http.Request) {
+	user, _ := GetUserFromContext(r.Context())
+	var req struct {
+		Answer string `json:"answer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "Invalid JSON body.", http.StatusBadRequest)
+		return
+	}
+
+	err := h.auth.AnswerChallenge(user.ID, chi.URLParam(r, "id"), req.Answer)
+	switch {
+	case errors.Is(err, auth.ErrWrongAnswer):
+		h.jsonError(w, "Incorrect answer.", http.StatusForbidden)
+	case errors.Is(err, auth.ErrChallengeLocked):
+		h.jsonError(w, "Too many incorrect answers. Try again later.", http.StatusLocked)
+	case errors.Is(err, auth.ErrChallengeNotFound):
+		h.jsonError(w, "Challenge not found or expired.", http.StatusNotFound)
+	case err != nil:
+		log.Printf("Failed to check challenge answer for %s: %v", user.ID, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// AdminSetUserRole handles PUT /admin/users/{id}/role — makes a user an
+// admin or a regular user. Body: {"role": "admin"}. The acting admin must
+// have passed a security challenge in the last few minutes.
+func (h *Handler) AdminSetUserRole(w http.ResponseWriter, r *http.Request) {
+	actor, _ := GetUserFromContext(r.Context())
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "Invalid JSON body.", http.StatusBadRequest)
+		return
+	}
+	if req.Role != models.RoleUser && req.Role != models.RoleAdmin {
+		h.jsonError(w, "Role must be user or admin.", http.StatusBadRequest)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	target, err := h.db.GetUserByID(id)
+	if err != nil {
+		log.Printf("Failed to look up user %s: %v", id, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if target == nil {
+		h.jsonError(w, "User not found.", http.StatusNotFound)
+		return
+	}
+
+	err = h.auth.ChangeUserRole(actor, target.ID, req.Role)
+	switch {
+	case errors.Is(err, auth.ErrStepUpRequired):
+		h.jsonError(w, "Answer a security challenge first.", http.StatusForbidden)
+	case err != nil:
+		log.Printf("Failed to change role of %s: %v", target.ID, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jredh-dev/nexus/services/portal/pkg/models"
+)
+
+// escalationListLimit caps how many escalations a listing returns.
+const escalationListLimit = 100
+
+// escalationDetail is an escalation with its audit trail.
+type escalationDetail struct {
+	*models.Escalation
+	Events []models.EscalationEvent `json:"events"`
+}
+
+// AdminListEscalations handles GET /admin/escalations — the queue, oldest
+// first. ?status= filters to one status; without it, escalations still
+// waiting for a person (open and claimed) are listed.
+func (h *Handler) AdminListEscalations(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.EscalationOpen, models.EscalationClaimed, models.EscalationAnswered, models.EscalationDismissed:
+	default:
+		h.jsonError(w, "Unknown status.", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.db.GetEscalations(status, escalationListLimit)
+	if err != nil {
+		log.Printf("Failed to list escalations: %v", err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.Escalation{}
+	}
+	h.writeJSON(w, http.StatusOK, list)
+}
+
+// AdminGetEscalation handles GET /admin/escalations/{id} — one escalation
+// with its audit trail of who did what.
+func (h *Handler) AdminGetEscalation(w http.ResponseWriter, r *http.Request) {
+	h.writeEscalation(w, chi.URLParam(r, "id"), http.StatusOK)
+}
+
+// AdminClaimEscalation handles POST /admin/escalations/{id}/claim —
+// assigns the escalation to the current admin so nobody else answers it.
+func (h *Handler) AdminClaimEscalation(w http.ResponseWriter, r *http.Request) {
+	user, _ := GetUserFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+	h.transitionEscalation(w, id, func() (bool, error) {
+		return h.db.ClaimEscalation(id, user.ID)
+	})
+}
+
+// AdminAnswerEscalation handles POST /admin/escalations/{id}/answer —
+// records the admin's reply, which the SMS server then texts to the
+// sender. Body: {"answer": "..."}.
+func (h *Handler) AdminAnswerEscalation(w http.ResponseWriter, r *http.Request) {
+	user, _ := GetUserFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	var req struct {
+		Answer string `json:"answer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "Invalid JSON body.", http.StatusBadRequest)
+		return
+	}
+	answer := strings.TrimSpace(req.Answer)
+	if answer == "" {
+		h.jsonError(w, "Answer is required.", http.StatusBadRequest)
+		return
+	}
+	if len(answer) > maxPostLength {
+		h.jsonError(w, "Answer is too long.", http.StatusBadRequest)
+		return
+	}
+	h.transitionEscalation(w, id, func() (bool, error) {
+		return h.db.AnswerEscalation(id, user.ID, answer)
+	})
+}
+
+// AdminDismissEscalation handles POST /admin/escalations/{id}/dismiss —
+// closes the escalation without texting the sender. An optional
+// {"note": "..."} is kept in the audit trail.
+func (h *Handler) AdminDismissEscalation(w http.ResponseWriter, r *http.Request) {
+	user, _ := GetUserFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.jsonError(w, "Invalid JSON body.", http.StatusBadRequest)
+			return
+		}
+	}
+	note := strings.TrimSpace(req.Note)
+	if len(note) > maxPostLength {
+		h.jsonError(w, "Note is too long.", http.StatusBadRequest)
+		return
+	}
+	h.transitionEscalation(w, id, func() (bool, error) {
+		return h.db.DismissEscalation(id, user.ID, note)
+	})
+}
+
+// transitionEscalation applies update to the {id} escalation and writes
+// the result. update reports false when the escalation is closed or
+// claimed by another admin, which is a 409.
+func (h *Handler) transitionEscalation(w http.ResponseWriter, id string, update func() (bool, error)) {
+	ok, err := update()
+	if err != nil {
+		log.Printf("Failed to update escalation %s: %v", id, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		e, err := h.db.GetEscalation(id)
+		if err != nil {
+			log.Printf("Failed to look up escalation %s: %v", id, err)
+			h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+			return
+		}
+		if e == nil {
+			h.jsonError(w, "Escalation not found.", http.StatusNotFound)
+			return
+		}
+		if e.Status == models.EscalationClaimed {
+			h.jsonError(w, "Escalation is claimed by "+e.Assignee+".", http.StatusConflict)
+			return
+		}
+		h.jsonError(w, "Escalation is already "+e.Status+".", http.StatusConflict)
+		return
+	}
+	h.writeEscalation(w, id, http.StatusOK)
+}
+
+// writeEscalation writes the escalation with its audit trail, or a 404.
+func (h *Handler) writeEscalation(w http.ResponseWriter, id string, status int) {
+	e, err := h.db.GetEscalation(id)
+	if err != nil {
+		log.Printf("Failed to look up escalation %s: %v", id, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if e == nil {
+		h.jsonError(w, "Escalation not found.", http.StatusNotFound)
+		return
+	}
+	events, err := h.db.GetEscalationEvents(id)
+	if err != nil {
+		log.Printf("Failed to load events of escalation %s: %v", id, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []models.EscalationEvent{}
+	}
+	h.writeJSON(w, status, escalationDetail{Escalation: e, Events: events})
+}
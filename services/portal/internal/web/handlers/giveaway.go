@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jredh-dev/nexus/internal/events"
 	"github.com/jredh-dev/nexus/services/portal/pkg/fees"
 	"github.com/jredh-dev/nexus/services/portal/pkg/models"
 )
@@ -141,6 +142,7 @@ func (h *Handler) GiveawayClaimSubmit(w http.ResponseWriter, r *http.Request) {
 	if err := h.giveawayDB.UpdateItem(item); err != nil {
 		log.Printf("Error updating item status for %s: %v", id, err)
 	}
+	h.publishClaim(item, claim)
 
 	h.renderTemplate(w, "giveaway_item.html", map[string]interface{}{
 		"Title":    item.Title,
@@ -248,6 +250,7 @@ func (h *Handler) APICreateClaim(w http.ResponseWriter, r *http.Request) {
 	if err := h.giveawayDB.UpdateItem(item); err != nil {
 		log.Printf("API: error updating item status for %s: %v", req.ItemID, err)
 	}
+	h.publishClaim(item, claim)
 
 	w.WriteHeader(http.StatusCreated)
 	jsonResponse(w, claim)
@@ -255,6 +258,25 @@ func (h *Handler) APICreateClaim(w http.ResponseWriter, r *http.Request) {
 
 // --- helpers ---
 
+// publishClaim reports a new claim to the nexus SMS server, if SetEvents
+// was called.
+func (h *Handler) publishClaim(item *models.Item, claim *models.Claim) {
+	if h.events == nil {
+		return
+	}
+	h.events.Go(&events.Event{
+		Type:  events.GiveawayClaimed,
+		Phone: claim.ClaimerPhone,
+		Data: map[string]string{
+			"claim_id":   claim.ID,
+			"item_id":    item.ID,
+			"item_title": item.Title,
+			"name":       claim.ClaimerName,
+			"email":      claim.ClaimerEmail,
+		},
+	})
+}
+
 func generateID() string {
 	b := make([]byte, 16)
 	if _, err := rand.Read(b); err != nil {
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/jredh-dev/nexus/internal/events"
 	"github.com/jredh-dev/nexus/services/portal/config"
 	"github.com/jredh-dev/nexus/services/portal/internal/actions"
 	"github.com/jredh-dev/nexus/services/portal/internal/auth"
@@ -20,6 +21,7 @@ type Handler struct {
 	cfg     *config.Config
 	auth    *auth.Service
 	actions *actions.Registry
+	events  *events.Publisher
 }
 
 // New creates a new handler.
@@ -32,6 +34,12 @@ func New(db *database.DB, cfg *config.Config, authService *auth.Service, registr
 	}
 }
 
+// SetEvents reports giveaway claims to the nexus SMS server as
+// giveaway.claim.created events. Without it claims are not published.
+func (h *Handler) SetEvents(p *events.Publisher) {
+	h.events = p
+}
+
 // AuthService returns the auth service instance.
 func (h *Handler) AuthService() *auth.Service {
 	return h.auth
@@ -209,15 +217,8 @@ func (h *Handler) AdminGenerateMagicLink(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Build the magic link URL.
-	scheme := "https"
-	if h.cfg.Server.Env != "production" {
-		scheme = "http"
-	}
-	link := fmt.Sprintf("%s://%s/auth/magic?token=%s", scheme, r.Host, token)
-
 	w.Header().Set("Content-Type", "application/json")
-	fmt.Fprintf(w, `{"link":%q}`, link)
+	fmt.Fprintf(w, `{"link":%q}`, h.magicLinkURL(r, token))
 }
 
 // SearchActions returns actions matching the query parameter "q".
@@ -245,6 +246,20 @@ func (h *Handler) SearchActions(w http.ResponseWriter, r *http.Request) {
 
 // --- helpers ---
 
+// magicLinkURL builds the /auth/magic link for token, using the configured
+// public URL or, failing that, the request host.
+func (h *Handler) magicLinkURL(r *http.Request, token string) string {
+	base := strings.TrimRight(h.cfg.Server.PublicURL, "/")
+	if base == "" {
+		scheme := "https"
+		if h.cfg.Server.Env != "production" {
+			scheme = "http"
+		}
+		base = scheme + "://" + r.Host
+	}
+	return base + "/auth/magic?token=" + token
+}
+
 // jsonError writes a JSON error response.
 func (h *Handler) jsonError(w http.ResponseWriter, msg string, status int) {
 	w.Header().Set("Content-Type", "application/json")
@@ -252,6 +267,15 @@ func (h *Handler) jsonError(w http.ResponseWriter, msg string, status int) {
 	fmt.Fprintf(w, `{"error":%q}`, msg)
 }
 
+// writeJSON writes v as a JSON response with the given status.
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
 // redirectWithError redirects to the given path with an error query param.
 func (h *Handler) redirectWithError(w http.ResponseWriter, r *http.Request, path, msg string) {
 	target := path + "?error=" + strings.ReplaceAll(msg, " ", "+")
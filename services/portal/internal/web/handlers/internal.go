@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jredh-dev/nexus/services/portal/internal/auth"
+	"github.com/jredh-dev/nexus/services/portal/pkg/models"
+)
+
+// internalUser is the account view shared with other nexus services.
+// It deliberately omits contact details and hashes.
+type internalUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+}
+
+// InternalUserByPhoneHash handles GET /internal/users/by-phone-hash/{hash} —
+// resolves an identity.PhoneHash to the owning account. Used by the SMS
+// server to attribute inbound texts. Returns 404 if no account matches.
+func (h *Handler) InternalUserByPhoneHash(w http.ResponseWriter, r *http.Request) {
+	hash := chi.URLParam(r, "hash")
+	if hash == "" {
+		h.jsonError(w, "Phone hash is required.", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.db.GetUserByPhoneHash(hash)
+	if err != nil {
+		log.Printf("Failed to look up user by phone hash: %v", err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		h.jsonError(w, "User not found.", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(internalUser{
+		ID:       user.ID,
+		Username: user.Username,
+		Name:     user.Name,
+		Role:     user.Role,
+	}); err != nil {
+		log.Printf("Failed to encode user: %v", err)
+	}
+}
+
+// InternalCreateMagicLink handles POST /internal/magic-links — creates a
+// one-time login link for the account whose phone hash matches. The SMS
+// server calls this when a user texts LOGIN and delivers the link by SMS;
+// it is responsible for rate limiting. Returns 404 if no account matches.
+func (h *Handler) InternalCreateMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PhoneHash string `json:"phone_hash"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "Invalid JSON body.", http.StatusBadRequest)
+		return
+	}
+	hash := strings.TrimSpace(req.PhoneHash)
+	if hash == "" {
+		h.jsonError(w, "Phone hash is required.", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.auth.CreateMagicTokenByPhoneHash(hash)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			h.jsonError(w, "User not found.", http.StatusNotFound)
+			return
+		}
+		log.Printf("Failed to generate magic link by phone hash: %v", err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(map[string]string{"link": h.magicLinkURL(r, token)}); err != nil {
+		log.Printf("Failed to encode magic link: %v", err)
+	}
+}
+
+// maxRelayBatch caps how many answer relays one request returns.
+const maxRelayBatch = 100
+
+// InternalCreateQuestion handles POST /internal/questions — posts a
+// question to the circle of the account whose phone hash matches. The SMS
+// server calls this when a user texts ASK. Returns 404 if no account
+// matches.
+func (h *Handler) InternalCreateQuestion(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PhoneHash string `json:"phone_hash"`
+		Body      string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "Invalid JSON body.", http.StatusBadRequest)
+		return
+	}
+	body := strings.TrimSpace(req.Body)
+	if req.PhoneHash == "" || body == "" {
+		h.jsonError(w, "Phone hash and body are required.", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxPostLength {
+		h.jsonError(w, "Body is too long.", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.db.GetUserByPhoneHash(req.PhoneHash)
+	if err != nil {
+		log.Printf("Failed to look up user by phone hash: %v", err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		h.jsonError(w, "User not found.", http.StatusNotFound)
+		return
+	}
+	q, err := h.postQuestion(user, body, models.SourceSMS)
+	if err != nil {
+		log.Printf("Failed to create question for %s: %v", user.ID, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	circle, err := h.db.GetCircle(user.ID)
+	if err != nil {
+		log.Printf("Failed to load circle of %s: %v", user.ID, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, map[string]interface{}{"id": q.ID, "circle_size": len(circle)})
+}
+
+// InternalAnswerRelays handles GET /internal/answer-relays — answers not
+// yet texted to their askers, oldest first. The SMS server polls this,
+// sends each one and acknowledges it with InternalAnswerRelayed.
+func (h *Handler) InternalAnswerRelays(w http.ResponseWriter, r *http.Request) {
+	list, err := h.db.GetPendingRelays(maxRelayBatch)
+	if err != nil {
+		log.Printf("Failed to list answer relays: %v", err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.AnswerRelay{}
+	}
+	h.writeJSON(w, http.StatusOK, list)
+}
+
+// InternalAnswerRelayed handles POST /internal/answer-relays/{id}/done —
+// records that the answer has been texted to the asker.
+func (h *Handler) InternalAnswerRelayed(w http.ResponseWriter, r *http.Request) {
+	found, err := h.db.MarkRelayed(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Printf("Failed to mark answer relayed: %v", err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		h.jsonError(w, "Answer not found.", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// InternalCreateEscalation handles POST /internal/escalations — parks an
+// inbound text for a person to answer. The SMS server calls this when its
+// classifier flags a message. phone_hash attributes it to an account, if
+// one matches; phone is where the answer will be texted.
+func (h *Handler) InternalCreateEscalation(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Phone     string `json:"phone"`
+		PhoneHash string `json:"phone_hash"`
+		Body      string `json:"body"`
+		Reason    string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "Invalid JSON body.", http.StatusBadRequest)
+		return
+	}
+	body := strings.TrimSpace(req.Body)
+	if req.Phone == "" || body == "" {
+		h.jsonError(w, "Phone and body are required.", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxPostLength {
+		h.jsonError(w, "Body is too long.", http.StatusBadRequest)
+		return
+	}
+
+	var userID string
+	if req.PhoneHash != "" {
+		user, err := h.db.GetUserByPhoneHash(req.PhoneHash)
+		if err != nil {
+			log.Printf("Failed to look up user by phone hash: %v", err)
+			h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+			return
+		}
+		if user != nil {
+			userID = user.ID
+		}
+	}
+
+	now := time.Now()
+	e := &models.Escalation{
+		ID:        uuid.New().String(),
+		Phone:     req.Phone,
+		Body:      body,
+		Reason:    req.Reason,
+		Status:    models.EscalationOpen,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := h.db.CreateEscalation(e, userID); err != nil {
+		log.Printf("Failed to create escalation: %v", err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, map[string]string{"id": e.ID})
+}
+
+// InternalEscalationRelays handles GET /internal/escalation-relays —
+// answered escalations not yet texted to their senders, oldest first. The
+// SMS server polls this, sends each one and acknowledges it with
+// InternalEscalationRelayed.
+func (h *Handler) InternalEscalationRelays(w http.ResponseWriter, r *http.Request) {
+	list, err := h.db.GetPendingEscalationRelays(maxRelayBatch)
+	if err != nil {
+		log.Printf("Failed to list escalation relays: %v", err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.EscalationRelay{}
+	}
+	h.writeJSON(w, http.StatusOK, list)
+}
+
+// InternalEscalationRelayed handles POST
+// /internal/escalation-relays/{id}/done — records that the answer has been
+// texted to the sender.
+func (h *Handler) InternalEscalationRelayed(w http.ResponseWriter, r *http.Request) {
+	found, err := h.db.MarkEscalationRelayed(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Printf("Failed to mark escalation relayed: %v", err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		h.jsonError(w, "Escalation not found.", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"context"
+	"crypto/subtle"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/jredh-dev/nexus/services/portal/internal/auth"
 	"github.com/jredh-dev/nexus/services/portal/pkg/models"
@@ -79,3 +81,23 @@ func clearSessionCookie(w http.ResponseWriter) {
 		MaxAge: -1,
 	})
 }
+
+// InternalAPIMiddleware guards service-to-service routes with a shared
+// bearer token. When token is empty the routes are disabled and every
+// request gets 404, so an unconfigured portal never exposes them.
+func InternalAPIMiddleware(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				http.NotFound(w, r)
+				return
+			}
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
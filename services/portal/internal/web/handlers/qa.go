@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jredh-dev/nexus/services/portal/pkg/models"
+)
+
+// questionListLimit caps how many questions a listing returns.
+const questionListLimit = 50
+
+// maxPostLength bounds question and answer bodies.
+const maxPostLength = 2000
+
+// --- Circle ---
+
+// GetCircle handles GET /api/qa/circle — lists the members of the current
+// user's circle, who can see and answer their questions.
+func (h *Handler) GetCircle(w http.ResponseWriter, r *http.Request) {
+	user, _ := GetUserFromContext(r.Context())
+	members, err := h.db.GetCircle(user.ID)
+	if err != nil {
+		log.Printf("Failed to load circle of %s: %v", user.ID, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if members == nil {
+		members = []models.CircleMember{}
+	}
+	h.writeJSON(w, http.StatusOK, members)
+}
+
+// AddCircleMember handles POST /api/qa/circle — adds a user, by username,
+// to the current user's circle. Body: {"username": "..."}.
+func (h *Handler) AddCircleMember(w http.ResponseWriter, r *http.Request) {
+	user, _ := GetUserFromContext(r.Context())
+	var req struct {
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "Invalid JSON body.", http.StatusBadRequest)
+		return
+	}
+	member, err := h.db.GetUserByUsername(strings.TrimSpace(req.Username))
+	if err != nil {
+		log.Printf("Failed to look up user %q: %v", req.Username, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if member == nil {
+		h.jsonError(w, "User not found.", http.StatusNotFound)
+		return
+	}
+	if member.ID == user.ID {
+		h.jsonError(w, "You are always in your own circle.", http.StatusBadRequest)
+		return
+	}
+	if err := h.db.AddCircleMember(user.ID, member.ID); err != nil {
+		log.Printf("Failed to add %s to circle of %s: %v", member.ID, user.ID, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, models.CircleMember{
+		UserID:   member.ID,
+		Username: member.Username,
+		Name:     member.Name,
+		AddedAt:  time.Now(),
+	})
+}
+
+// RemoveCircleMember handles DELETE /api/qa/circle/{username}.
+func (h *Handler) RemoveCircleMember(w http.ResponseWriter, r *http.Request) {
+	user, _ := GetUserFromContext(r.Context())
+	member, err := h.db.GetUserByUsername(chi.URLParam(r, "username"))
+	if err != nil {
+		log.Printf("Failed to look up user: %v", err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	removed := false
+	if member != nil {
+		if removed, err = h.db.RemoveCircleMember(user.ID, member.ID); err != nil {
+			log.Printf("Failed to remove %s from circle of %s: %v", member.ID, user.ID, err)
+			h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+			return
+		}
+	}
+	if !removed {
+		h.jsonError(w, "User is not in your circle.", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- Questions and answers ---
+
+// ListQuestions handles GET /api/qa/questions — the newest questions from
+// the current user and from everyone whose circle they are in.
+func (h *Handler) ListQuestions(w http.ResponseWriter, r *http.Request) {
+	user, _ := GetUserFromContext(r.Context())
+	list, err := h.db.GetVisibleQuestions(user.ID, questionListLimit)
+	if err != nil {
+		log.Printf("Failed to list questions for %s: %v", user.ID, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.Question{}
+	}
+	h.writeJSON(w, http.StatusOK, list)
+}
+
+// CreateQuestion handles POST /api/qa/questions — posts a question to the
+// current user's circle. Body: {"body": "..."}.
+func (h *Handler) CreateQuestion(w http.ResponseWriter, r *http.Request) {
+	user, _ := GetUserFromContext(r.Context())
+	body, ok := h.decodePost(w, r)
+	if !ok {
+		return
+	}
+	q, err := h.postQuestion(user, body, models.SourceWeb)
+	if err != nil {
+		log.Printf("Failed to create question for %s: %v", user.ID, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, q)
+}
+
+// GetQuestion handles GET /api/qa/questions/{id} — a question and its
+// answers, most upvoted first.
+func (h *Handler) GetQuestion(w http.ResponseWriter, r *http.Request) {
+	user, _ := GetUserFromContext(r.Context())
+	q, ok := h.visibleQuestion(w, r, user)
+	if !ok {
+		return
+	}
+	answers, err := h.db.GetAnswers(q.ID, user.ID, user.IsAdmin())
+	if err != nil {
+		log.Printf("Failed to load answers of question %s: %v", q.ID, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if answers == nil {
+		answers = []models.Answer{}
+	}
+	h.writeJSON(w, http.StatusOK, struct {
+		*models.Question
+		Answers []models.Answer `json:"answers"`
+	}{q, answers})
+}
+
+// CreateAnswer handles POST /api/qa/questions/{id}/answers — answers a
+// question the current user can see. The asker gets the answer by SMS.
+// Body: {"body": "..."}.
+func (h *Handler) CreateAnswer(w http.ResponseWriter, r *http.Request) {
+	user, _ := GetUserFromContext(r.Context())
+	q, ok := h.visibleQuestion(w, r, user)
+	if !ok {
+		return
+	}
+	body, ok := h.decodePost(w, r)
+	if !ok {
+		return
+	}
+	a := &models.Answer{
+		ID:         uuid.New().String(),
+		QuestionID: q.ID,
+		UserID:     user.ID,
+		Username:   user.Username,
+		Body:       body,
+		CreatedAt:  time.Now(),
+	}
+	if err := h.db.CreateAnswer(a); err != nil {
+		log.Printf("Failed to answer question %s: %v", q.ID, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, a)
+}
+
+// Upvote handles PUT /api/qa/answers/{id}/vote — upvotes an answer to a
+// question the current user can see. Each user has one vote per answer.
+func (h *Handler) Upvote(w http.ResponseWriter, r *http.Request) {
+	h.vote(w, r, true)
+}
+
+// RemoveUpvote handles DELETE /api/qa/answers/{id}/vote.
+func (h *Handler) RemoveUpvote(w http.ResponseWriter, r *http.Request) {
+	h.vote(w, r, false)
+}
+
+func (h *Handler) vote(w http.ResponseWriter, r *http.Request, up bool) {
+	user, _ := GetUserFromContext(r.Context())
+	a, err := h.db.GetAnswer(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Printf("Failed to look up answer: %v", err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if a == nil || a.HiddenAt != nil {
+		h.jsonError(w, "Answer not found.", http.StatusNotFound)
+		return
+	}
+	if _, ok := h.questionFor(w, a.QuestionID, user); !ok {
+		return
+	}
+	if up {
+		err = h.db.Upvote(a.ID, user.ID)
+	} else {
+		err = h.db.RemoveUpvote(a.ID, user.ID)
+	}
+	if err != nil {
+		log.Printf("Failed to vote on answer %s: %v", a.ID, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- Moderation (admin role) ---
+
+// AdminListQuestions handles GET /admin/qa/questions — the newest
+// questions of every user, including removed ones.
+func (h *Handler) AdminListQuestions(w http.ResponseWriter, r *http.Request) {
+	list, err := h.db.GetAllQuestions(questionListLimit)
+	if err != nil {
+		log.Printf("Failed to list questions: %v", err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []models.Question{}
+	}
+	h.writeJSON(w, http.StatusOK, list)
+}
+
+// AdminHideQuestion handles DELETE /admin/qa/questions/{id} — removes a
+// question from every listing. Its answers are no longer relayed.
+func (h *Handler) AdminHideQuestion(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, "Question", h.db.HideQuestion)
+}
+
+// AdminHideAnswer handles DELETE /admin/qa/answers/{id} — removes an
+// answer; if it hasn't been texted to the asker yet, it never will be.
+func (h *Handler) AdminHideAnswer(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, "Answer", h.db.HideAnswer)
+}
+
+func (h *Handler) moderate(w http.ResponseWriter, r *http.Request, kind string, hide func(id string) (bool, error)) {
+	id := chi.URLParam(r, "id")
+	found, err := hide(id)
+	if err != nil {
+		log.Printf("Failed to remove %s %s: %v", strings.ToLower(kind), id, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		h.jsonError(w, kind+" not found.", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- helpers ---
+
+// postQuestion stores a new question from user.
+func (h *Handler) postQuestion(user *models.User, body, source string) (*models.Question, error) {
+	q := &models.Question{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		Username:  user.Username,
+		Body:      body,
+		Source:    source,
+		CreatedAt: time.Now(),
+	}
+	return q, h.db.CreateQuestion(q)
+}
+
+// decodePost reads {"body": "..."}, writing a 400 if it is missing or too
+// long.
+func (h *Handler) decodePost(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.jsonError(w, "Invalid JSON body.", http.StatusBadRequest)
+		return "", false
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		h.jsonError(w, "Body is required.", http.StatusBadRequest)
+		return "", false
+	}
+	if len(body) > maxPostLength {
+		h.jsonError(w, "Body is too long.", http.StatusBadRequest)
+		return "", false
+	}
+	return body, true
+}
+
+// visibleQuestion loads the {id} question if user may see it, writing a
+// 404 otherwise.
+func (h *Handler) visibleQuestion(w http.ResponseWriter, r *http.Request, user *models.User) (*models.Question, bool) {
+	return h.questionFor(w, chi.URLParam(r, "id"), user)
+}
+
+// questionFor loads a question if user may see it: it is theirs or they
+// are in the asker's circle, and it hasn't been removed. Admins see every
+// question. Anything else is a 404, so question IDs don't leak.
+func (h *Handler) questionFor(w http.ResponseWriter, id string, user *models.User) (*models.Question, bool) {
+	q, err := h.db.GetQuestion(id)
+	if err != nil {
+		log.Printf("Failed to look up question %s: %v", id, err)
+		h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+		return nil, false
+	}
+	visible := q != nil && (user.IsAdmin() || q.HiddenAt == nil)
+	if visible && !user.IsAdmin() {
+		if visible, err = h.db.CanSeeQuestionsOf(user.ID, q.UserID); err != nil {
+			log.Printf("Failed to check circle of %s: %v", q.UserID, err)
+			h.jsonError(w, "Internal server error.", http.StatusInternalServerError)
+			return nil, false
+		}
+	}
+	if !visible {
+		h.jsonError(w, "Question not found.", http.StatusNotFound)
+		return nil, false
+	}
+	return q, true
+}
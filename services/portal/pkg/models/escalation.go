@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Escalation statuses.
+const (
+	EscalationOpen      = "open"
+	EscalationClaimed   = "claimed"
+	EscalationAnswered  = "answered"
+	EscalationDismissed = "dismissed"
+)
+
+// Escalation audit actions.
+const (
+	ActionCreated   = "created"
+	ActionClaimed   = "claimed"
+	ActionAnswered  = "answered"
+	ActionDismissed = "dismissed"
+	ActionRelayed   = "relayed"
+)
+
+// Escalation is an inbound text the SMS assistant handed to a person
+// because it touches a sensitive topic.
+type Escalation struct {
+	ID         string     `json:"id"`
+	Phone      string     `json:"phone"`
+	Username   string     `json:"username,omitempty"` // sender's account, if any
+	Body       string     `json:"body"`
+	Reason     string     `json:"reason"` // why the classifier escalated it
+	Status     string     `json:"status"`
+	AssigneeID string     `json:"assignee_id,omitempty"`
+	Assignee   string     `json:"assignee,omitempty"` // username of the admin handling it
+	Answer     string     `json:"answer,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	RelayedAt  *time.Time `json:"relayed_at,omitempty"` // when the answer was texted
+}
+
+// EscalationEvent is one entry in an escalation's audit trail. ActorID is
+// empty for actions taken by the system.
+type EscalationEvent struct {
+	ID        int64     `json:"id"`
+	ActorID   string    `json:"actor_id,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+	Action    string    `json:"action"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EscalationRelay is an answered escalation waiting to be texted to the
+// sender.
+type EscalationRelay struct {
+	EscalationID string `json:"escalation_id"`
+	Phone        string `json:"phone"`
+	Answer       string `json:"answer"`
+}
@@ -47,3 +47,16 @@ type MagicToken struct {
 	UsedAt    time.Time `json:"used_at,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+// Challenge is a security question generated from a user's own data and
+// asked before a sensitive action. The expected answer never leaves the
+// server.
+type Challenge struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"-"`
+	Question  string     `json:"question"`
+	Answer    string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	PassedAt  *time.Time `json:"passed_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
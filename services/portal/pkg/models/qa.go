@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Question sources.
+const (
+	SourceWeb = "web"
+	SourceSMS = "sms"
+)
+
+// CircleMember is a user someone has added to their circle. A user's
+// questions are visible to the members of their circle.
+type CircleMember struct {
+	UserID   string    `json:"user_id"`
+	Username string    `json:"username"`
+	Name     string    `json:"name"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// Question is a community Q&A post.
+type Question struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	Username    string     `json:"username"`
+	Body        string     `json:"body"`
+	Source      string     `json:"source"`
+	AnswerCount int        `json:"answer_count"`
+	HiddenAt    *time.Time `json:"hidden_at,omitempty"` // set when an admin removes it
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Answer is a reply to a Question. Voted reports whether the user
+// viewing it has upvoted it.
+type Answer struct {
+	ID         string     `json:"id"`
+	QuestionID string     `json:"question_id"`
+	UserID     string     `json:"user_id"`
+	Username   string     `json:"username"`
+	Body       string     `json:"body"`
+	Votes      int        `json:"votes"`
+	Voted      bool       `json:"voted"`
+	HiddenAt   *time.Time `json:"hidden_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// AnswerRelay is an answer waiting to be texted to the asker.
+type AnswerRelay struct {
+	AnswerID string `json:"answer_id"`
+	Phone    string `json:"phone"` // asker's phone number as entered at signup
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+	Username string `json:"username"` // who answered
+}
@@ -40,9 +40,10 @@ func testServerWithDB(t *testing.T) (srv *httptest.Server, client *http.Client,
 	}
 
 	cfg := &config.Config{
-		Server:  config.ServerConfig{Port: "0", Env: "test"},
-		DB:      config.DBConfig{Path: dbPath},
-		Session: config.SessionConfig{Secret: "test-secret", MaxAge: 3600},
+		Server:   config.ServerConfig{Port: "0", Env: "test"},
+		DB:       config.DBConfig{Path: dbPath},
+		Session:  config.SessionConfig{Secret: "test-secret", MaxAge: 3600},
+		Internal: config.InternalConfig{Token: testInternalToken},
 	}
 
 	authSvc = auth.New(db, cfg)
@@ -59,6 +60,40 @@ func testServerWithDB(t *testing.T) (srv *httptest.Server, client *http.Client,
 		r.Use(handlers.AuthMiddleware(authSvc))
 		r.Use(handlers.AdminMiddleware)
 		r.Post("/admin/magic-link", h.AdminGenerateMagicLink)
+		r.Put("/admin/users/{id}/role", h.AdminSetUserRole)
+		r.Get("/admin/qa/questions", h.AdminListQuestions)
+		r.Delete("/admin/qa/questions/{id}", h.AdminHideQuestion)
+		r.Delete("/admin/qa/answers/{id}", h.AdminHideAnswer)
+		r.Get("/admin/escalations", h.AdminListEscalations)
+		r.Get("/admin/escalations/{id}", h.AdminGetEscalation)
+		r.Post("/admin/escalations/{id}/claim", h.AdminClaimEscalation)
+		r.Post("/admin/escalations/{id}/answer", h.AdminAnswerEscalation)
+		r.Post("/admin/escalations/{id}/dismiss", h.AdminDismissEscalation)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(handlers.AuthMiddleware(authSvc))
+		r.Get("/api/qa/circle", h.GetCircle)
+		r.Post("/api/qa/circle", h.AddCircleMember)
+		r.Delete("/api/qa/circle/{username}", h.RemoveCircleMember)
+		r.Get("/api/qa/questions", h.ListQuestions)
+		r.Post("/api/qa/questions", h.CreateQuestion)
+		r.Get("/api/qa/questions/{id}", h.GetQuestion)
+		r.Post("/api/qa/questions/{id}/answers", h.CreateAnswer)
+		r.Put("/api/qa/answers/{id}/vote", h.Upvote)
+		r.Delete("/api/qa/answers/{id}/vote", h.RemoveUpvote)
+		r.Post("/api/challenge", h.StartChallenge)
+		r.Post("/api/challenge/{id}", h.AnswerChallenge)
+	})
+	r.Route("/internal", func(r chi.Router) {
+		r.Use(handlers.InternalAPIMiddleware(cfg.Internal.Token))
+		r.Get("/users/by-phone-hash/{hash}", h.InternalUserByPhoneHash)
+		r.Post("/magic-links", h.InternalCreateMagicLink)
+		r.Post("/questions", h.InternalCreateQuestion)
+		r.Get("/answer-relays", h.InternalAnswerRelays)
+		r.Post("/answer-relays/{id}/done", h.InternalAnswerRelayed)
+		r.Post("/escalations", h.InternalCreateEscalation)
+		r.Get("/escalation-relays", h.InternalEscalationRelays)
+		r.Post("/escalation-relays/{id}/done", h.InternalEscalationRelayed)
 	})
 
 	srv = httptest.NewServer(r)
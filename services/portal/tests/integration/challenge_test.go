@@ -0,0 +1,192 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jredh-dev/nexus/internal/calclient"
+	nexusdb "github.com/jredh-dev/nexus/internal/database"
+	smshandlers "github.com/jredh-dev/nexus/internal/handlers"
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/services/portal/internal/auth"
+	"github.com/jredh-dev/nexus/services/portal/pkg/models"
+)
+
+// fakeCalendar serves reminder feeds from memory.
+type fakeCalendar map[string][]calclient.Event
+
+func (f fakeCalendar) ListEvents(ctx context.Context, feedID string) ([]calclient.Event, error) {
+	return f[feedID], nil
+}
+
+// testFacts starts an SMS server exposing the notes and reminders that
+// security questions are drawn from, and points authSvc at it.
+func testFacts(t *testing.T, authSvc *auth.Service) (*nexusdb.DB, fakeCalendar) {
+	t.Helper()
+	db, err := nexusdb.Open(t.TempDir() + "/nexus.db")
+	if err != nil {
+		t.Fatalf("open nexus db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cal := fakeCalendar{}
+	h := smshandlers.New(db, sms.NewRouter(), smshandlers.Options{Calendar: cal})
+	internal := smshandlers.InternalAPIMiddleware(testInternalToken)
+	mux := http.NewServeMux()
+	mux.Handle("GET /internal/notes", internal(http.HandlerFunc(h.InternalNotes)))
+	mux.Handle("GET /internal/reminders", internal(http.HandlerFunc(h.InternalReminders)))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	authSvc.SetChallenges(auth.StubGenerator{},
+		auth.NewNotesFacts(srv.URL, testInternalToken),
+		auth.NewReminderFacts(srv.URL, testInternalToken))
+	return db, cal
+}
+
+// addNote stores a note texted from phone age ago.
+func addNote(t *testing.T, db *nexusdb.DB, phone, body string, age time.Duration) {
+	t.Helper()
+	if err := db.CreateNote(&nexusdb.Note{Phone: phone, Body: body, CreatedAt: time.Now().Add(-age).UTC()}); err != nil {
+		t.Fatalf("create note: %v", err)
+	}
+}
+
+// addReminder adds a reminder created age ago to phone's feed.
+func addReminder(t *testing.T, db *nexusdb.DB, cal fakeCalendar, phone, summary string, age time.Duration) {
+	t.Helper()
+	feedID := "feed-" + phone
+	if f, _ := db.CalFeedByPhone(phone); f == nil {
+		if err := db.SaveCalFeed(&nexusdb.CalFeed{Phone: phone, FeedID: feedID, Token: "t", CreatedAt: time.Now().UTC()}); err != nil {
+			t.Fatalf("save cal feed: %v", err)
+		}
+	}
+	created := time.Now().Add(-age).UTC()
+	cal[feedID] = append(cal[feedID], calclient.Event{Summary: summary, Start: created.Add(72 * time.Hour), CreatedAt: created})
+}
+
+func TestChallenge_RefusedWithoutSources(t *testing.T) {
+	srv, _, db, _, cleanup := testServerWithDB(t)
+	defer cleanup()
+
+	ann := newAdmin(t, db, srv.URL, "ann", "5551110008")
+	newQAUser(t, srv.URL, "bob", "5551110002")
+	bob, err := db.GetUserByUsername("bob")
+	if err != nil || bob == nil {
+		t.Fatalf("lookup bob: %v", err)
+	}
+	// Portal content is never used in place of texted data.
+	qaRequest(t, ann, http.MethodPost, srv.URL+"/api/qa/questions", "", map[string]string{"body": "Where can I buy kumquats downtown?"}, nil)
+
+	if code := qaRequest(t, ann, http.MethodPost, srv.URL+"/api/challenge", "", nil, nil); code != http.StatusServiceUnavailable {
+		t.Errorf("challenge without sources: status %d, want 503", code)
+	}
+	if code := qaRequest(t, ann, http.MethodPut, srv.URL+"/admin/users/"+bob.ID+"/role", "", map[string]string{"role": "admin"}, nil); code != http.StatusForbidden {
+		t.Errorf("role change without challenges: status %d, want 403", code)
+	}
+}
+
+func TestChallenge_IgnoresSelfSeededContent(t *testing.T) {
+	srv, _, db, authSvc, cleanup := testServerWithDB(t)
+	defer cleanup()
+	nexusDB, cal := testFacts(t, authSvc)
+
+	ann := newAdmin(t, db, srv.URL, "ann", "5551110008")
+	// Someone holding ann's session posts, and even texts, the answers
+	// they want to be asked about.
+	qaRequest(t, ann, http.MethodPost, srv.URL+"/api/qa/questions", "", map[string]string{"body": "Where can I buy kumquats downtown?"}, nil)
+	addNote(t, nexusDB, "15551110008", "buy kumquats downtown", time.Hour)
+	addReminder(t, nexusDB, cal, "15551110008", "Kumquat tasting", time.Hour)
+
+	if code := qaRequest(t, ann, http.MethodPost, srv.URL+"/api/challenge", "", nil, nil); code != http.StatusUnprocessableEntity {
+		t.Errorf("challenge from fresh content: status %d, want 422", code)
+	}
+}
+
+func TestChallenge_StepUpForRoleChange(t *testing.T) {
+	srv, _, db, authSvc, cleanup := testServerWithDB(t)
+	defer cleanup()
+	nexusDB, _ := testFacts(t, authSvc)
+
+	ann := newAdmin(t, db, srv.URL, "ann", "5551110008")
+	newQAUser(t, srv.URL, "bob", "5551110002")
+	bob, err := db.GetUserByUsername("bob")
+	if err != nil || bob == nil {
+		t.Fatalf("lookup bob: %v", err)
+	}
+	roleURL := srv.URL + "/admin/users/" + bob.ID + "/role"
+
+	if code := qaRequest(t, ann, http.MethodPut, roleURL, "", map[string]string{"role": "admin"}, nil); code != http.StatusForbidden {
+		t.Errorf("role change without step-up: status %d, want 403", code)
+	}
+
+	// Questions are drawn from notes ann texted in days ago.
+	addNote(t, nexusDB, "15551110008", "pick up kumquats downtown", 72*time.Hour)
+	var c models.Challenge
+	if code := qaRequest(t, ann, http.MethodPost, srv.URL+"/api/challenge", "", nil, &c); code != http.StatusCreated {
+		t.Fatalf("start challenge: status %d", code)
+	}
+	if !strings.Contains(c.Question, `note of`) || !strings.Contains(c.Question, `"pick up ___ downtown"`) {
+		t.Errorf("question = %q", c.Question)
+	}
+	if code := qaRequest(t, ann, http.MethodPost, srv.URL+"/api/challenge/"+c.ID, "", map[string]string{"answer": "Kumquat"}, nil); code != http.StatusNoContent {
+		t.Fatalf("answer challenge: status %d", code)
+	}
+	if code := qaRequest(t, ann, http.MethodPost, srv.URL+"/api/challenge/"+c.ID, "", map[string]string{"answer": "kumquats"}, nil); code != http.StatusNotFound {
+		t.Errorf("reusing a passed challenge: status %d, want 404", code)
+	}
+
+	if code := qaRequest(t, ann, http.MethodPut, roleURL, "", map[string]string{"role": "superuser"}, nil); code != http.StatusBadRequest {
+		t.Errorf("invalid role: status %d, want 400", code)
+	}
+	if code := qaRequest(t, ann, http.MethodPut, srv.URL+"/admin/users/missing/role", "", map[string]string{"role": "admin"}, nil); code != http.StatusNotFound {
+		t.Errorf("unknown user: status %d, want 404", code)
+	}
+	if code := qaRequest(t, ann, http.MethodPut, roleURL, "", map[string]string{"role": "admin"}, nil); code != http.StatusNoContent {
+		t.Fatalf("role change after step-up: status %d", code)
+	}
+	if bob, _ = db.GetUserByID(bob.ID); bob.Role != models.RoleAdmin {
+		t.Errorf("bob's role = %q, want admin", bob.Role)
+	}
+}
+
+func TestChallenge_Lockout(t *testing.T) {
+	srv, _, _, authSvc, cleanup := testServerWithDB(t)
+	defer cleanup()
+	nexusDB, cal := testFacts(t, authSvc)
+
+	carol := newQAUser(t, srv.URL, "carol", "5551110003")
+	dave := newQAUser(t, srv.URL, "dave", "5551110004")
+	addReminder(t, nexusDB, cal, "15551110003", "Collect bicycles from the shop", 48*time.Hour)
+
+	var c models.Challenge
+	if code := qaRequest(t, carol, http.MethodPost, srv.URL+"/api/challenge", "", nil, &c); code != http.StatusCreated {
+		t.Fatalf("start challenge: status %d", code)
+	}
+	if !strings.Contains(c.Question, "reminder of") {
+		t.Errorf("question = %q", c.Question)
+	}
+	answerURL := srv.URL + "/api/challenge/" + c.ID
+
+	// Someone else's challenge doesn't exist for them.
+	if code := qaRequest(t, dave, http.MethodPost, answerURL, "", map[string]string{"answer": "bicycles"}, nil); code != http.StatusNotFound {
+		t.Errorf("answering another user's challenge: status %d, want 404", code)
+	}
+
+	for i := 0; i < 3; i++ {
+		if code := qaRequest(t, carol, http.MethodPost, answerURL, "", map[string]string{"answer": "cars"}, nil); code != http.StatusForbidden {
+			t.Fatalf("wrong answer %d: status %d, want 403", i+1, code)
+		}
+	}
+	// Locked out: even the right answer is refused, and so is a new challenge.
+	if code := qaRequest(t, carol, http.MethodPost, answerURL, "", map[string]string{"answer": "bicycles"}, nil); code != http.StatusLocked {
+		t.Errorf("answer while locked: status %d, want 423", code)
+	}
+	if code := qaRequest(t, carol, http.MethodPost, srv.URL+"/api/challenge", "", nil, nil); code != http.StatusLocked {
+		t.Errorf("new challenge while locked: status %d, want 423", code)
+	}
+}
@@ -0,0 +1,176 @@
+package integration
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jredh-dev/nexus/services/portal/internal/database"
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+	"github.com/jredh-dev/nexus/services/portal/pkg/models"
+)
+
+// newAdmin signs up a user, promotes them to admin and logs them in again
+// so their session carries the role.
+func newAdmin(t *testing.T, db *database.DB, srvURL, username, phone string) *http.Client {
+	t.Helper()
+	client := newQAUser(t, srvURL, username, phone)
+	user, err := db.GetUserByUsername(username)
+	if err != nil || user == nil {
+		t.Fatalf("lookup %s: %v", username, err)
+	}
+	if err := db.UpdateUserRole(user.ID, models.RoleAdmin); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	resp, err := postForm(client, srvURL+"/login", url.Values{"email": {username + "@example.com"}, "password": {"password"}})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	resp.Body.Close()
+	return client
+}
+
+type escalationDetail struct {
+	models.Escalation
+	Events []models.EscalationEvent `json:"events"`
+}
+
+func TestEscalation_ClaimAnswerRelay(t *testing.T) {
+	srv, _, db, _, cleanup := testServerWithDB(t)
+	defer cleanup()
+
+	newQAUser(t, srv.URL, "alice", "5551110001")
+	ann := newAdmin(t, db, srv.URL, "ann", "5551110008")
+	ben := newAdmin(t, db, srv.URL, "ben", "5551110009")
+	anon := http.DefaultClient
+
+	if code := qaRequest(t, anon, http.MethodPost, srv.URL+"/internal/escalations", testInternalToken, map[string]string{"phone": "15551110001"}, nil); code != http.StatusBadRequest {
+		t.Errorf("missing body: status %d, want 400", code)
+	}
+	req := map[string]string{
+		"phone":      "15551110001",
+		"phone_hash": identity.PhoneHash("+15551110001"),
+		"body":       "I think I need a lawyer",
+		"reason":     `keyword "lawyer"`,
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if code := qaRequest(t, anon, http.MethodPost, srv.URL+"/internal/escalations", testInternalToken, req, &created); code != http.StatusCreated {
+		t.Fatalf("create escalation: status %d", code)
+	}
+	escURL := srv.URL + "/admin/escalations/" + created.ID
+
+	var queue []models.Escalation
+	if code := qaRequest(t, ann, http.MethodGet, srv.URL+"/admin/escalations", "", nil, &queue); code != http.StatusOK || len(queue) != 1 {
+		t.Fatalf("queue: status %d, %+v", code, queue)
+	}
+	if e := queue[0]; e.Status != models.EscalationOpen || e.Username != "alice" || e.Reason != `keyword "lawyer"` {
+		t.Errorf("queued escalation = %+v", e)
+	}
+
+	// Ann claims it; Ben can no longer claim, answer or dismiss it.
+	var got escalationDetail
+	if code := qaRequest(t, ann, http.MethodPost, escURL+"/claim", "", nil, &got); code != http.StatusOK || got.Status != models.EscalationClaimed || got.Assignee != "ann" {
+		t.Fatalf("claim: status %d, %+v", code, got.Escalation)
+	}
+	if code := qaRequest(t, ann, http.MethodPost, escURL+"/claim", "", nil, nil); code != http.StatusOK {
+		t.Errorf("reclaim own: status %d, want 200", code)
+	}
+	if code := qaRequest(t, ben, http.MethodPost, escURL+"/claim", "", nil, nil); code != http.StatusConflict {
+		t.Errorf("ben claim: status %d, want 409", code)
+	}
+	if code := qaRequest(t, ben, http.MethodPost, escURL+"/answer", "", map[string]string{"answer": "hi"}, nil); code != http.StatusConflict {
+		t.Errorf("ben answer: status %d, want 409", code)
+	}
+	if code := qaRequest(t, ann, http.MethodPost, escURL+"/answer", "", map[string]string{"answer": " "}, nil); code != http.StatusBadRequest {
+		t.Errorf("empty answer: status %d, want 400", code)
+	}
+	if code := qaRequest(t, ann, http.MethodPost, escURL+"/answer", "", map[string]string{"answer": "Call legal aid at 555-0100."}, &got); code != http.StatusOK || got.Status != models.EscalationAnswered {
+		t.Fatalf("answer: status %d, %+v", code, got.Escalation)
+	}
+	if code := qaRequest(t, ann, http.MethodPost, escURL+"/dismiss", "", nil, nil); code != http.StatusConflict {
+		t.Errorf("dismiss answered: status %d, want 409", code)
+	}
+
+	// Answered escalations leave the default queue.
+	if qaRequest(t, ann, http.MethodGet, srv.URL+"/admin/escalations", "", nil, &queue); len(queue) != 0 {
+		t.Errorf("queue after answer = %+v, want empty", queue)
+	}
+	if qaRequest(t, ann, http.MethodGet, srv.URL+"/admin/escalations?status=answered", "", nil, &queue); len(queue) != 1 {
+		t.Errorf("answered = %+v, want one", queue)
+	}
+
+	var relays []models.EscalationRelay
+	qaRequest(t, anon, http.MethodGet, srv.URL+"/internal/escalation-relays", testInternalToken, nil, &relays)
+	if len(relays) != 1 || relays[0].Phone != "15551110001" || relays[0].Answer != "Call legal aid at 555-0100." {
+		t.Fatalf("relays = %+v", relays)
+	}
+	if code := qaRequest(t, anon, http.MethodPost, srv.URL+"/internal/escalation-relays/"+created.ID+"/done", testInternalToken, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("mark relayed: status %d", code)
+	}
+	if code := qaRequest(t, anon, http.MethodPost, srv.URL+"/internal/escalation-relays/"+created.ID+"/done", testInternalToken, nil, nil); code != http.StatusNotFound {
+		t.Errorf("mark relayed twice: status %d, want 404", code)
+	}
+
+	// The audit trail records who did what.
+	if code := qaRequest(t, ann, http.MethodGet, escURL, "", nil, &got); code != http.StatusOK || got.RelayedAt == nil {
+		t.Fatalf("get: status %d, %+v", code, got.Escalation)
+	}
+	want := []struct{ action, actor string }{
+		{models.ActionCreated, ""},
+		{models.ActionClaimed, "ann"},
+		{models.ActionClaimed, "ann"},
+		{models.ActionAnswered, "ann"},
+		{models.ActionRelayed, ""},
+	}
+	if len(got.Events) != len(want) {
+		t.Fatalf("events = %+v", got.Events)
+	}
+	for i, w := range want {
+		if ev := got.Events[i]; ev.Action != w.action || ev.Actor != w.actor {
+			t.Errorf("event %d = %s by %q, want %s by %q", i, ev.Action, ev.Actor, w.action, w.actor)
+		}
+	}
+}
+
+func TestEscalation_DismissAndAccess(t *testing.T) {
+	srv, _, db, _, cleanup := testServerWithDB(t)
+	defer cleanup()
+
+	bob := newQAUser(t, srv.URL, "bob", "5551110002")
+	ann := newAdmin(t, db, srv.URL, "ann", "5551110008")
+
+	// Senders without an account can still be escalated.
+	var created struct {
+		ID string `json:"id"`
+	}
+	req := map[string]string{"phone": "15550000000", "body": "help, emergency"}
+	if code := qaRequest(t, http.DefaultClient, http.MethodPost, srv.URL+"/internal/escalations", testInternalToken, req, &created); code != http.StatusCreated {
+		t.Fatalf("create escalation: status %d", code)
+	}
+	escURL := srv.URL + "/admin/escalations/" + created.ID
+
+	if code := qaRequest(t, bob, http.MethodGet, srv.URL+"/admin/escalations", "", nil, nil); code != http.StatusForbidden {
+		t.Errorf("non-admin list: status %d, want 403", code)
+	}
+	if code := qaRequest(t, ann, http.MethodGet, srv.URL+"/admin/escalations?status=bogus", "", nil, nil); code != http.StatusBadRequest {
+		t.Errorf("bad status: status %d, want 400", code)
+	}
+	if code := qaRequest(t, ann, http.MethodPost, srv.URL+"/admin/escalations/missing/claim", "", nil, nil); code != http.StatusNotFound {
+		t.Errorf("claim missing: status %d, want 404", code)
+	}
+
+	var got escalationDetail
+	if code := qaRequest(t, ann, http.MethodPost, escURL+"/dismiss", "", map[string]string{"note": "wrong number"}, &got); code != http.StatusOK || got.Status != models.EscalationDismissed {
+		t.Fatalf("dismiss: status %d, %+v", code, got.Escalation)
+	}
+	if last := got.Events[len(got.Events)-1]; last.Action != models.ActionDismissed || last.Note != "wrong number" || last.Actor != "ann" {
+		t.Errorf("dismiss event = %+v", last)
+	}
+
+	var relays []models.EscalationRelay
+	if qaRequest(t, http.DefaultClient, http.MethodGet, srv.URL+"/internal/escalation-relays", testInternalToken, nil, &relays); len(relays) != 0 {
+		t.Errorf("relays after dismiss = %+v, want none", relays)
+	}
+}
@@ -0,0 +1,75 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	nexusdb "github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/events"
+	smshandlers "github.com/jredh-dev/nexus/internal/handlers"
+	"github.com/jredh-dev/nexus/internal/rules"
+	"github.com/jredh-dev/nexus/internal/sms"
+)
+
+// testNexus starts an SMS server exposing POST /events with a rules engine
+// that texts through the returned fake sender.
+func testNexus(t *testing.T) (*httptest.Server, *nexusdb.DB, *sms.FakeSender) {
+	t.Helper()
+	db, err := nexusdb.Open(t.TempDir() + "/nexus.db")
+	if err != nil {
+		t.Fatalf("open nexus db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	sender := sms.NewFakeSender("")
+	h := smshandlers.New(db, sms.NewRouter(), smshandlers.Options{
+		Rules: rules.NewEngine(db, rules.Deps{Sender: sender}),
+	})
+	mux := http.NewServeMux()
+	mux.Handle("POST /events", smshandlers.InternalAPIMiddleware(testInternalToken)(http.HandlerFunc(h.Events)))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, db, sender
+}
+
+func TestSignup_FiresRules(t *testing.T) {
+	nexus, nexusDB, sender := testNexus(t)
+	rule := &rules.Rule{
+		Name:    "welcome",
+		Enabled: true,
+		Trigger: rules.Trigger{Event: rules.EventPortalSignup},
+		Actions: []rules.Action{{Type: rules.ActionSendSMS, Body: "Welcome {{.username}}!"}},
+	}
+	if err := nexusDB.CreateRule(rule); err != nil {
+		t.Fatalf("create rule: %v", err)
+	}
+
+	srv, client, _, authSvc, cleanup := testServerWithDB(t)
+	defer cleanup()
+	authSvc.SetEvents(events.NewPublisher(nexus.URL, testInternalToken))
+
+	resp, err := postForm(client, srv.URL+"/signup", url.Values{
+		"username": {"tex"},
+		"email":    {"tex@example.com"},
+		"phone":    {"(555) 555-0100"},
+		"password": {"password123"},
+		"name":     {"Tex"},
+	})
+	if err != nil {
+		t.Fatalf("signup: %v", err)
+	}
+	resp.Body.Close()
+
+	// The event is published in the background.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(sender.Sent()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	sent := sender.Sent()
+	if len(sent) != 1 || sent[0].To != "+15555550100" || sent[0].Body != "Welcome tex!" {
+		t.Fatalf("sent = %+v, want one welcome text", sent)
+	}
+}
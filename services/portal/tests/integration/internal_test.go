@@ -0,0 +1,149 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+)
+
+const testInternalToken = "test-internal-token"
+
+// internalGet issues a GET to an /internal route with the given bearer token.
+func internalGet(t *testing.T, url, token string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	return resp
+}
+
+func TestInternalUserByPhoneHash(t *testing.T) {
+	srv, client, _, _, cleanup := testServerWithDB(t)
+	defer cleanup()
+
+	signupAndLogin(t, client, srv.URL, "texter", "texter@example.com", "(555) 333-4444", "password", "Tex Ter")
+
+	// Any spelling of the number hashes to the same account.
+	hash := identity.PhoneHash("+15553334444")
+	resp := internalGet(t, srv.URL+"/internal/users/by-phone-hash/"+hash, testInternalToken)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var got map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got["username"] != "texter" || got["name"] != "Tex Ter" || got["id"] == "" {
+		t.Errorf("user = %v, want texter / Tex Ter with an id", got)
+	}
+	if _, ok := got["email"]; ok {
+		t.Error("internal user response must not include email")
+	}
+}
+
+func TestInternalUserByPhoneHash_NotFound(t *testing.T) {
+	srv, _, _, _, cleanup := testServerWithDB(t)
+	defer cleanup()
+
+	resp := internalGet(t, srv.URL+"/internal/users/by-phone-hash/"+identity.PhoneHash("5550000000"), testInternalToken)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestInternalRoutes_RequireToken(t *testing.T) {
+	srv, _, _, _, cleanup := testServerWithDB(t)
+	defer cleanup()
+
+	url := srv.URL + "/internal/users/by-phone-hash/" + identity.PhoneHash("5550000000")
+	for _, token := range []string{"", "wrong-token"} {
+		resp := internalGet(t, url, token)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("token %q: status = %d, want 401", token, resp.StatusCode)
+		}
+	}
+}
+
+func TestInternalCreateMagicLink(t *testing.T) {
+	srv, client, _, _, cleanup := testServerWithDB(t)
+	defer cleanup()
+
+	signupAndLogin(t, client, srv.URL, "texter", "texter@example.com", "5553334444", "password", "Tex Ter")
+	resp, err := client.Get(srv.URL + "/logout")
+	if err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+	resp.Body.Close()
+
+	body := strings.NewReader(`{"phone_hash":"` + identity.PhoneHash("+1 555 333 4444") + `"}`)
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/internal/magic-links", body)
+	req.Header.Set("Authorization", "Bearer "+testInternalToken)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /internal/magic-links: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status = %d, want 201", resp.StatusCode)
+	}
+	var got struct {
+		Link string `json:"link"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !strings.HasPrefix(got.Link, srv.URL+"/auth/magic?token=") {
+		t.Fatalf("link = %q, want %s/auth/magic?token=...", got.Link, srv.URL)
+	}
+
+	// The link logs the user in exactly once.
+	resp, err = client.Get(got.Link)
+	if err != nil {
+		t.Fatalf("GET magic link: %v", err)
+	}
+	resp.Body.Close()
+	if !strings.HasSuffix(resp.Request.URL.Path, "/dashboard") {
+		t.Errorf("magic link landed on %s, want /dashboard", resp.Request.URL.Path)
+	}
+	resp, err = client.Get(got.Link)
+	if err != nil {
+		t.Fatalf("reuse magic link: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("reused link: status = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestInternalCreateMagicLink_UnknownPhone(t *testing.T) {
+	srv, _, _, _, cleanup := testServerWithDB(t)
+	defer cleanup()
+
+	body := strings.NewReader(`{"phone_hash":"` + identity.PhoneHash("5550000000") + `"}`)
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/internal/magic-links", body)
+	req.Header.Set("Authorization", "Bearer "+testInternalToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /internal/magic-links: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
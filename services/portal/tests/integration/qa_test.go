@@ -0,0 +1,256 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"testing"
+
+	"github.com/jredh-dev/nexus/services/portal/pkg/identity"
+	"github.com/jredh-dev/nexus/services/portal/pkg/models"
+)
+
+// newQAUser signs up a user and returns a client holding their session.
+// Redirects are not followed, so AuthMiddleware's redirect shows up as 303.
+func newQAUser(t *testing.T, srvURL, username, phone string) *http.Client {
+	t.Helper()
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{
+		Jar: jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	signupAndLogin(t, client, srvURL, username, username+"@example.com", phone, "password", "")
+	return client
+}
+
+// qaRequest sends a JSON request (body may be nil) and decodes the response
+// into out if it is non-nil. It returns the status code.
+func qaRequest(t *testing.T, client *http.Client, method, url, token string, body, out interface{}) int {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, url, &buf)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s %s: %v", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+type questionWithAnswers struct {
+	models.Question
+	Answers []models.Answer `json:"answers"`
+}
+
+func TestQA_CircleVisibilityAnswersAndVotes(t *testing.T) {
+	srv, _, _, _, cleanup := testServerWithDB(t)
+	defer cleanup()
+
+	alice := newQAUser(t, srv.URL, "alice", "5551110001")
+	bob := newQAUser(t, srv.URL, "bob", "5551110002")
+	carol := newQAUser(t, srv.URL, "carol", "5551110003")
+
+	if code := qaRequest(t, alice, http.MethodPost, srv.URL+"/api/qa/circle", "", map[string]string{"username": "bob"}, nil); code != http.StatusCreated {
+		t.Fatalf("add bob to circle: status %d", code)
+	}
+	if code := qaRequest(t, alice, http.MethodPost, srv.URL+"/api/qa/circle", "", map[string]string{"username": "nobody"}, nil); code != http.StatusNotFound {
+		t.Errorf("add unknown user: status %d, want 404", code)
+	}
+	var circle []models.CircleMember
+	if qaRequest(t, alice, http.MethodGet, srv.URL+"/api/qa/circle", "", nil, &circle); len(circle) != 1 || circle[0].Username != "bob" {
+		t.Errorf("circle = %+v, want bob", circle)
+	}
+
+	if code := qaRequest(t, alice, http.MethodPost, srv.URL+"/api/qa/questions", "", map[string]string{"body": " "}, nil); code != http.StatusBadRequest {
+		t.Errorf("empty question: status %d, want 400", code)
+	}
+	var q models.Question
+	if code := qaRequest(t, alice, http.MethodPost, srv.URL+"/api/qa/questions", "", map[string]string{"body": "Best taco place?"}, &q); code != http.StatusCreated || q.Source != models.SourceWeb {
+		t.Fatalf("create question: status %d, %+v", code, q)
+	}
+	questionURL := srv.URL + "/api/qa/questions/" + q.ID
+
+	// Bob is in Alice's circle; Carol isn't.
+	var list []models.Question
+	if qaRequest(t, bob, http.MethodGet, srv.URL+"/api/qa/questions", "", nil, &list); len(list) != 1 || list[0].Username != "alice" {
+		t.Errorf("bob's questions = %+v, want alice's", list)
+	}
+	if qaRequest(t, carol, http.MethodGet, srv.URL+"/api/qa/questions", "", nil, &list); len(list) != 0 {
+		t.Errorf("carol's questions = %+v, want none", list)
+	}
+	if code := qaRequest(t, carol, http.MethodGet, questionURL, "", nil, nil); code != http.StatusNotFound {
+		t.Errorf("carol GET question: status %d, want 404", code)
+	}
+	if code := qaRequest(t, carol, http.MethodPost, questionURL+"/answers", "", map[string]string{"body": "me!"}, nil); code != http.StatusNotFound {
+		t.Errorf("carol answering: status %d, want 404", code)
+	}
+
+	var a1, a2 models.Answer
+	qaRequest(t, bob, http.MethodPost, questionURL+"/answers", "", map[string]string{"body": "El Farolito"}, &a1)
+	if code := qaRequest(t, alice, http.MethodPost, questionURL+"/answers", "", map[string]string{"body": "Or La Taqueria?"}, &a2); code != http.StatusCreated {
+		t.Fatalf("alice answering: status %d", code)
+	}
+
+	// Votes are one per user and order the answers.
+	for i := 0; i < 2; i++ {
+		if code := qaRequest(t, alice, http.MethodPut, srv.URL+"/api/qa/answers/"+a2.ID+"/vote", "", nil, nil); code != http.StatusNoContent {
+			t.Fatalf("vote: status %d", code)
+		}
+	}
+	qaRequest(t, bob, http.MethodPut, srv.URL+"/api/qa/answers/"+a2.ID+"/vote", "", nil, nil)
+	qaRequest(t, bob, http.MethodDelete, srv.URL+"/api/qa/answers/"+a2.ID+"/vote", "", nil, nil)
+	if code := qaRequest(t, carol, http.MethodPut, srv.URL+"/api/qa/answers/"+a1.ID+"/vote", "", nil, nil); code != http.StatusNotFound {
+		t.Errorf("carol voting: status %d, want 404", code)
+	}
+
+	var got questionWithAnswers
+	if code := qaRequest(t, alice, http.MethodGet, questionURL, "", nil, &got); code != http.StatusOK {
+		t.Fatalf("GET question: status %d", code)
+	}
+	if got.AnswerCount != 2 || len(got.Answers) != 2 || got.Answers[0].ID != a2.ID || got.Answers[0].Votes != 1 || !got.Answers[0].Voted {
+		t.Errorf("question = %+v, want a2 first with alice's vote", got)
+	}
+
+	// Removing Bob from the circle hides Alice's questions from him.
+	if code := qaRequest(t, alice, http.MethodDelete, srv.URL+"/api/qa/circle/bob", "", nil, nil); code != http.StatusNoContent {
+		t.Errorf("remove bob: status %d", code)
+	}
+	if code := qaRequest(t, bob, http.MethodGet, questionURL, "", nil, nil); code != http.StatusNotFound {
+		t.Errorf("bob after removal: status %d, want 404", code)
+	}
+}
+
+func TestQA_SMSAskAndRelay(t *testing.T) {
+	srv, _, _, _, cleanup := testServerWithDB(t)
+	defer cleanup()
+
+	alice := newQAUser(t, srv.URL, "alice", "(555) 111-0001")
+	bob := newQAUser(t, srv.URL, "bob", "5551110002")
+	qaRequest(t, alice, http.MethodPost, srv.URL+"/api/qa/circle", "", map[string]string{"username": "bob"}, nil)
+
+	anon := http.DefaultClient
+	ask := map[string]string{"phone_hash": identity.PhoneHash("+15551110001"), "body": "Anyone have a ladder?"}
+	if code := qaRequest(t, anon, http.MethodPost, srv.URL+"/internal/questions", "", ask, nil); code != http.StatusUnauthorized {
+		t.Errorf("ask without token: status %d, want 401", code)
+	}
+	var created struct {
+		ID         string `json:"id"`
+		CircleSize int    `json:"circle_size"`
+	}
+	if code := qaRequest(t, anon, http.MethodPost, srv.URL+"/internal/questions", testInternalToken, ask, &created); code != http.StatusCreated || created.CircleSize != 1 {
+		t.Fatalf("ask: status %d, %+v", code, created)
+	}
+	unknown := map[string]string{"phone_hash": identity.PhoneHash("5550000000"), "body": "hi"}
+	if code := qaRequest(t, anon, http.MethodPost, srv.URL+"/internal/questions", testInternalToken, unknown, nil); code != http.StatusNotFound {
+		t.Errorf("ask from unknown phone: status %d, want 404", code)
+	}
+
+	var list []models.Question
+	if qaRequest(t, bob, http.MethodGet, srv.URL+"/api/qa/questions", "", nil, &list); len(list) != 1 || list[0].Source != models.SourceSMS {
+		t.Fatalf("bob's questions = %+v, want the texted one", list)
+	}
+
+	var answer models.Answer
+	qaRequest(t, bob, http.MethodPost, srv.URL+"/api/qa/questions/"+created.ID+"/answers", "", map[string]string{"body": "Yes, come by"}, &answer)
+	// The asker's own follow-up is not relayed back to them.
+	qaRequest(t, alice, http.MethodPost, srv.URL+"/api/qa/questions/"+created.ID+"/answers", "", map[string]string{"body": "thanks"}, nil)
+
+	var relays []models.AnswerRelay
+	qaRequest(t, anon, http.MethodGet, srv.URL+"/internal/answer-relays", testInternalToken, nil, &relays)
+	if len(relays) != 1 || relays[0].AnswerID != answer.ID || relays[0].Phone != "(555) 111-0001" ||
+		relays[0].Username != "bob" || relays[0].Answer != "Yes, come by" || relays[0].Question != "Anyone have a ladder?" {
+		t.Fatalf("relays = %+v", relays)
+	}
+	if code := qaRequest(t, anon, http.MethodPost, srv.URL+"/internal/answer-relays/"+answer.ID+"/done", testInternalToken, nil, nil); code != http.StatusNoContent {
+		t.Errorf("mark relayed: status %d", code)
+	}
+	if qaRequest(t, anon, http.MethodGet, srv.URL+"/internal/answer-relays", testInternalToken, nil, &relays); len(relays) != 0 {
+		t.Errorf("relays after ack = %+v, want none", relays)
+	}
+}
+
+func TestQA_AdminModeration(t *testing.T) {
+	srv, _, db, _, cleanup := testServerWithDB(t)
+	defer cleanup()
+
+	alice := newQAUser(t, srv.URL, "alice", "5551110001")
+	bob := newQAUser(t, srv.URL, "bob", "5551110002")
+	mod := newQAUser(t, srv.URL, "mod", "5551110009")
+	qaRequest(t, alice, http.MethodPost, srv.URL+"/api/qa/circle", "", map[string]string{"username": "bob"}, nil)
+
+	var q models.Question
+	qaRequest(t, alice, http.MethodPost, srv.URL+"/api/qa/questions", "", map[string]string{"body": "Spam?"}, &q)
+	var a models.Answer
+	qaRequest(t, bob, http.MethodPost, srv.URL+"/api/qa/questions/"+q.ID+"/answers", "", map[string]string{"body": "buy pills"}, &a)
+
+	// Regular users can't moderate.
+	if code := qaRequest(t, bob, http.MethodDelete, srv.URL+"/admin/qa/answers/"+a.ID, "", nil, nil); code != http.StatusForbidden {
+		t.Errorf("non-admin moderation: status %d, want 403", code)
+	}
+
+	user, err := db.GetUserByUsername("mod")
+	if err != nil || user == nil {
+		t.Fatalf("lookup mod: %v", err)
+	}
+	if err := db.UpdateUserRole(user.ID, models.RoleAdmin); err != nil {
+		t.Fatalf("promote: %v", err)
+	}
+	resp, err := postForm(mod, srv.URL+"/login", url.Values{"email": {"mod@example.com"}, "password": {"password"}})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	resp.Body.Close()
+
+	// Admins see every question, even outside their circle.
+	var all []models.Question
+	if code := qaRequest(t, mod, http.MethodGet, srv.URL+"/admin/qa/questions", "", nil, &all); code != http.StatusOK || len(all) != 1 {
+		t.Fatalf("admin list: status %d, %+v", code, all)
+	}
+	if code := qaRequest(t, mod, http.MethodDelete, srv.URL+"/admin/qa/answers/"+a.ID, "", nil, nil); code != http.StatusNoContent {
+		t.Errorf("hide answer: status %d", code)
+	}
+	if code := qaRequest(t, mod, http.MethodDelete, srv.URL+"/admin/qa/answers/missing", "", nil, nil); code != http.StatusNotFound {
+		t.Errorf("hide missing answer: status %d, want 404", code)
+	}
+
+	var got questionWithAnswers
+	if qaRequest(t, alice, http.MethodGet, srv.URL+"/api/qa/questions/"+q.ID, "", nil, &got); len(got.Answers) != 0 || got.AnswerCount != 0 {
+		t.Errorf("question after hiding answer = %+v, want no answers", got)
+	}
+	var relays []models.AnswerRelay
+	if qaRequest(t, http.DefaultClient, http.MethodGet, srv.URL+"/internal/answer-relays", testInternalToken, nil, &relays); len(relays) != 0 {
+		t.Errorf("hidden answer queued for relay: %+v", relays)
+	}
+
+	if code := qaRequest(t, mod, http.MethodDelete, srv.URL+"/admin/qa/questions/"+q.ID, "", nil, nil); code != http.StatusNoContent {
+		t.Errorf("hide question: status %d", code)
+	}
+	var list []models.Question
+	if qaRequest(t, bob, http.MethodGet, srv.URL+"/api/qa/questions", "", nil, &list); len(list) != 0 {
+		t.Errorf("hidden question still listed: %+v", list)
+	}
+	if qaRequest(t, mod, http.MethodGet, srv.URL+"/admin/qa/questions", "", nil, &all); len(all) != 1 || all[0].HiddenAt == nil {
+		t.Errorf("admin list after hiding = %+v, want it marked hidden", all)
+	}
+}
@@ -0,0 +1,450 @@
+// nexus - Personal AI assistant system
+// Copyright (C) 2025  nexus contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata" // embed zone data; the container image has none
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/jredh-dev/nexus/internal/assistant"
+	"github.com/jredh-dev/nexus/internal/calclient"
+	"github.com/jredh-dev/nexus/internal/commands"
+	"github.com/jredh-dev/nexus/internal/database"
+	"github.com/jredh-dev/nexus/internal/escalation"
+	"github.com/jredh-dev/nexus/internal/handlers"
+	"github.com/jredh-dev/nexus/internal/media"
+	"github.com/jredh-dev/nexus/internal/portalclient"
+	"github.com/jredh-dev/nexus/internal/ratelimit"
+	"github.com/jredh-dev/nexus/internal/rules"
+	"github.com/jredh-dev/nexus/internal/sms"
+	"github.com/jredh-dev/nexus/internal/twilio"
+	"github.com/jredh-dev/nexus/internal/webhooks"
+	"github.com/jredh-dev/nexus/pkg/scheduler"
+	"github.com/jredh-dev/nexus/services/sms/config"
+)
+
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+// mediaLinkTTL is how long links to attachments in replies keep working.
+const mediaLinkTTL = 7 * 24 * time.Hour
+
+func main() {
+	showVersion := flag.Bool("version", false, "Show version information")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("nexus-sms %s\n", version)
+		fmt.Printf("Commit: %s\n", commit)
+		fmt.Printf("Built: %s\n", buildDate)
+		os.Exit(0)
+	}
+
+	cfg := config.Load()
+
+	// Background work stops on SIGINT/SIGTERM along with the HTTP server.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	jobs := scheduler.New()
+
+	db, err := database.Open(cfg.DB.Path)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	db.SetThreadTimeout(cfg.SMS.ThreadTimeout)
+
+	loc, err := time.LoadLocation(cfg.Server.Timezone)
+	if err != nil {
+		log.Fatalf("Invalid TIMEZONE %q: %v", cfg.Server.Timezone, err)
+	}
+
+	sender, err := newSender(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure SMS sender: %v", err)
+	}
+	verifyWebhooks, err := webhookAuth(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure webhook signatures: %v", err)
+	}
+
+	// Never send to numbers that replied STOP, and keep what is sent in
+	// the recipient's conversation thread.
+	sender = sms.NewRecordingSender(sms.NewSuppressingSender(sender, db), db)
+
+	store, err := media.NewStore(cfg.DB.MediaDir)
+	if err != nil {
+		log.Fatalf("Failed to open media store: %v", err)
+	}
+
+	bot, err := newAssistant(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure assistant: %v", err)
+	}
+
+	// Outgoing webhooks: queued in SQLite, delivered in the background.
+	hooks := webhooks.NewDispatcher(db, nil)
+	go hooks.Run(ctx, 10*time.Second)
+
+	opts := commands.Options{
+		Sender:        sender,
+		Location:      loc,
+		HelpMessage:   cfg.SMS.HelpMessage,
+		Assistant:     bot,
+		MaxReplyChars: cfg.Assistant.MaxReplyChars,
+	}
+	var links *media.Links
+	if cfg.Server.BaseURL != "" && cfg.Server.MediaSecret != "" {
+		links = media.NewLinks(strings.TrimRight(cfg.Server.BaseURL, "/")+"/media/", cfg.Server.MediaSecret, mediaLinkTTL)
+		opts.MediaLinks = links
+	} else {
+		log.Println("PUBLIC_BASE_URL or MEDIA_LINK_SECRET is empty — attachment links and GET /media are disabled")
+	}
+	if cfg.Cal.URL != "" {
+		opts.Cal = calclient.New(cfg.Cal.URL)
+	} else {
+		log.Println("CAL_URL is empty — REMIND is disabled")
+	}
+
+	handlerOpts := handlers.Options{
+		Media:        store,
+		Fetcher:      media.NewHTTPFetcher(cfg.Twilio.AccountSID, cfg.Twilio.AuthToken),
+		MediaLinks:   links,
+		HistoryTurns: cfg.SMS.HistoryTurns,
+		ReplyMode:    cfg.SMS.ReplyMode,
+		MaxSegments:  cfg.SMS.MaxSegments,
+		Location:     loc,
+		Webhooks:     hooks,
+	}
+	if opts.Cal != nil {
+		handlerOpts.Calendar = opts.Cal
+	}
+	if cfg.SMS.RateBurst > 0 {
+		handlerOpts.Limiter = ratelimit.NewTokenBucket(cfg.SMS.RateBurst, cfg.SMS.RateRefill, db)
+	} else {
+		log.Println("RATE_LIMIT_BURST is 0 — inbound rate limiting is disabled")
+	}
+	if cfg.Portal.URL != "" {
+		portal := portalclient.New(cfg.Portal.URL, cfg.Portal.Token)
+		opts.Portal = portal
+		handlerOpts.Accounts = portal
+		handlerOpts.SignupURL = cfg.Portal.SignupURL
+		if handlerOpts.SignupURL == "" {
+			handlerOpts.SignupURL = strings.TrimRight(cfg.Portal.URL, "/") + "/signup"
+		}
+	} else {
+		log.Println("PORTAL_URL is empty — account linking, LOGIN and calling in are disabled")
+	}
+	if opts.Portal != nil {
+		handlerOpts.Classifier = newClassifier(cfg)
+		handlerOpts.Escalations = opts.Portal
+	}
+
+	handlerOpts.Rules = newRules(db, sender, opts.Cal, loc, cfg.Cal.RuleLead, jobs)
+
+	router := sms.NewRouter()
+	cmds := commands.New(db, opts)
+	cmds.Register(router)
+	if opts.Cal != nil {
+		mustAddJob(jobs, scheduler.Job{
+			Name:     "reminders",
+			Schedule: scheduler.Every(time.Minute),
+			Timeout:  time.Minute,
+			Run: func(ctx context.Context) error {
+				return cmds.DispatchReminders(ctx, time.Now())
+			},
+		})
+	}
+	if opts.Portal != nil {
+		mustAddJob(jobs, scheduler.Job{
+			Name:     "qa-relay",
+			Schedule: scheduler.Every(time.Minute),
+			Timeout:  time.Minute,
+			Run:      cmds.RelayAnswers,
+		})
+		mustAddJob(jobs, scheduler.Job{
+			Name:     "escalation-relay",
+			Schedule: scheduler.Every(time.Minute),
+			Timeout:  time.Minute,
+			Run:      cmds.RelayEscalations,
+		})
+	}
+	mustAddJob(jobs, scheduler.Job{
+		Name:     "big-question",
+		Schedule: scheduler.Every(time.Minute),
+		Timeout:  time.Minute,
+		Run: func(ctx context.Context) error {
+			return cmds.DispatchBigQuestions(ctx, time.Now())
+		},
+	})
+	h := handlers.New(db, router, handlerOpts)
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+
+	// Public website: index.html at /, everything else from the static dir.
+	static := http.FileServer(http.Dir(cfg.Server.StaticDir))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join(cfg.Server.StaticDir, "index.html"))
+	})
+	r.Get("/*", static.ServeHTTP)
+
+	// Health check
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	// Twilio webhooks: SMS, delivery status callbacks and voice IVR ("A
+	// CALL COMES IN" is /voice). Signatures are verified unless
+	// webhookAuth allowed running without them.
+	r.Group(func(r chi.Router) {
+		if verifyWebhooks != nil {
+			r.Use(verifyWebhooks)
+		}
+		r.HandleFunc("/sms", h.SMS)
+		r.Post("/sms/status", h.SMSStatus)
+		r.Post("/voice", h.Voice)
+		r.Post("/voice/pin", h.VoicePIN)
+		r.Post("/voice/menu", h.VoiceMenu)
+		r.Post("/voice/memo", h.VoiceMemo)
+	})
+
+	// Stored MMS attachments, through the signed links in RECALL/LIST replies
+	r.Get("/media/{sum}", h.Media)
+
+	// Admin debugging API (ADMIN_TOKEN bearer token required)
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(handlers.AdminTokenMiddleware(cfg.Server.AdminToken))
+		r.Get("/jobs", jobs.ServeHTTP)
+		r.Get("/threads", h.AdminThreads)
+		r.Get("/threads/{id}", h.AdminThread)
+		r.Get("/delivery", h.AdminDelivery)
+		r.Get("/blocklist", h.AdminBlocklist)
+		r.Post("/blocklist", h.AdminBlock)
+		r.Delete("/blocklist/{phone}", h.AdminUnblock)
+		r.Get("/rules", h.AdminRules)
+		r.Post("/rules", h.AdminCreateRule)
+		r.Get("/rules/{id}", h.AdminRule)
+		r.Put("/rules/{id}", h.AdminUpdateRule)
+		r.Delete("/rules/{id}", h.AdminDeleteRule)
+		r.Get("/rules/{id}/runs", h.AdminRuleRuns)
+		r.Get("/webhooks", h.AdminWebhooks)
+		r.Post("/webhooks", h.AdminCreateWebhook)
+		r.Delete("/webhooks/{id}", h.AdminDeleteWebhook)
+		r.Get("/webhooks/{id}/deliveries", h.AdminWebhookDeliveries)
+		r.Post("/webhooks/deliveries/{id}/retry", h.AdminRetryDelivery)
+		r.Get("/bigq/questions", h.AdminBigQuestions)
+		r.Post("/bigq/questions", h.AdminCreateBigQuestion)
+		r.Delete("/bigq/questions/{id}", h.AdminRetireBigQuestion)
+		r.Get("/bigq/answers", h.AdminBigQAnswers)
+		r.Get("/bigq/subscribers", h.AdminBigQSubscribers)
+		r.Get("/bigq/export", h.AdminBigQExport)
+	})
+	if cfg.Server.AdminToken == "" {
+		log.Println("ADMIN_TOKEN is empty — /admin endpoints are disabled")
+	}
+
+	// Service-to-service API (INTERNAL_API_TOKEN bearer token required):
+	// event ingest, and the notes and reminders the portal draws security
+	// questions from.
+	r.Group(func(r chi.Router) {
+		r.Use(handlers.InternalAPIMiddleware(cfg.Portal.Token))
+		r.Post("/events", h.Events)
+		r.Get("/internal/notes", h.InternalNotes)
+		r.Get("/internal/reminders", h.InternalReminders)
+	})
+
+	addr := ":" + cfg.Server.Port
+	srv := &http.Server{
+		Addr:        addr,
+		Handler:     r,
+		ReadTimeout: 15 * time.Second,
+		// Replies may wait on the assistant (up to 10s) and the portal.
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		log.Println("Shutting down server...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := jobs.Stop(shutdownCtx); err != nil {
+			log.Printf("Scheduler shutdown error: %v", err)
+		}
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Server shutdown error: %v", err)
+		}
+	}()
+
+	jobs.Start()
+
+	log.Printf("nexus-sms starting on %s", addr)
+	log.Printf("  Website: http://localhost%s/", addr)
+	log.Printf("  SMS:     http://localhost%s/sms", addr)
+	log.Printf("  Voice:   http://localhost%s/voice", addr)
+
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatalf("Server error: %v", err)
+	}
+	log.Println("Server stopped")
+}
+
+// mustAddJob registers a background job; names are fixed in code, so a
+// failure is a programming error.
+func mustAddJob(jobs *scheduler.Scheduler, job scheduler.Job) {
+	if err := jobs.Add(job); err != nil {
+		log.Fatalf("Failed to schedule %s: %v", job.Name, err)
+	}
+}
+
+// webhookAuth returns the middleware verifying Twilio webhook signatures.
+// With SMS_PROVIDER=twilio a TWILIO_AUTH_TOKEN is required; with any other
+// provider an empty token leaves webhooks unverified (nil) for local
+// development, which is logged loudly since anyone could then post texts
+// as any number.
+func webhookAuth(cfg *config.Config) (func(http.Handler) http.Handler, error) {
+	if cfg.Twilio.AuthToken != "" {
+		return handlers.TwilioSignatureMiddleware(cfg.Twilio.AuthToken, cfg.Server.BaseURL), nil
+	}
+	if cfg.SMS.Provider == "twilio" {
+		return nil, fmt.Errorf("SMS_PROVIDER=twilio requires TWILIO_AUTH_TOKEN to verify webhook signatures")
+	}
+	log.Println("WARNING: ************************************************************")
+	log.Printf("WARNING: TWILIO_AUTH_TOKEN is empty (SMS_PROVIDER=%s) — webhook signatures are NOT verified", cfg.SMS.Provider)
+	log.Println("WARNING: anyone who can reach /sms and /voice can send texts as any number")
+	log.Println("WARNING: never expose this server publicly without TWILIO_AUTH_TOKEN")
+	log.Println("WARNING: ************************************************************")
+	return nil, nil
+}
+
+// newSender returns the outbound SMS provider selected by SMS_PROVIDER.
+func newSender(cfg *config.Config) (sms.Sender, error) {
+	switch cfg.SMS.Provider {
+	case "twilio":
+		if cfg.Twilio.AccountSID == "" || cfg.Twilio.AuthToken == "" || cfg.Twilio.FromNumber == "" {
+			return nil, fmt.Errorf("SMS_PROVIDER=twilio requires TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_FROM_NUMBER")
+		}
+		log.Printf("Outbound SMS via Twilio from %s", cfg.Twilio.FromNumber)
+		client := twilio.NewClient(cfg.Twilio.AccountSID, cfg.Twilio.AuthToken, cfg.Twilio.FromNumber)
+		if cfg.Server.BaseURL != "" {
+			client.StatusCallback = strings.TrimRight(cfg.Server.BaseURL, "/") + "/sms/status"
+		} else {
+			log.Println("PUBLIC_BASE_URL is empty — delivery status callbacks are disabled")
+		}
+		return client, nil
+	case "fake":
+		log.Printf("Outbound SMS via fake provider (outbox: %q)", cfg.SMS.OutboxPath)
+		return sms.NewFakeSender(cfg.SMS.OutboxPath), nil
+	default:
+		return nil, fmt.Errorf("unknown SMS_PROVIDER %q (want twilio or fake)", cfg.SMS.Provider)
+	}
+}
+
+// newRules creates the automation engine and, when nexus-cal is
+// configured, schedules a job watching reminder feeds for
+// cal.event.starting.
+func newRules(db *database.DB, sender sms.Sender, cal *calclient.Client, loc *time.Location, lead time.Duration, jobs *scheduler.Scheduler) *rules.Engine {
+	deps := rules.Deps{
+		Sender:   sender,
+		Location: loc,
+		FeedForPhone: func(phone string) (string, error) {
+			feed, err := db.CalFeedByPhone(phone)
+			if err != nil || feed == nil {
+				return "", err
+			}
+			return feed.FeedID, nil
+		},
+	}
+	if cal != nil {
+		deps.Calendar = cal
+	}
+	engine := rules.NewEngine(db, deps)
+
+	if cal != nil {
+		feeds := func() ([]rules.FeedRef, error) {
+			list, err := db.CalFeeds()
+			if err != nil {
+				return nil, err
+			}
+			refs := make([]rules.FeedRef, len(list))
+			for i, f := range list {
+				refs[i] = rules.FeedRef{Phone: f.Phone, FeedID: f.FeedID}
+			}
+			return refs, nil
+		}
+		watcher := rules.NewCalendarWatcher(engine, cal, feeds, lead)
+		mustAddJob(jobs, scheduler.Job{
+			Name:     "rules-calendar",
+			Schedule: scheduler.Every(time.Minute),
+			Timeout:  time.Minute,
+			Run: func(ctx context.Context) error {
+				return watcher.Check(ctx, time.Now())
+			},
+		})
+	}
+	return engine
+}
+
+// newAssistant returns the responder selected by ASSISTANT_PROVIDER.
+func newAssistant(cfg *config.Config) (assistant.Assistant, error) {
+	switch cfg.Assistant.Provider {
+	case "openai":
+		if cfg.Assistant.URL == "" || cfg.Assistant.Model == "" {
+			return nil, fmt.Errorf("ASSISTANT_PROVIDER=openai requires ASSISTANT_URL and ASSISTANT_MODEL")
+		}
+		log.Printf("Assistant via %s (model %s)", cfg.Assistant.URL, cfg.Assistant.Model)
+		return assistant.NewOpenAI(cfg.Assistant.URL, cfg.Assistant.APIKey, cfg.Assistant.Model), nil
+	case "stub":
+		log.Println("Assistant via built-in stub replies")
+		return assistant.Stub{}, nil
+	default:
+		return nil, fmt.Errorf("unknown ASSISTANT_PROVIDER %q (want stub or openai)", cfg.Assistant.Provider)
+	}
+}
+
+// newClassifier returns the keyword classifier selected by
+// ESCALATION_KEYWORDS, or nil if escalation is turned off.
+func newClassifier(cfg *config.Config) escalation.Classifier {
+	switch list := strings.TrimSpace(cfg.Assistant.EscalationKeywords); {
+	case strings.EqualFold(list, "none"):
+		log.Println("ESCALATION_KEYWORDS is none — escalation to a person is disabled")
+		return nil
+	case list == "":
+		return escalation.NewKeywords(escalation.DefaultKeywords)
+	default:
+		return escalation.NewKeywords(escalation.ParseKeywords(list))
+	}
+}
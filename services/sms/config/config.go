@@ -0,0 +1,196 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config holds all configuration for the nexus SMS server.
+type Config struct {
+	Server    ServerConfig
+	DB        DBConfig
+	Twilio    TwilioConfig
+	SMS       SMSConfig
+	Cal       CalConfig
+	Portal    PortalConfig
+	Assistant AssistantConfig
+}
+
+// ServerConfig holds HTTP server settings.
+type ServerConfig struct {
+	Port string
+
+	// StaticDir holds the public website (index.html, images) served at /.
+	StaticDir string
+
+	// Timezone is the IANA zone used to interpret dates in messages
+	// (e.g. "friday 3pm").
+	Timezone string
+
+	// BaseURL is the public URL Twilio uses to reach this server
+	// (e.g. https://abc123.ngrok.io). It is needed to reconstruct the
+	// exact URL Twilio signed. If empty, the URL is derived from the request.
+	BaseURL string
+
+	// MediaSecret signs the links to stored MMS attachments that replies
+	// include. Empty disables the links and GET /media.
+	MediaSecret string
+
+	// AdminToken is the bearer token for /admin debugging endpoints.
+	// Empty disables them.
+	AdminToken string
+}
+
+// DBConfig holds database settings.
+type DBConfig struct {
+	Path     string // path to SQLite database file
+	MediaDir string // content-addressed blob directory for MMS attachments
+}
+
+// TwilioConfig holds Twilio account settings.
+type TwilioConfig struct {
+	AccountSID string // used for the REST API (outbound messages)
+	AuthToken  string // REST API password; also verifies X-Twilio-Signature
+	FromNumber string // our Twilio number, E.164
+}
+
+// SMSConfig selects the outbound message provider.
+type SMSConfig struct {
+	// Provider is "twilio" to send real messages or "fake" to record them
+	// locally without network access.
+	Provider string
+	// OutboxPath is where the fake provider appends sent messages as JSON
+	// lines. Empty keeps them in memory only.
+	OutboxPath string
+	// HelpMessage is sent in reply to HELP, ahead of the command list.
+	HelpMessage string
+	// ThreadTimeout is how long a sender can be idle before their next
+	// message starts a new conversation thread.
+	ThreadTimeout time.Duration
+	// HistoryTurns is how many recent thread messages replies can see.
+	HistoryTurns int
+	// ReplyMode is "split" to send long replies as numbered single-segment
+	// messages or "cap" to shorten them to MaxSegments.
+	ReplyMode string
+	// MaxSegments bounds a reply: parts in split mode, concatenated
+	// segments in cap mode.
+	MaxSegments int
+	// RateBurst is how many messages a sender can send at once; one more
+	// is allowed every RateRefill. Zero disables rate limiting.
+	RateBurst  int
+	RateRefill time.Duration
+}
+
+// CalConfig holds settings for the nexus-cal integration.
+type CalConfig struct {
+	URL string // nexus-cal base URL; empty disables REMIND
+	// RuleLead is how long before an event starts cal.event.starting
+	// rules fire.
+	RuleLead time.Duration
+}
+
+// PortalConfig holds settings for linking senders to portal accounts.
+type PortalConfig struct {
+	URL   string // portal base URL; empty disables account linking
+	Token string // shared secret for the portal's /internal API
+
+	// SignupURL is sent to unknown numbers. Defaults to URL + "/signup".
+	SignupURL string
+}
+
+// AssistantConfig selects the model that answers free-form texts.
+type AssistantConfig struct {
+	// Provider is "stub" for the built-in rule-based replies or "openai"
+	// for an OpenAI-compatible chat completions API.
+	Provider string
+	URL      string // API base URL including version, e.g. https://api.openai.com/v1
+	APIKey   string
+	Model    string
+	// MaxReplyChars bounds replies so they fit in a couple of SMS segments.
+	MaxReplyChars int
+	// EscalationKeywords is a comma-separated list of phrases that flag a
+	// text for a person. Empty uses the built-in list; "none" turns
+	// escalation off.
+	EscalationKeywords string
+}
+
+// Load reads configuration from environment variables with sensible defaults.
+func Load() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:      getEnv("PORT", "8080"),
+			StaticDir: getEnv("STATIC_DIR", "services/sms/static"),
+
+			BaseURL:     getEnv("PUBLIC_BASE_URL", ""),
+			MediaSecret: getEnv("MEDIA_LINK_SECRET", ""),
+			Timezone:    getEnv("TIMEZONE", "America/Los_Angeles"),
+
+			AdminToken: getEnv("ADMIN_TOKEN", ""),
+		},
+		DB: DBConfig{
+			Path:     getEnv("DB_PATH", "nexus.db"),
+			MediaDir: getEnv("MEDIA_DIR", "media"),
+		},
+		Twilio: TwilioConfig{
+			AccountSID: getEnv("TWILIO_ACCOUNT_SID", ""),
+			AuthToken:  getEnv("TWILIO_AUTH_TOKEN", ""),
+			FromNumber: getEnv("TWILIO_FROM_NUMBER", ""),
+		},
+		SMS: SMSConfig{
+			Provider:   getEnv("SMS_PROVIDER", "fake"),
+			OutboxPath: getEnv("SMS_OUTBOX_PATH", "outbox.jsonl"),
+			HelpMessage: getEnv("SMS_HELP_MESSAGE",
+				"nexus: personal assistant by text. Msg & data rates may apply. Reply STOP to unsubscribe. Contact: dev@jredh.com"),
+			ThreadTimeout: getEnvDuration("THREAD_TIMEOUT", 30*time.Minute),
+			HistoryTurns:  getEnvInt("HISTORY_TURNS", 10),
+			ReplyMode:     getEnv("SMS_REPLY_MODE", "split"),
+			MaxSegments:   getEnvInt("SMS_MAX_SEGMENTS", 3),
+			RateBurst:     getEnvInt("RATE_LIMIT_BURST", 10),
+			RateRefill:    getEnvDuration("RATE_LIMIT_REFILL", 10*time.Second),
+		},
+		Cal: CalConfig{
+			URL:      getEnv("CAL_URL", ""),
+			RuleLead: getEnvDuration("CAL_RULE_LEAD", 15*time.Minute),
+		},
+		Portal: PortalConfig{
+			URL:       getEnv("PORTAL_URL", ""),
+			Token:     getEnv("INTERNAL_API_TOKEN", ""),
+			SignupURL: getEnv("SIGNUP_URL", ""),
+		},
+		Assistant: AssistantConfig{
+			Provider:      getEnv("ASSISTANT_PROVIDER", "stub"),
+			URL:           getEnv("ASSISTANT_URL", "https://api.openai.com/v1"),
+			APIKey:        getEnv("ASSISTANT_API_KEY", ""),
+			Model:         getEnv("ASSISTANT_MODEL", "gpt-4o-mini"),
+			MaxReplyChars: getEnvInt("ASSISTANT_MAX_REPLY_CHARS", 320),
+
+			EscalationKeywords: getEnv("ESCALATION_KEYWORDS", ""),
+		},
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intVal, err := strconv.Atoi(value); err == nil {
+			return intVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}